@@ -3,8 +3,10 @@ package main
 import (
 	"context"
 	"dish-service/src/config"
+	"dish-service/src/inventory"
 	"dish-service/src/queue"
 	"dish-service/src/routes"
+	"dish-service/src/search"
 	"log"
 	"os"
 	"time"
@@ -30,7 +32,22 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
+	go func() {
+		if err := search.BackfillDishGrams(context.Background()); err != nil {
+			log.Println("Failed to backfill dish search grams:", err)
+		}
+	}()
 	go queue.UpdateRating(client)
+	go queue.DishDetailsResponder(client)
+	go queue.DishDetailsBatchResponder(client)
+	go queue.DishPricingResponder(client)
+	go queue.DishCustomizationsResponder(client)
+	go queue.ComboPricingResponder(client)
+	go queue.SyncRestaurantLocations(client)
+	go queue.RestaurantLocationResponder(client)
+	go queue.StockReservationResponder(client, inventory.Reserve)
+	go queue.ConsumeStockReleases(client, inventory.Release)
+	go inventory.RunStockReset(config.StockResetInterval())
 	// Ensure the database disconnects properly
 	defer func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
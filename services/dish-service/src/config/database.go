@@ -10,7 +10,12 @@ import (
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
-var DishCollection *mongo.Collection
+var (
+	DishCollection               *mongo.Collection
+	RestaurantLocationCollection *mongo.Collection
+	ComboCollection              *mongo.Collection
+	StockReservationCollection   *mongo.Collection
+)
 
 func ConnectDB() (*mongo.Client, error) {
 	mongo_uri := os.Getenv("DATABASE_URL")
@@ -29,6 +34,13 @@ func ConnectDB() (*mongo.Client, error) {
 	}
 
 	DishCollection = client.Database("customDish").Collection("dishes")
+	RestaurantLocationCollection = client.Database("customDish").Collection("restaurantLocations")
+	ComboCollection = client.Database("customDish").Collection("combos")
+	StockReservationCollection = client.Database("customDish").Collection("stockReservations")
+
+	if err := EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
 
 	log.Println("Connected to MongoDB!")
 	return client, nil
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// EnsureIndexes creates the indexes dish-service relies on for its queries.
+func EnsureIndexes(ctx context.Context) error {
+	_, err := DishCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "restaurant", Value: 1}}},
+		{
+			Keys: bson.D{{Key: "restaurant", Value: 1}, {Key: "sku", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"sku": bson.M{"$type": "string"}}),
+		},
+		// Keyset pagination indexes, one per listing sort. Each serves both
+		// directions.
+		{Keys: bson.D{{Key: "price", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "rating", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "popularity", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "preparationTime", Value: 1}, {Key: "_id", Value: 1}}},
+		{
+			Keys: bson.D{
+				{Key: "name", Value: "text"},
+				{Key: "description", Value: "text"},
+				{Key: "category", Value: "text"},
+				{Key: "tags", Value: "text"},
+			},
+			Options: options.Index().
+				SetName("dish_text").
+				SetWeights(bson.D{
+					{Key: "name", Value: 10},
+					{Key: "tags", Value: 5},
+					{Key: "category", Value: 3},
+					{Key: "description", Value: 1},
+				}),
+		},
+		{Keys: bson.D{{Key: "searchGrams", Value: 1}}},
+		// Daily stock reset sweep.
+		{Keys: bson.D{{Key: "stock.nextResetAt", Value: 1}}},
+		{Keys: bson.D{{Key: "variants.stock.nextResetAt", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = RestaurantLocationCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "location", Value: "2dsphere"}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = ComboCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "restaurant", Value: 1}, {Key: "_id", Value: -1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = StockReservationCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "createdAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(StockReservationRetention().Seconds())),
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "updatedAt", Value: 1}},
+		},
+	})
+	return err
+}
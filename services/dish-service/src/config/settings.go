@@ -0,0 +1,82 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Settings are read from the environment on use so values from .env are
+// picked up.
+
+// DefaultSearchRadiusKm bounds a location search that did not ask for a radius.
+func DefaultSearchRadiusKm() float64 {
+	return envFloat("DISH_SEARCH_RADIUS_KM", 10)
+}
+
+// MaxSearchRadiusKm caps the radiusKm a customer may ask for.
+func MaxSearchRadiusKm() float64 {
+	return envFloat("DISH_MAX_SEARCH_RADIUS_KM", 50)
+}
+
+// DefaultDeliveryRadiusKm is used for restaurants that never published one.
+func DefaultDeliveryRadiusKm() float64 {
+	return envFloat("RESTAURANT_DEFAULT_DELIVERY_RADIUS_KM", 7)
+}
+
+// StockReservationRetention is how long stock reservations are kept, and so
+// how long after an order is placed its cancellation can still give stock
+// back.
+func StockReservationRetention() time.Duration {
+	return time.Duration(envFloat("DISH_STOCK_RESERVATION_RETENTION_HOURS", 48) * float64(time.Hour))
+}
+
+// StockReservationTimeout is how long a reservation may stay pending
+// before the stock it took is given back as abandoned.
+func StockReservationTimeout() time.Duration {
+	return time.Duration(envFloat("DISH_STOCK_RESERVATION_TIMEOUT_SECONDS", 60) * float64(time.Second))
+}
+
+// StockResetInterval is how often dishes are checked for a due stock reset.
+func StockResetInterval() time.Duration {
+	return time.Duration(envFloat("DISH_STOCK_RESET_INTERVAL_SECONDS", 60) * float64(time.Second))
+}
+
+// MenuImportMaxRows caps how many dishes one menu import may contain.
+func MenuImportMaxRows() int {
+	return int(envFloat("MENU_IMPORT_MAX_ROWS", 1000))
+}
+
+// DishImageMaxBytes caps the size of an uploaded dish image.
+func DishImageMaxBytes() int {
+	return int(envFloat("DISH_IMAGE_MAX_MB", 10) * (1 << 20))
+}
+
+// DishImageMinSide and DishImageMaxSide bound, in pixels, the shorter and
+// longer side of an uploaded dish image.
+func DishImageMinSide() int {
+	return int(envFloat("DISH_IMAGE_MIN_SIDE_PX", 300))
+}
+
+func DishImageMaxSide() int {
+	return int(envFloat("DISH_IMAGE_MAX_SIDE_PX", 6000))
+}
+
+// DishImageMaxCount caps how many images a dish's gallery may hold.
+func DishImageMaxCount() int {
+	return int(envFloat("DISH_IMAGE_MAX_COUNT", 10))
+}
+
+func envFloat(key string, fallback float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid %s %q, using %v\n", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"dish-service/src/model"
+	"time"
+)
+
+// DishListing is a dish as returned to customers, with whether it can be
+// ordered right now. AvailableFrom is when a dish that is only outside its
+// schedule can be ordered next.
+type DishListing struct {
+	model.Dish     `bson:",inline"`
+	IsAvailableNow bool       `bson:"-" json:"isAvailableNow"`
+	AvailableFrom  *time.Time `bson:"-" json:"availableFrom,omitempty"`
+}
+
+func listDish(dish model.Dish, now time.Time) DishListing {
+	available, next := dish.AvailableAt(now)
+	return DishListing{Dish: dish, IsAvailableNow: available, AvailableFrom: next}
+}
+
+func listDishes(dishes []model.Dish, now time.Time) []DishListing {
+	listings := make([]DishListing, 0, len(dishes))
+	for _, dish := range dishes {
+		listings = append(listings, listDish(dish, now))
+	}
+	return listings
+}
@@ -0,0 +1,311 @@
+package controllers
+
+import (
+	"context"
+	"dish-service/src/config"
+	"dish-service/src/model"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type UpdateComboInput struct {
+	Name               *string            `json:"name"`
+	Description        *string            `json:"description"`
+	DisplayImage       *string            `json:"displayImage"`
+	Price              *int               `json:"price"`
+	Tags               []string           `json:"tags"`
+	Groups             []model.ComboGroup `json:"groups"`
+	AvailabilityStatus *string            `json:"availabilityStatus"`
+}
+
+// ComboListing is a combo with whether it can be ordered right now, which
+// depends on its component dishes.
+type ComboListing struct {
+	model.Combo
+	Available bool `json:"available"`
+}
+
+func AddCombo(client *mongo.Client, c *gin.Context) {
+	var combo model.Combo
+	if err := c.ShouldBindJSON(&combo); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	restaurantId, ok := loggedInRestaurant(c)
+	if !ok {
+		return
+	}
+	combo.ID = bson.NilObjectID
+	combo.RestaurantId = restaurantId
+
+	dishes, err := loadComponentDishes(context.TODO(), combo)
+	if err != nil {
+		log.Println("Error fetching combo dishes:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add combo"})
+		return
+	}
+	if err := model.PrepareCombo(&combo, dishes, nil); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := config.ComboCollection.InsertOne(context.TODO(), combo)
+	if err != nil {
+		log.Println("Error inserting combo:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add combo"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Combo added successfully!", "comboId": result.InsertedID})
+}
+
+// GetAllCombos lists combos, optionally of one restaurant, newest first.
+func GetAllCombos(client *mongo.Client, c *gin.Context) {
+	filter := bson.M{}
+	if restaurantId := c.Query("restaurantId"); restaurantId != "" {
+		filter["restaurant"] = restaurantId
+	}
+	page, limit := parsePagination(c)
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetLimit(limit).
+		SetSkip((page - 1) * limit)
+	combos, err := findComboListings(context.TODO(), filter, findOptions)
+	if err != nil {
+		log.Println("Error fetching combos:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch combos"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Combos fetched successfully!",
+		"combos":      combos,
+		"currentPage": page,
+	})
+}
+
+func GetComboDetails(client *mongo.Client, c *gin.Context) {
+	objectId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid combo ID"})
+		return
+	}
+	combos, err := findComboListings(context.TODO(), bson.M{"_id": objectId}, options.Find())
+	if err != nil {
+		log.Println("Error fetching combo:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch combo"})
+		return
+	}
+	if len(combos) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Combo not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Combo fetched successfully!", "combo": combos[0]})
+}
+
+func UpdateCombo(client *mongo.Client, c *gin.Context) {
+	var input UpdateComboInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	combo, ok := findOwnedCombo(c)
+	if !ok {
+		return
+	}
+	existingGroups := combo.Groups
+
+	if input.Name != nil {
+		combo.Name = *input.Name
+	}
+	if input.Description != nil {
+		combo.Description = *input.Description
+	}
+	if input.DisplayImage != nil {
+		combo.DisplayImage = *input.DisplayImage
+	}
+	if input.Price != nil {
+		combo.Price = *input.Price
+	}
+	if input.Tags != nil {
+		combo.Tags = input.Tags
+	}
+	if input.Groups != nil {
+		combo.Groups = input.Groups
+	}
+	if input.AvailabilityStatus != nil {
+		combo.AvailabilityStatus = *input.AvailabilityStatus
+	}
+
+	dishes, err := loadComponentDishes(context.TODO(), *combo)
+	if err != nil {
+		log.Println("Error fetching combo dishes:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update combo"})
+		return
+	}
+	if err := model.PrepareCombo(combo, dishes, existingGroups); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err = config.ComboCollection.ReplaceOne(context.TODO(), bson.M{"_id": combo.ID}, combo)
+	if err != nil {
+		log.Println("Error updating combo:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update combo"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Combo updated successfully!"})
+}
+
+func DeleteCombo(client *mongo.Client, c *gin.Context) {
+	combo, ok := findOwnedCombo(c)
+	if !ok {
+		return
+	}
+	if _, err := config.ComboCollection.DeleteOne(context.TODO(), bson.M{"_id": combo.ID}); err != nil {
+		log.Println("Error deleting combo:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete combo"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Combo deleted successfully!"})
+}
+
+// GetRestaurantMenu lists a restaurant's dishes and combos together.
+func GetRestaurantMenu(client *mongo.Client, c *gin.Context) {
+	restaurantId := c.Param("restaurantId")
+
+	cursor, err := config.DishCollection.Find(context.TODO(), bson.M{"restaurant": restaurantId},
+		options.Find().SetSort(bson.D{{Key: "category", Value: 1}, {Key: "name", Value: 1}}))
+	if err != nil {
+		log.Println("Error fetching dishes:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch menu"})
+		return
+	}
+	var dishes []model.Dish
+	if err := cursor.All(context.TODO(), &dishes); err != nil {
+		log.Println("Error decoding dishes:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch menu"})
+		return
+	}
+
+	combos, err := findComboListings(context.TODO(), bson.M{"restaurant": restaurantId},
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		log.Println("Error fetching combos:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch menu"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Menu fetched successfully!",
+		"dishes":  listDishes(dishes, time.Now()),
+		"combos":  combos,
+	})
+}
+
+// findComboListings loads combos and derives their availability from their
+// component dishes, fetched with one query.
+func findComboListings(ctx context.Context, filter bson.M, findOptions *options.FindOptionsBuilder) ([]ComboListing, error) {
+	cursor, err := config.ComboCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	var combos []model.Combo
+	if err := cursor.All(ctx, &combos); err != nil {
+		return nil, err
+	}
+
+	var ids []bson.ObjectID
+	for _, combo := range combos {
+		ids = append(ids, combo.ComponentIDs()...)
+	}
+	dishes, err := loadDishes(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	listings := make([]ComboListing, 0, len(combos))
+	for _, combo := range combos {
+		listings = append(listings, ComboListing{Combo: combo, Available: combo.Availability(dishes, now)})
+	}
+	return listings, nil
+}
+
+func loadComponentDishes(ctx context.Context, combo model.Combo) (map[string]model.Dish, error) {
+	return loadDishes(ctx, combo.ComponentIDs())
+}
+
+// loadDishes fetches dishes by id, keyed by hex id.
+func loadDishes(ctx context.Context, ids []bson.ObjectID) (map[string]model.Dish, error) {
+	dishes := map[string]model.Dish{}
+	if len(ids) == 0 {
+		return dishes, nil
+	}
+	cursor, err := config.DishCollection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	var found []model.Dish
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	for _, dish := range found {
+		dishes[dish.ID.Hex()] = dish
+	}
+	return dishes, nil
+}
+
+// findOwnedCombo loads the combo named by the id parameter and checks it
+// belongs to the logged in restaurant. It writes the error response and
+// returns false when it does not.
+func findOwnedCombo(c *gin.Context) (*model.Combo, bool) {
+	objectId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid combo ID"})
+		return nil, false
+	}
+	restaurantId, ok := loggedInRestaurant(c)
+	if !ok {
+		return nil, false
+	}
+
+	var combo model.Combo
+	err = config.ComboCollection.FindOne(context.TODO(), bson.M{"_id": objectId}).Decode(&combo)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Combo not found"})
+		return nil, false
+	}
+	if err != nil {
+		log.Println("Error fetching combo:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch combo"})
+		return nil, false
+	}
+	if restaurantId != combo.RestaurantId {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only update combos from your restaurant"})
+		return nil, false
+	}
+	return &combo, true
+}
+
+// loggedInRestaurant returns the restaurant id set by AuthMiddleware,
+// writing the error response when it is missing.
+func loggedInRestaurant(c *gin.Context) (string, bool) {
+	restaurantId, exists := c.Get("restaurantId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: No restaurant ID found"})
+		return "", false
+	}
+	restaurantIdStr, ok := restaurantId.(string)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid restaurant ID format"})
+		return "", false
+	}
+	return restaurantIdStr, true
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"dish-service/src/model"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var (
+	errInvalidSort   = errors.New("invalid sort")
+	errInvalidCursor = errors.New("invalid cursor")
+)
+
+// pageCursor is what an opaque cursor token carries: the sort it was issued
+// for and the sort value and id of the last dish on the previous page.
+type pageCursor struct {
+	Sort  string `json:"s"`
+	Value *int64 `json:"v,omitempty"`
+	ID    string `json:"id"`
+}
+
+func encodeCursor(cursor pageCursor) string {
+	body, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(body)
+}
+
+func decodeCursor(token string) (pageCursor, error) {
+	var cursor pageCursor
+	body, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, errInvalidCursor
+	}
+	if err := json.Unmarshal(body, &cursor); err != nil {
+		return cursor, errInvalidCursor
+	}
+	return cursor, nil
+}
+
+// dishSortFields maps each sort option to its field and default direction.
+// newest sorts on _id, whose leading bytes are the creation time. Every
+// other sort breaks ties on _id so the order is total and stable.
+var dishSortFields = map[string]struct {
+	field     string
+	direction int
+}{
+	"price":           {"price", 1},
+	"rating":          {"rating", -1},
+	"popularity":      {"popularity", -1},
+	"preparationTime": {"preparationTime", 1},
+	"newest":          {"_id", -1},
+}
+
+type dishSort struct {
+	name      string
+	field     string
+	direction int
+}
+
+// parseDishSort reads the sort and order query parameters. Without sort,
+// dishes are listed newest first.
+func parseDishSort(c *gin.Context) (dishSort, error) {
+	name := c.DefaultQuery("sort", "newest")
+	spec, ok := dishSortFields[name]
+	if !ok {
+		return dishSort{}, errInvalidSort
+	}
+	sort := dishSort{name: name, field: spec.field, direction: spec.direction}
+	switch strings.ToLower(c.Query("order")) {
+	case "":
+	case "asc":
+		sort.direction = 1
+	case "desc":
+		sort.direction = -1
+	default:
+		return dishSort{}, errInvalidSort
+	}
+	return sort, nil
+}
+
+// key identifies the sort inside a cursor so a token cannot be replayed
+// against a different ordering.
+func (s dishSort) key() string {
+	if s.direction < 0 {
+		return s.name + ":desc"
+	}
+	return s.name + ":asc"
+}
+
+func (s dishSort) options() bson.D {
+	if s.field == "_id" {
+		return bson.D{{Key: "_id", Value: s.direction}}
+	}
+	return bson.D{{Key: s.field, Value: s.direction}, {Key: "_id", Value: s.direction}}
+}
+
+// after returns the filter selecting the dishes that follow cursor.
+func (s dishSort) after(cursor pageCursor) (bson.M, error) {
+	if cursor.Sort != s.key() {
+		return nil, errInvalidCursor
+	}
+	id, err := bson.ObjectIDFromHex(cursor.ID)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	op := "$gt"
+	if s.direction < 0 {
+		op = "$lt"
+	}
+	if s.field == "_id" {
+		return bson.M{"_id": bson.M{op: id}}, nil
+	}
+	if cursor.Value == nil {
+		return nil, errInvalidCursor
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{s.field: bson.M{op: *cursor.Value}},
+		bson.M{s.field: *cursor.Value, "_id": bson.M{op: id}},
+	}}, nil
+}
+
+// cursorAfter returns the token for the page following dish.
+func (s dishSort) cursorAfter(dish model.Dish) string {
+	cursor := pageCursor{Sort: s.key(), ID: dish.ID.Hex()}
+	var value int64
+	switch s.field {
+	case "price":
+		value = int64(dish.Price)
+	case "rating":
+		value = int64(dish.Rating)
+	case "popularity":
+		value = int64(dish.Popularity)
+	case "preparationTime":
+		value = int64(dish.PreparationTime)
+	default:
+		return encodeCursor(cursor)
+	}
+	cursor.Value = &value
+	return encodeCursor(cursor)
+}
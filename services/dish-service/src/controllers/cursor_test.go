@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"dish-service/src/model"
+	"encoding/base64"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestParseDishSort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		query   string
+		want    dishSort
+		wantErr bool
+	}{
+		{"", dishSort{name: "newest", field: "_id", direction: -1}, false},
+		{"sort=price", dishSort{name: "price", field: "price", direction: 1}, false},
+		{"sort=rating", dishSort{name: "rating", field: "rating", direction: -1}, false},
+		{"sort=rating&order=asc", dishSort{name: "rating", field: "rating", direction: 1}, false},
+		{"sort=name", dishSort{}, true},
+		{"sort=price&order=sideways", dishSort{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/?"+tt.query, nil)
+			got, err := parseDishSort(c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDishSort() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseDishSort() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDishCursorRoundTrip(t *testing.T) {
+	dish := model.Dish{ID: bson.NewObjectID(), Price: 180, Rating: 4, PreparationTime: 20}
+
+	tests := []struct {
+		name string
+		sort dishSort
+		want bson.M
+	}{
+		{
+			name: "newest",
+			sort: dishSort{name: "newest", field: "_id", direction: -1},
+			want: bson.M{"_id": bson.M{"$lt": dish.ID}},
+		},
+		{
+			name: "cheapest first",
+			sort: dishSort{name: "price", field: "price", direction: 1},
+			want: bson.M{"$or": bson.A{
+				bson.M{"price": bson.M{"$gt": int64(180)}},
+				bson.M{"price": int64(180), "_id": bson.M{"$gt": dish.ID}},
+			}},
+		},
+		{
+			name: "best rated first",
+			sort: dishSort{name: "rating", field: "rating", direction: -1},
+			want: bson.M{"$or": bson.A{
+				bson.M{"rating": bson.M{"$lt": int64(4)}},
+				bson.M{"rating": int64(4), "_id": bson.M{"$lt": dish.ID}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := decodeCursor(tt.sort.cursorAfter(dish))
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+			got, err := tt.sort.after(cursor)
+			if err != nil {
+				t.Fatalf("after() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("after() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDishCursorRejected(t *testing.T) {
+	price := dishSort{name: "price", field: "price", direction: 1}
+	rating := dishSort{name: "rating", field: "rating", direction: -1}
+	id := bson.NewObjectID().Hex()
+
+	tests := []struct {
+		name   string
+		sort   dishSort
+		cursor pageCursor
+	}{
+		{"another sort's cursor", rating, pageCursor{Sort: price.key(), Value: new(int64), ID: id}},
+		{"reversed order", dishSort{name: "price", field: "price", direction: -1}, pageCursor{Sort: price.key(), Value: new(int64), ID: id}},
+		{"invalid id", price, pageCursor{Sort: price.key(), Value: new(int64), ID: "42"}},
+		{"missing value", price, pageCursor{Sort: price.key(), ID: id}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.sort.after(tt.cursor); err != errInvalidCursor {
+				t.Errorf("after() error = %v, want errInvalidCursor", err)
+			}
+		})
+	}
+
+	for _, token := range []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("not json")),
+	} {
+		if _, err := decodeCursor(token); err != errInvalidCursor {
+			t.Errorf("decodeCursor(%q) error = %v, want errInvalidCursor", token, err)
+		}
+	}
+}
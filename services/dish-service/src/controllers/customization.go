@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"context"
+	"dish-service/src/config"
+	"dish-service/src/model"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func GetDishCustomizations(client *mongo.Client, c *gin.Context) {
+	objectId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dish ID"})
+		return
+	}
+
+	var dish model.Dish
+	err = config.DishCollection.FindOne(context.TODO(), bson.M{"_id": objectId}).Decode(&dish)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dish not found"})
+		return
+	}
+
+	customizations := dish.Customizations
+	if customizations == nil {
+		customizations = []model.CustomizationOption{}
+	}
+	c.JSON(http.StatusOK, gin.H{"customizations": customizations})
+}
+
+func AddDishCustomization(client *mongo.Client, c *gin.Context) {
+	var option model.CustomizationOption
+	if err := c.ShouldBindJSON(&option); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	options := []model.CustomizationOption{option}
+	if err := model.ValidateCustomizationOptions(options); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dish, ok := findOwnedDish(c)
+	if !ok {
+		return
+	}
+
+	// The key check in the filter keeps concurrent adds from creating duplicates
+	result, err := config.DishCollection.UpdateOne(context.TODO(),
+		bson.M{"_id": dish.ID, "customizations.key": bson.M{"$ne": option.Key}},
+		bson.M{"$push": bson.M{"customizations": options[0]}},
+	)
+	if err != nil {
+		log.Println("Error adding customization:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add customization"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Customization " + option.Key + " already exists"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Customization added successfully!", "customization": options[0]})
+}
+
+func UpdateDishCustomization(client *mongo.Client, c *gin.Context) {
+	key := c.Param("key")
+	var option model.CustomizationOption
+	if err := c.ShouldBindJSON(&option); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if option.Key == "" {
+		option.Key = key
+	}
+	if option.Key != key {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Customization key cannot be changed"})
+		return
+	}
+	options := []model.CustomizationOption{option}
+	if err := model.ValidateCustomizationOptions(options); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dish, ok := findOwnedDish(c)
+	if !ok {
+		return
+	}
+
+	result, err := config.DishCollection.UpdateOne(context.TODO(),
+		bson.M{"_id": dish.ID, "customizations.key": key},
+		bson.M{"$set": bson.M{"customizations.$": options[0]}},
+	)
+	if err != nil {
+		log.Println("Error updating customization:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update customization"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customization not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Customization updated successfully!", "customization": options[0]})
+}
+
+func DeleteDishCustomization(client *mongo.Client, c *gin.Context) {
+	key := c.Param("key")
+	dish, ok := findOwnedDish(c)
+	if !ok {
+		return
+	}
+
+	result, err := config.DishCollection.UpdateOne(context.TODO(),
+		bson.M{"_id": dish.ID, "customizations.key": key},
+		bson.M{"$pull": bson.M{"customizations": bson.M{"key": key}}},
+	)
+	if err != nil {
+		log.Println("Error deleting customization:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete customization"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customization not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Customization deleted successfully!"})
+}
+
+// findOwnedDish loads the dish named by the id parameter and checks it
+// belongs to the logged in restaurant. It writes the error response and
+// returns false when it does not.
+func findOwnedDish(c *gin.Context) (*model.Dish, bool) {
+	objectId, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dish ID"})
+		return nil, false
+	}
+	restaurantIdStr, ok := loggedInRestaurant(c)
+	if !ok {
+		return nil, false
+	}
+
+	var dish model.Dish
+	err = config.DishCollection.FindOne(context.TODO(), bson.M{"_id": objectId}).Decode(&dish)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dish not found"})
+		return nil, false
+	}
+	if err != nil {
+		log.Println("Error fetching dish:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dish"})
+		return nil, false
+	}
+	if restaurantIdStr != dish.RestaurantId {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only update dishes from your restaurant"})
+		return nil, false
+	}
+	return &dish, true
+}
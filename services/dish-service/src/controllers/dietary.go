@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"dish-service/src/model"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// prepareDietary normalizes a dish's allergens and dietary labels and checks
+// them, and its nutrition, against each other and isVeg.
+func prepareDietary(isVeg bool, allergens []string, labels []string, nutrition *model.Nutrition) ([]string, []string, error) {
+	allergens, err := model.NormalizeAllergens(allergens)
+	if err != nil {
+		return nil, nil, err
+	}
+	labels, err = model.NormalizeDietaryLabels(labels)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := model.CheckDietary(isVeg, allergens, labels); err != nil {
+		return nil, nil, err
+	}
+	if nutrition != nil {
+		if err := nutrition.Validate(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return allergens, labels, nil
+}
+
+// addDietaryFilter narrows filter to dishes free of the excluded allergens
+// and carrying every requested diet label. Dishes that have not declared
+// their allergens are left out when excluding any, since they may contain
+// them. The vegetarian diet is matched on isVeg so dishes listed before
+// labels existed are found too.
+func addDietaryFilter(filter bson.M, input GetDishesFilter) error {
+	excluded, err := model.NormalizeAllergens(splitOn(input.ExcludeAllergens, ","))
+	if err != nil {
+		return err
+	}
+	diets, err := model.NormalizeDietaryLabels(splitOn(input.Diet, ","))
+	if err != nil {
+		return err
+	}
+	if len(excluded) > 0 {
+		filter["allergens"] = bson.M{"$type": "array", "$nin": excluded}
+	}
+	var labels []string
+	for _, diet := range diets {
+		if diet == model.DietVegetarian {
+			filter["isVeg"] = true
+			continue
+		}
+		labels = append(labels, diet)
+	}
+	if len(labels) > 0 {
+		filter["dietaryLabels"] = bson.M{"$all": labels}
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"context"
+	"dish-service/src/config"
+	"dish-service/src/media"
+	"dish-service/src/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// AddDishImage adds the uploaded image to the dish's gallery. With
+// primary=true, or when the gallery is empty, it goes first and becomes
+// the display image.
+func AddDishImage(client *mongo.Client, c *gin.Context) {
+	primary, err := queryBool(c, "primary")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dish, ok := findOwnedDish(c)
+	if !ok {
+		return
+	}
+	limitImageUpload(c)
+	processed, err := readDishImage(c, "image")
+	if err != nil {
+		respondImageError(c, err)
+		return
+	}
+	if processed == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "An image file is required"})
+		return
+	}
+	if _, exists := dish.Image(processed.Hash); exists {
+		c.JSON(http.StatusConflict, gin.H{"error": "This image is already in the dish's gallery"})
+		return
+	}
+	maxCount := config.DishImageMaxCount()
+	if len(dish.Images) >= maxCount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("A dish can have at most %d images", maxCount)})
+		return
+	}
+
+	image, err := media.Store(dish.RestaurantId, dish.ID.Hex(), processed)
+	if err != nil {
+		log.Println("Error uploading dish image:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
+		return
+	}
+	push := bson.M{"$each": bson.A{image}}
+	update := bson.M{"$push": bson.M{"images": push}}
+	if primary || len(dish.Images) == 0 {
+		push["$position"] = 0
+		update["$set"] = bson.M{"displayImage": image.Rendition(model.RenditionMedium).URL}
+	}
+	// The gallery may have changed since it was read, so the limit and the
+	// duplicate check are applied again by the update
+	result, err := config.DishCollection.UpdateOne(context.TODO(), bson.M{
+		"_id":                                dish.ID,
+		"images._id":                         bson.M{"$ne": image.ID},
+		fmt.Sprintf("images.%d", maxCount-1): bson.M{"$exists": false},
+	}, update)
+	if err != nil {
+		log.Println("Error adding dish image:", err)
+		discardImage(dish.ID, image)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add image"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		discardImage(dish.ID, image)
+		c.JSON(http.StatusConflict, gin.H{"error": "The dish's images changed, please try again"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Image added successfully!", "image": image})
+}
+
+// DeleteDishImage removes an image from the dish's gallery and deletes its
+// files. If it was the display image the next image takes its place.
+func DeleteDishImage(client *mongo.Client, c *gin.Context) {
+	dish, ok := findOwnedDish(c)
+	if !ok {
+		return
+	}
+	image, ok := dish.Image(c.Param("imageId"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return
+	}
+
+	var updated model.Dish
+	err := config.DishCollection.FindOneAndUpdate(context.TODO(),
+		bson.M{"_id": dish.ID, "images._id": image.ID},
+		bson.M{"$pull": bson.M{"images": bson.M{"_id": image.ID}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return
+	}
+	if err != nil {
+		log.Println("Error removing dish image:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete image"})
+		return
+	}
+
+	displayImage := image.Rendition(model.RenditionMedium).URL
+	if updated.DisplayImage == displayImage {
+		next := ""
+		if len(updated.Images) > 0 {
+			next = updated.Images[0].Rendition(model.RenditionMedium).URL
+		}
+		_, err := config.DishCollection.UpdateOne(context.TODO(),
+			bson.M{"_id": dish.ID, "displayImage": displayImage},
+			bson.M{"$set": bson.M{"displayImage": next}},
+		)
+		if err != nil {
+			log.Println("Error updating display image:", err)
+		}
+	}
+	media.Delete(image)
+	c.JSON(http.StatusOK, gin.H{"message": "Image deleted successfully!"})
+}
+
+// SetPrimaryDishImage moves an image to the front of the dish's gallery and
+// makes it the display image.
+func SetPrimaryDishImage(client *mongo.Client, c *gin.Context) {
+	dish, ok := findOwnedDish(c)
+	if !ok {
+		return
+	}
+	image, ok := dish.Image(c.Param("imageId"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return
+	}
+
+	images := []model.DishImage{image}
+	ids := bson.A{}
+	for _, other := range dish.Images {
+		ids = append(ids, other.ID)
+		if other.ID != image.ID {
+			images = append(images, other)
+		}
+	}
+	// Only reorder the gallery as it was read
+	result, err := config.DishCollection.UpdateOne(context.TODO(), bson.M{
+		"_id":        dish.ID,
+		"images._id": bson.M{"$all": ids},
+		"images":     bson.M{"$size": len(ids)},
+	}, bson.M{"$set": bson.M{
+		"images":       images,
+		"displayImage": image.Rendition(model.RenditionMedium).URL,
+	}})
+	if err != nil {
+		log.Println("Error setting primary dish image:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update images"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "The dish's images changed, please try again"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Display image updated successfully!", "images": images})
+}
+
+// bindDishInput reads a dish from a JSON body, or from the JSON in the dish
+// field of a multipart form, which may also carry a displayImage file.
+func bindDishInput(c *gin.Context, input any) error {
+	if c.ContentType() != "multipart/form-data" {
+		return c.ShouldBindJSON(input)
+	}
+	limitImageUpload(c)
+	if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
+		return err
+	}
+	data := c.PostForm("dish")
+	if data == "" {
+		return errors.New("the dish field is required")
+	}
+	return json.Unmarshal([]byte(data), input)
+}
+
+// limitImageUpload caps the request body at the largest image allowed,
+// with room for the rest of the form.
+func limitImageUpload(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(config.DishImageMaxBytes())+1<<20)
+}
+
+// readDishImage reads and processes the image uploaded in field. It
+// returns nil when the request carries no such file.
+func readDishImage(c *gin.Context, field string) (*media.Processed, error) {
+	file, _, err := c.Request.FormFile(field)
+	if errors.Is(err, http.ErrMissingFile) || errors.Is(err, http.ErrNotMultipart) {
+		return nil, nil
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, &media.ImageError{Message: fmt.Sprintf("image cannot be larger than %d MB", config.DishImageMaxBytes()>>20)}
+	}
+	if err != nil {
+		return nil, &media.ImageError{Message: "failed to read image: " + err.Error()}
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, int64(config.DishImageMaxBytes())+1))
+	if err != nil {
+		return nil, err
+	}
+	return media.Process(data)
+}
+
+// respondImageError writes the response for an image that could not be
+// read or processed.
+func respondImageError(c *gin.Context, err error) {
+	var imageErr *media.ImageError
+	if errors.As(err, &imageErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": imageErr.Message})
+		return
+	}
+	log.Println("Error processing dish image:", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process image"})
+}
+
+// discardImage deletes the files of an image that did not make it onto the
+// dish, unless the dish holds the same image from another upload, as both
+// share their keys.
+func discardImage(dishId bson.ObjectID, image model.DishImage) {
+	count, err := config.DishCollection.CountDocuments(context.TODO(), bson.M{"_id": dishId, "images._id": image.ID})
+	if err != nil {
+		log.Println("Error checking dish images:", err)
+		return
+	}
+	if count == 0 {
+		media.Delete(image)
+	}
+}
@@ -3,21 +3,26 @@ package controllers
 import (
 	"context"
 	"dish-service/src/config"
+	"dish-service/src/media"
 	"dish-service/src/model"
-	"dish-service/src/utils"
+	"dish-service/src/search"
+	"errors"
 	"log"
 	"math"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type AddDishInput struct {
+	SKU string `json:"sku"`
 	Name string `json:"name"`
 	Description string `json:"description"`
 	Category string `json:"category"`
@@ -27,6 +32,19 @@ type AddDishInput struct {
 	PreparationTime int `json:"preparationTime"`
 	AvailabilityStatus string `json:"availabilityStatus"`
 	Tags []string `json:"tags"`
+	Customizations []model.CustomizationOption `json:"customizations"`
+	Variants []model.Variant `json:"variants"`
+	Schedule *model.Schedule `json:"schedule"`
+	Allergens []string `json:"allergens"`
+	DietaryLabels []string `json:"dietaryLabels"`
+	Nutrition *model.Nutrition `json:"nutrition"`
+}
+
+// UpdateDishInput is AddDishInput with IsVeg optional, so an update that
+// leaves it out keeps the dish's.
+type UpdateDishInput struct {
+	AddDishInput
+	IsVeg *bool `json:"isVeg"`
 }
 
 type GetDishesFilter struct {
@@ -39,11 +57,37 @@ type GetDishesFilter struct {
 	PreparationTime    *int     `form:"preparationTime"`
 	AvailabilityStatus string   `form:"availabilityStatus"`
 	Tags               []string `form:"tags"`
+	Latitude          *float64 `form:"lat"`
+	Longitude         *float64 `form:"lng"`
+	RadiusKm          *float64 `form:"radiusKm"`
+	// ExcludeAllergens and Diet are comma separated lists, for example
+	// excludeAllergens=peanut,gluten and diet=vegan.
+	ExcludeAllergens  string   `form:"excludeAllergens"`
+	Diet              string   `form:"diet"`
 }
 func AddDish(client *mongo.Client, c *gin.Context) {
 	var input AddDishInput
 
-	if err := c.ShouldBindJSON(&input); err != nil {
+	if err := bindDishInput(c, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := model.ValidateCustomizationOptions(input.Customizations); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := model.PrepareVariants(input.Variants, nil); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Schedule != nil {
+		if err := input.Schedule.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	allergens, labels, err := prepareDietary(input.IsVeg, input.Allergens, input.DietaryLabels, input.Nutrition)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -59,24 +103,35 @@ func AddDish(client *mongo.Client, c *gin.Context) {
 		return
 	}
 
+	// The ID is chosen up front so an uploaded image is stored under it
+	dishId := bson.NewObjectID()
+	var images []model.DishImage
 	var imageUrl string
-	file, fileHeader, err := c.Request.FormFile("displayImage")
-	if err == nil {
-		defer file.Close()
-		imageUrl, err = utils.SaveFile(file,fileHeader,"dish",restaurantIdStr, "dishes")
+	processed, err := readDishImage(c, "displayImage")
+	if err != nil {
+		respondImageError(c, err)
+		return
+	}
+	if processed != nil {
+		image, err := media.Store(restaurantIdStr, dishId.Hex(), processed)
 		if err != nil {
+			log.Println("Error uploading dish image:", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
 			return
 		}
+		images = []model.DishImage{image}
+		imageUrl = image.Rendition(model.RenditionMedium).URL
 	}
 
 	newDish := model.Dish{
+		ID:                dishId,
 		RestaurantId:      restaurantIdStr,
 		Name:              input.Name,
 		Description:       input.Description,
 		Category:          input.Category,
-		Price:             input.Price,
+		Price:             model.StartingPrice(input.Price, input.Variants),
 		DisplayImage:      imageUrl, 
+		Images:            images,
 		Type:              input.Type,
 		IsVeg:             input.IsVeg,
 		Rating: 		   0,
@@ -84,9 +139,24 @@ func AddDish(client *mongo.Client, c *gin.Context) {
 		PreparationTime:   input.PreparationTime,
 		AvailabilityStatus: input.AvailabilityStatus,
 		Tags:              input.Tags,
+		Customizations:    input.Customizations,
+		Variants:          input.Variants,
+		Schedule:          input.Schedule,
+		Allergens:         allergens,
+		DietaryLabels:     labels,
+		Nutrition:         input.Nutrition,
+		SKU:               strings.TrimSpace(input.SKU),
 	}
+	newDish.SearchGrams = search.DishGrams(newDish)
 
 	result, err := config.DishCollection.InsertOne(context.TODO(), newDish)
+	if err != nil {
+		media.Delete(images...)
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A dish with SKU " + newDish.SKU + " already exists"})
+		return
+	}
 	if err != nil {
 		log.Println("Error inserting dish:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add dish"})
@@ -106,56 +176,13 @@ func GetAllDishes(client *mongo.Client, c *gin.Context) {
 		return
 	}
 
-	// Default pagination values
-	limit := int64(10) // Default limit per page
-	page := int64(1)   // Default page number
-
-	// Parse limit and page from query parameters
-	if c.Query("limit") != "" {
-		if parsedLimit, err := strconv.ParseInt(c.Query("limit"), 10, 64); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-		}
-	}
-	if c.Query("page") != "" {
-		if parsedPage, err := strconv.ParseInt(c.Query("page"), 10, 64); err == nil && parsedPage > 0 {
-			page = parsedPage
-		}
-	}
-
+	page, limit := parsePagination(c)
 	skip := (page - 1) * limit // Calculate offset
 
-	// Build filter criteria
-	filter := bson.M{}
-	if input.Name != "" {
-		filter["name"] = bson.M{"$regex": input.Name, "$options": "i"} // Case-insensitive search
-	}
-	if input.Category != "" {
-		filter["category"] = input.Category
-	}
-	if input.MaxPrice != nil {
-		filter["price"] = bson.M{"$lte": *input.MaxPrice}
-	}
-	if input.MinPrice != nil {
-		if _, exists := filter["price"]; exists {
-			filter["price"].(bson.M)["$gte"] = *input.MinPrice
-		} else {
-			filter["price"] = bson.M{"$gte": *input.MinPrice}
-		}
-	}
-	if input.Type != "" {
-		filter["type"] = input.Type
-	}
-	if input.IsVeg != nil {
-		filter["isVeg"] = *input.IsVeg
-	}
-	if input.PreparationTime != nil {
-		filter["preparationTime"] = bson.M{"$lte": *input.PreparationTime}
-	}
-	if input.AvailabilityStatus != "" {
-		filter["availabilityStatus"] = input.AvailabilityStatus
-	}
-	if len(input.Tags) > 0 {
-		filter["tags"] = bson.M{"$in": input.Tags}
+	filter := buildDishFilter(input)
+	if err := addDietaryFilter(filter, input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	// Projection to return only selected fields
@@ -166,15 +193,53 @@ func GetAllDishes(client *mongo.Client, c *gin.Context) {
 		"description":  1,
 		"category":     1,
 		"isVeg":        1,
+		"variants":     1,
+		"availabilityStatus": 1,
+		"schedule":     1,
+		"allergens":    1,
+		"dietaryLabels": 1,
+	}
+
+	if input.Latitude != nil || input.Longitude != nil {
+		getNearbyDishes(c, input, filter, projection, page, limit)
+		return
+	}
+
+	sort, err := parseDishSort(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort"})
+		return
+	}
+	if sort.field != "_id" {
+		projection[sort.field] = 1
+	}
+
+	// A cursor continues from the last dish of the previous page; without
+	// one the page parameter is used.
+	query := filter
+	if token := c.Query("cursor"); token != "" {
+		pageCursor, err := decodeCursor(token)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		after, err := sort.after(pageCursor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		query = bson.M{"$and": bson.A{filter, after}}
+		skip = 0
 	}
 
-	// Query dishes with pagination
+	// Query one extra dish to learn whether there is a next page
 	findOptions := options.Find().
 		SetProjection(projection).
-		SetLimit(limit).
+		SetSort(sort.options()).
+		SetLimit(limit + 1).
 		SetSkip(skip)
 
-	cursor, err := config.DishCollection.Find(context.TODO(), filter, findOptions)
+	cursor, err := config.DishCollection.Find(context.TODO(), query, findOptions)
 	if err != nil {
 		log.Println("Error fetching dishes:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dishes"})
@@ -188,6 +253,11 @@ func GetAllDishes(client *mongo.Client, c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode dishes"})
 		return
 	}
+	var nextCursor string
+	if int64(len(dishes)) > limit {
+		dishes = dishes[:limit]
+		nextCursor = sort.cursorAfter(dishes[limit-1])
+	}
 
 	// Count total dishes matching filter (for frontend pagination)
 	totalCount, err := config.DishCollection.CountDocuments(context.TODO(), filter)
@@ -198,18 +268,86 @@ func GetAllDishes(client *mongo.Client, c *gin.Context) {
 	}
 
 	// Return paginated results
-	c.JSON(http.StatusOK, gin.H{
-		"message":     "Dishes fetched successfully!",
-		"dishes":      dishes,
-		"totalCount":  totalCount,
-		"currentPage": page,
-		"totalPages":  int64(math.Ceil(float64(totalCount) / float64(limit))),
-	})
+	response := gin.H{
+		"message":    "Dishes fetched successfully!",
+		"dishes":     listDishes(dishes, time.Now()),
+		"totalCount": totalCount,
+		"nextCursor": nextCursor,
+	}
+	if c.Query("cursor") == "" {
+		response["currentPage"] = page
+		response["totalPages"] = int64(math.Ceil(float64(totalCount) / float64(limit)))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// parsePagination reads page and limit from the query string, defaulting to
+// the first page of 10.
+func parsePagination(c *gin.Context) (page int64, limit int64) {
+	limit = int64(10) // Default limit per page
+	page = int64(1)   // Default page number
+
+	if c.Query("limit") != "" {
+		if parsedLimit, err := strconv.ParseInt(c.Query("limit"), 10, 64); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+	if c.Query("page") != "" {
+		if parsedPage, err := strconv.ParseInt(c.Query("page"), 10, 64); err == nil && parsedPage > 0 {
+			page = parsedPage
+		}
+	}
+	return page, limit
+}
+
+// buildDishFilter turns the attribute filters of a dish listing into a Mongo
+// filter. The location parameters are handled separately.
+func buildDishFilter(input GetDishesFilter) bson.M {
+	filter := bson.M{}
+	if input.Name != "" {
+		// Case-insensitive substring match; the input is escaped so it
+		// cannot inject regex syntax.
+		filter["name"] = bson.M{"$regex": regexp.QuoteMeta(input.Name), "$options": "i"}
+	}
+	if input.Category != "" {
+		filter["category"] = input.Category
+	}
+	// A dish matches the price range when its price does or, for a dish
+	// with variants, when any of its variants does.
+	price := bson.M{}
+	if input.MaxPrice != nil {
+		price["$lte"] = *input.MaxPrice
+	}
+	if input.MinPrice != nil {
+		price["$gte"] = *input.MinPrice
+	}
+	if len(price) > 0 {
+		filter["$or"] = bson.A{
+			bson.M{"variants": bson.M{"$elemMatch": bson.M{"price": price}}},
+			bson.M{"variants.0": bson.M{"$exists": false}, "price": price},
+		}
+	}
+	if input.Type != "" {
+		filter["type"] = input.Type
+	}
+	if input.IsVeg != nil {
+		filter["isVeg"] = *input.IsVeg
+	}
+	if input.PreparationTime != nil {
+		filter["preparationTime"] = bson.M{"$lte": *input.PreparationTime}
+	}
+	if input.AvailabilityStatus != "" {
+		filter["availabilityStatus"] = input.AvailabilityStatus
+	}
+	if len(input.Tags) > 0 {
+		filter["tags"] = bson.M{"$in": input.Tags}
+	}
+	return filter
 }
 
 func GetDishDetails(client *mongo.Client, c *gin.Context) {
     dishId := c.Param("id") 
-    objectId, err := primitive.ObjectIDFromHex(dishId)
+    objectId, err := bson.ObjectIDFromHex(dishId)
     if err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dish ID"})
         return
@@ -226,20 +364,20 @@ func GetDishDetails(client *mongo.Client, c *gin.Context) {
 
     c.JSON(http.StatusOK, gin.H{
         "message": "Dish fetched successfully!",
-        "dish": dish,
+        "dish": listDish(dish, time.Now()),
     })
 }
 
 
 func UpdateDish(client *mongo.Client, c*gin.Context) {
 	dishId := c.Param("id")
-	objectId, err := primitive.ObjectIDFromHex(dishId)
+	objectId, err := bson.ObjectIDFromHex(dishId)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dish ID"})
 		return
 	}
-	var input AddDishInput
-	if err := c.ShouldBindJSON(&input); err != nil {
+	var input UpdateDishInput
+	if err := bindDishInput(c, &input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -268,6 +406,9 @@ func UpdateDish(client *mongo.Client, c*gin.Context) {
 	}
 
 	update := bson.M{}
+	if sku := strings.TrimSpace(input.SKU); sku != "" {
+		update["sku"] = sku
+	}
     if input.Name != "" {
         update["name"] = input.Name
     }
@@ -277,13 +418,33 @@ func UpdateDish(client *mongo.Client, c*gin.Context) {
     if input.Category != "" {
         update["category"] = input.Category
     }
-    if input.Price != 0 {
-        update["price"] = input.Price
-    }
+	// A dish with variants is priced per variant
+	if input.Variants != nil {
+		if err := model.PrepareVariants(input.Variants, dish.Variants); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		update["variants"] = input.Variants
+		if len(input.Variants) > 0 {
+			update["price"] = model.StartingPrice(input.Price, input.Variants)
+		} else if input.Price != 0 {
+			update["price"] = input.Price
+		}
+	} else if input.Price != 0 {
+		if len(dish.Variants) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Price is set per variant for this dish"})
+			return
+		}
+		update["price"] = input.Price
+	}
     if input.Type != "" {
         update["type"] = input.Type
     }
-    update["isVeg"] = input.IsVeg
+    isVeg := dish.IsVeg
+    if input.IsVeg != nil {
+        isVeg = *input.IsVeg
+        update["isVeg"] = isVeg
+    }
     if input.PreparationTime != 0 {
         update["preparationTime"] = input.PreparationTime
     }
@@ -293,21 +454,123 @@ func UpdateDish(client *mongo.Client, c*gin.Context) {
     if len(input.Tags) > 0 {
         update["tags"] = input.Tags
     }
+	// Customizations are replaced as a whole when given
+	if input.Customizations != nil {
+		if err := model.ValidateCustomizationOptions(input.Customizations); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		update["customizations"] = input.Customizations
+	}
+
+	// An empty schedule removes it, making the dish orderable at any time
+	if input.Schedule != nil {
+		if len(input.Schedule.Windows) == 0 && len(input.Schedule.Exceptions) == 0 {
+			update["schedule"] = nil
+		} else if err := input.Schedule.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		} else {
+			update["schedule"] = input.Schedule
+		}
+	}
+
+	// Allergens and labels are replaced as a whole when given and checked
+	// against the dish as it will be saved
+	allergens, labels := dish.Allergens, dish.DietaryLabels
+	if input.Allergens != nil {
+		allergens = input.Allergens
+	}
+	if input.DietaryLabels != nil {
+		labels = input.DietaryLabels
+	}
+	allergens, labels, err = prepareDietary(isVeg, allergens, labels, input.Nutrition)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Allergens != nil {
+		update["allergens"] = allergens
+	}
+	if input.DietaryLabels != nil {
+		update["dietaryLabels"] = labels
+	}
+	if input.Nutrition != nil {
+		update["nutrition"] = input.Nutrition
+	}
+
+	// Keep the fuzzy search grams in step with the fields they are built from.
+	searchable := dish
+	if input.Name != "" {
+		searchable.Name = input.Name
+	}
+	if input.Category != "" {
+		searchable.Category = input.Category
+	}
+	if len(input.Tags) > 0 {
+		searchable.Tags = input.Tags
+	}
+	update["searchGrams"] = search.DishGrams(searchable)
+
+	// An uploaded displayImage replaces the dish's first image, as long as
+	// that is still the image that was read
+	processed, err := readDishImage(c, "displayImage")
+	if err != nil {
+		respondImageError(c, err)
+		return
+	}
+	var uploaded, replaced *model.DishImage
+	if processed != nil {
+		if _, exists := dish.Image(processed.Hash); exists {
+			c.JSON(http.StatusConflict, gin.H{"error": "This image is already in the dish's gallery"})
+			return
+		}
+		image, err := media.Store(dish.RestaurantId, dish.ID.Hex(), processed)
+		if err != nil {
+			log.Println("Error uploading dish image:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
+			return
+		}
+		uploaded = &image
+		update["displayImage"] = image.Rendition(model.RenditionMedium).URL
+		if len(dish.Images) > 0 {
+			replaced = &dish.Images[0]
+			filter["images.0._id"] = replaced.ID
+			update["images.0"] = image
+		} else {
+			filter["images.0"] = bson.M{"$exists": false}
+			update["images"] = []model.DishImage{image}
+		}
+	}
 
 	// Update the dish document
 	updateResult := config.DishCollection.FindOneAndUpdate(context.TODO(), filter, bson.M{"$set": update})
+	if updateResult.Err() != nil && uploaded != nil {
+		discardImage(dish.ID, *uploaded)
+	}
 
+	if mongo.IsDuplicateKeyError(updateResult.Err()) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Another dish already has SKU " + strings.TrimSpace(input.SKU)})
+		return
+	}
+	if errors.Is(updateResult.Err(), mongo.ErrNoDocuments) {
+		c.JSON(http.StatusConflict, gin.H{"error": "The dish's images changed, please try again"})
+		return
+	}
 	if updateResult.Err() != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update dish"})
         return
     }
+	if replaced != nil {
+		media.Delete(*replaced)
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Dish updated successfully!"})
 }
 
 func DeleteDish(client *mongo.Client, c*gin.Context) {
 	// Get the dish ID from the URL parameter
 	dishId := c.Param("id")
-	objectId, err := primitive.ObjectIDFromHex(dishId)
+	objectId, err := bson.ObjectIDFromHex(dishId)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dish ID"})
 		return
@@ -340,6 +603,7 @@ func DeleteDish(client *mongo.Client, c*gin.Context) {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete dish"})
         return
     }
+	media.DeleteDish(dish.RestaurantId, dishId)
 
     c.JSON(http.StatusOK, gin.H{"message": "Dish deleted successfully!"})
 }
\ No newline at end of file
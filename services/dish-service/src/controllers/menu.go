@@ -0,0 +1,547 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"dish-service/src/config"
+	"dish-service/src/model"
+	"dish-service/src/search"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// maxMenuUploadBytes bounds the size of an imported menu file.
+const maxMenuUploadBytes = 10 << 20
+
+// MenuItem is one dish of an imported or exported menu. Images are given by
+// URL. On import, a dish with the SKU of an existing dish of the restaurant
+// updates it, keeping its image, customizations, schedule, availability and
+// isVeg when the row leaves them empty, and variants are matched to the
+// existing ones by name.
+type MenuItem struct {
+	AddDishInput
+	IsVeg        *bool  `json:"isVeg"`
+	DisplayImage string `json:"displayImage"`
+}
+
+// Import row statuses. In a dry run they say what would have happened.
+const (
+	importCreated = "created"
+	importUpdated = "updated"
+	importInvalid = "invalid"
+	importSkipped = "skipped"
+	importFailed  = "failed"
+)
+
+// MenuImportRow reports the outcome of one imported dish.
+type MenuImportRow struct {
+	Row    int            `json:"row"`
+	SKU    string         `json:"sku,omitempty"`
+	Name   string         `json:"name,omitempty"`
+	Status string         `json:"status"`
+	DishID *bson.ObjectID `json:"dishId,omitempty"`
+	Errors []string       `json:"errors,omitempty"`
+}
+
+type MenuImportSummary struct {
+	Total   int `json:"total"`
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Invalid int `json:"invalid"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+// importedDish is a valid row ready to be written.
+type importedDish struct {
+	report   *MenuImportRow
+	dish     model.Dish
+	existing *model.Dish
+}
+
+// ImportMenu adds or updates the logged in restaurant's dishes from a CSV or
+// JSON menu, sent as the request body or as a multipart file field. Every
+// row is validated and reported on. With dryRun=true nothing is written;
+// with atomic=true nothing is written unless every row is valid, and the
+// rows are written in one transaction.
+func ImportMenu(client *mongo.Client, c *gin.Context) {
+	restaurantId, ok := loggedInRestaurant(c)
+	if !ok {
+		return
+	}
+	dryRun, err := queryBool(c, "dryRun")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	atomic, err := queryBool(c, "atomic")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	body, format, err := readMenuUpload(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var rows []menuRow
+	if format == "csv" {
+		rows, err = parseMenuCSV(body)
+	} else {
+		rows, err = parseMenuJSON(body)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Menu has no dishes"})
+		return
+	}
+	if limit := config.MenuImportMaxRows(); len(rows) > limit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Menu has %d dishes, at most %d can be imported at once", len(rows), limit)})
+		return
+	}
+
+	ctx := c.Request.Context()
+	existing, err := findDishesBySKU(ctx, restaurantId, rows)
+	if err != nil {
+		log.Println("Error fetching dishes by SKU:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import menu"})
+		return
+	}
+
+	reports := make([]MenuImportRow, len(rows))
+	var valid []importedDish
+	seenSKUs := map[string]int{}
+	for i, row := range rows {
+		item := row.Item
+		item.SKU = strings.TrimSpace(item.SKU)
+		report := &reports[i]
+		*report = MenuImportRow{Row: row.Row, SKU: item.SKU, Name: strings.TrimSpace(item.Name), Errors: row.Errors}
+		if item.SKU != "" {
+			if first, ok := seenSKUs[item.SKU]; ok {
+				report.Errors = append(report.Errors, fmt.Sprintf("SKU %s is also used on row %d", item.SKU, first))
+			} else {
+				seenSKUs[item.SKU] = row.Row
+			}
+		}
+		if len(report.Errors) > 0 {
+			report.Status = importInvalid
+			continue
+		}
+		current := existing[item.SKU]
+		dish, errs := menuDish(item, restaurantId, current)
+		report.Errors = append(report.Errors, errs...)
+		if len(report.Errors) > 0 {
+			report.Status = importInvalid
+			continue
+		}
+		report.Status = importCreated
+		if current != nil {
+			report.Status = importUpdated
+			report.DishID = &current.ID
+		}
+		valid = append(valid, importedDish{report: report, dish: dish, existing: current})
+	}
+
+	status := http.StatusOK
+	invalid := len(rows) - len(valid)
+	switch {
+	case dryRun:
+	case atomic && invalid > 0:
+		for _, row := range valid {
+			row.report.Status = importSkipped
+		}
+		status = http.StatusUnprocessableEntity
+	case atomic:
+		err := withTransaction(ctx, client, func(ctx context.Context) error {
+			for _, row := range valid {
+				if err := writeImportedDish(ctx, row); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Println("Error importing menu:", err)
+			for _, row := range valid {
+				row.report.Status = importFailed
+				row.report.DishID = nil
+				row.report.Errors = []string{importWriteError(err)}
+			}
+			status = http.StatusInternalServerError
+		}
+	default:
+		for _, row := range valid {
+			if err := writeImportedDish(ctx, row); err != nil {
+				log.Println("Error importing dish:", err)
+				row.report.Status = importFailed
+				row.report.DishID = nil
+				row.report.Errors = []string{importWriteError(err)}
+			}
+		}
+	}
+
+	summary := MenuImportSummary{Total: len(reports)}
+	for _, report := range reports {
+		switch report.Status {
+		case importCreated:
+			summary.Created++
+		case importUpdated:
+			summary.Updated++
+		case importInvalid:
+			summary.Invalid++
+		case importSkipped:
+			summary.Skipped++
+		case importFailed:
+			summary.Failed++
+		}
+	}
+	message := "Menu imported successfully!"
+	if dryRun {
+		message = "Menu checked, nothing was imported"
+	} else if summary.Created+summary.Updated == 0 {
+		message = "Nothing was imported"
+	}
+	c.JSON(status, gin.H{
+		"message": message,
+		"dryRun":  dryRun,
+		"atomic":  atomic,
+		"summary": summary,
+		"rows":    reports,
+	})
+}
+
+// ExportMenu writes the logged in restaurant's dishes as a JSON or CSV menu
+// that ImportMenu reads back.
+func ExportMenu(client *mongo.Client, c *gin.Context) {
+	restaurantId, ok := loggedInRestaurant(c)
+	if !ok {
+		return
+	}
+	format := strings.ToLower(c.DefaultQuery("format", "json"))
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	cursor, err := config.DishCollection.Find(ctx,
+		bson.M{"restaurant": restaurantId},
+		options.Find().SetSort(bson.D{{Key: "category", Value: 1}, {Key: "name", Value: 1}}),
+	)
+	if err != nil {
+		log.Println("Error fetching dishes:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export menu"})
+		return
+	}
+	var dishes []model.Dish
+	if err := cursor.All(ctx, &dishes); err != nil {
+		log.Println("Error decoding dishes:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export menu"})
+		return
+	}
+	items := make([]MenuItem, 0, len(dishes))
+	for _, dish := range dishes {
+		items = append(items, menuItemFromDish(dish))
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=menu."+format)
+	if format == "csv" {
+		var buf bytes.Buffer
+		if err := writeMenuCSV(&buf, items); err != nil {
+			log.Println("Error writing menu CSV:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export menu"})
+			return
+		}
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Menu exported successfully!",
+		"restaurantId": restaurantId,
+		"exportedAt":   time.Now(),
+		"dishes":       items,
+	})
+}
+
+// readMenuUpload returns the uploaded menu and whether it is csv or json,
+// taken from the format query parameter, the file name or the content type
+// in that order.
+func readMenuUpload(c *gin.Context) ([]byte, string, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxMenuUploadBytes)
+	var reader io.Reader = c.Request.Body
+	filename := ""
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			return nil, "", fmt.Errorf("menu file is required: %v", err)
+		}
+		defer file.Close()
+		reader = file
+		filename = header.Filename
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read menu: %v", err)
+	}
+
+	format := strings.ToLower(c.Query("format"))
+	switch {
+	case format != "":
+	case filename != "":
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	case strings.Contains(c.ContentType(), "csv"):
+		format = "csv"
+	default:
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		return nil, "", fmt.Errorf("menu must be json or csv, not %s", format)
+	}
+	return body, format, nil
+}
+
+// findDishesBySKU loads the restaurant's dishes that share a SKU with rows.
+func findDishesBySKU(ctx context.Context, restaurantId string, rows []menuRow) (map[string]*model.Dish, error) {
+	var skus []string
+	for _, row := range rows {
+		if sku := strings.TrimSpace(row.Item.SKU); sku != "" {
+			skus = append(skus, sku)
+		}
+	}
+	found := map[string]*model.Dish{}
+	if len(skus) == 0 {
+		return found, nil
+	}
+	cursor, err := config.DishCollection.Find(ctx, bson.M{"restaurant": restaurantId, "sku": bson.M{"$in": skus}})
+	if err != nil {
+		return nil, err
+	}
+	var dishes []model.Dish
+	if err := cursor.All(ctx, &dishes); err != nil {
+		return nil, err
+	}
+	for i := range dishes {
+		found[dishes[i].SKU] = &dishes[i]
+	}
+	return found, nil
+}
+
+// menuDish validates item as AddDish would and builds the dish to store,
+// starting from existing when the item updates a dish. It returns every
+// problem found rather than the first.
+func menuDish(item MenuItem, restaurantId string, existing *model.Dish) (model.Dish, []string) {
+	var errs []string
+	item.Name = strings.TrimSpace(item.Name)
+	if item.Name == "" {
+		errs = append(errs, "name is required")
+	}
+	if item.PreparationTime < 0 {
+		errs = append(errs, "preparationTime cannot be negative")
+	}
+	if err := model.ValidateCustomizationOptions(item.Customizations); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	var existingVariants []model.Variant
+	if existing != nil {
+		existingVariants = existing.Variants
+	}
+	for i := range item.Variants {
+		item.Variants[i].ID = bson.NilObjectID
+		for _, current := range existingVariants {
+			if strings.EqualFold(strings.TrimSpace(item.Variants[i].Name), current.Name) {
+				item.Variants[i].ID = current.ID
+			}
+		}
+	}
+	if err := model.PrepareVariants(item.Variants, existingVariants); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(item.Variants) == 0 && item.Price <= 0 {
+		errs = append(errs, "price must be positive")
+	}
+	if item.Schedule != nil {
+		if err := item.Schedule.Validate(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	isVeg := existing != nil && existing.IsVeg
+	if item.IsVeg != nil {
+		isVeg = *item.IsVeg
+	}
+	allergens, labels, err := prepareDietary(isVeg, item.Allergens, item.DietaryLabels, item.Nutrition)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	if item.DisplayImage != "" && !isImageURL(item.DisplayImage) {
+		errs = append(errs, "displayImage must be an http or https URL")
+	}
+	if len(errs) > 0 {
+		return model.Dish{}, errs
+	}
+
+	dish := model.Dish{}
+	if existing != nil {
+		dish = *existing
+	}
+	dish.RestaurantId = restaurantId
+	dish.SKU = item.SKU
+	dish.Name = item.Name
+	dish.Description = item.Description
+	dish.Category = item.Category
+	dish.Price = model.StartingPrice(item.Price, item.Variants)
+	dish.Type = item.Type
+	dish.IsVeg = isVeg
+	dish.PreparationTime = item.PreparationTime
+	dish.Tags = item.Tags
+	dish.Variants = item.Variants
+	dish.Allergens = allergens
+	dish.DietaryLabels = labels
+	dish.Nutrition = item.Nutrition
+	if item.AvailabilityStatus != "" || existing == nil {
+		dish.AvailabilityStatus = item.AvailabilityStatus
+	}
+	if item.DisplayImage != "" {
+		dish.DisplayImage = item.DisplayImage
+	}
+	if item.Customizations != nil || existing == nil {
+		dish.Customizations = item.Customizations
+	}
+	if item.Schedule != nil {
+		dish.Schedule = item.Schedule
+	}
+	dish.SearchGrams = search.DishGrams(dish)
+	return dish, nil
+}
+
+// writeImportedDish inserts or updates one imported dish. Updates leave
+// rating, popularity and stock alone.
+func writeImportedDish(ctx context.Context, row importedDish) error {
+	if row.existing == nil {
+		row.dish.ID = bson.NewObjectID()
+		if _, err := config.DishCollection.InsertOne(ctx, row.dish); err != nil {
+			return err
+		}
+		row.report.DishID = &row.dish.ID
+		return nil
+	}
+	dish := row.dish
+	_, err := config.DishCollection.UpdateOne(ctx,
+		bson.M{"_id": dish.ID, "restaurant": dish.RestaurantId},
+		bson.M{"$set": bson.M{
+			"name":               dish.Name,
+			"description":        dish.Description,
+			"category":           dish.Category,
+			"price":              dish.Price,
+			"type":               dish.Type,
+			"isVeg":              dish.IsVeg,
+			"preparationTime":    dish.PreparationTime,
+			"availabilityStatus": dish.AvailabilityStatus,
+			"tags":               dish.Tags,
+			"displayImage":       dish.DisplayImage,
+			"customizations":     dish.Customizations,
+			"variants":           dish.Variants,
+			"schedule":           dish.Schedule,
+			"allergens":          dish.Allergens,
+			"dietaryLabels":      dish.DietaryLabels,
+			"nutrition":          dish.Nutrition,
+			"searchGrams":        dish.SearchGrams,
+		}},
+	)
+	return err
+}
+
+func importWriteError(err error) string {
+	if mongo.IsDuplicateKeyError(err) {
+		return "another dish already has this SKU"
+	}
+	return "failed to save dish"
+}
+
+// menuItemFromDish is the dish as it is exported. Stock and the sold out
+// status it sets are left out, so importing the menu again does not switch
+// a dish off for good.
+func menuItemFromDish(dish model.Dish) MenuItem {
+	variants := make([]model.Variant, len(dish.Variants))
+	for i, variant := range dish.Variants {
+		variant.Stock = nil
+		variant.AvailabilityStatus = exportedStatus(variant.AvailabilityStatus)
+		variants[i] = variant
+	}
+	return MenuItem{
+		AddDishInput: AddDishInput{
+			SKU:                dish.SKU,
+			Name:               dish.Name,
+			Description:        dish.Description,
+			Category:           dish.Category,
+			Price:              dish.Price,
+			Type:               dish.Type,
+			PreparationTime:    dish.PreparationTime,
+			AvailabilityStatus: exportedStatus(dish.AvailabilityStatus),
+			Tags:               dish.Tags,
+			Customizations:     dish.Customizations,
+			Variants:           variants,
+			Schedule:           dish.Schedule,
+			Allergens:          dish.Allergens,
+			DietaryLabels:      dish.DietaryLabels,
+			Nutrition:          dish.Nutrition,
+		},
+		IsVeg:        &dish.IsVeg,
+		DisplayImage: dish.DisplayImage,
+	}
+}
+
+func exportedStatus(status string) string {
+	if status == model.AvailabilitySoldOut {
+		return ""
+	}
+	return status
+}
+
+func isImageURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+func queryBool(c *gin.Context, name string) (bool, error) {
+	value := c.Query(name)
+	if value == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", name)
+	}
+	return parsed, nil
+}
+
+// withTransaction runs fn in a MongoDB transaction. fn may be retried on
+// transient errors. Transactions need MongoDB to run as a replica set.
+func withTransaction(ctx context.Context, client *mongo.Client, fn func(ctx context.Context) error) error {
+	session, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
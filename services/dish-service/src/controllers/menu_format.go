@@ -0,0 +1,307 @@
+package controllers
+
+import (
+	"bytes"
+	"dish-service/src/model"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// menuColumns are the columns of a CSV menu, in export order. Lists are
+// separated by |, and variants, customizations and schedule hold the same
+// JSON as in a JSON menu, though variants may also be written name:price.
+// An allergens cell of "none" declares the dish free of allergens, an empty
+// one leaves them undeclared.
+var menuColumns = []string{
+	"sku", "name", "description", "category", "price", "type", "isVeg",
+	"preparationTime", "availabilityStatus", "tags", "displayImage",
+	"allergens", "dietaryLabels", "calories", "proteinG", "carbohydratesG",
+	"fatG", "fiberG", "sugarG", "sodiumMg", "variants", "customizations",
+	"schedule",
+}
+
+var nutritionColumns = []string{"calories", "proteinG", "carbohydratesG", "fatG", "fiberG", "sugarG", "sodiumMg"}
+
+const (
+	listSeparator = "|"
+	noneValue     = "none"
+)
+
+// menuRow is a parsed menu entry with any errors found while reading it.
+// Row is the CSV line number, or the position in a JSON menu, from 1.
+type menuRow struct {
+	Row    int
+	Item   MenuItem
+	Errors []string
+}
+
+// parseMenuJSON reads a menu given either as a list of dishes or as an
+// object with a dishes list, the shape ExportMenu writes.
+func parseMenuJSON(body []byte) ([]menuRow, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		var wrapped struct {
+			Dishes []json.RawMessage `json:"dishes"`
+		}
+		if err := json.Unmarshal(body, &wrapped); err != nil {
+			return nil, errors.New("menu must be a JSON list of dishes or an object with a dishes list")
+		}
+		raw = wrapped.Dishes
+	}
+	rows := make([]menuRow, 0, len(raw))
+	for i, entry := range raw {
+		row := menuRow{Row: i + 1}
+		if err := json.Unmarshal(entry, &row.Item); err != nil {
+			row.Errors = append(row.Errors, err.Error())
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseMenuCSV reads a CSV menu with a header row naming its columns, in
+// any order. Cells that cannot be read are reported on their row.
+func parseMenuCSV(body []byte) ([]menuRow, error) {
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("menu is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %v", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		known := false
+		for _, column := range menuColumns {
+			if strings.EqualFold(name, column) {
+				columns[column] = i
+				known = true
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("the name column is required")
+	}
+
+	var rows []menuRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, fmt.Errorf("invalid CSV on line %d: %v", line, err)
+		}
+		row := menuRow{Row: line}
+		if err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("expected %d cells, got %d", len(header), len(record)))
+		} else {
+			row.Item, row.Errors = menuItemFromRecord(record, columns)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func menuItemFromRecord(record []string, columns map[string]int) (MenuItem, []string) {
+	var errs []string
+	cell := func(column string) string {
+		if i, ok := columns[column]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	number := func(column string) float64 {
+		value := cell(column)
+		if value == "" {
+			return 0
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			errs = append(errs, column+" must be a number")
+		}
+		return parsed
+	}
+	whole := func(column string) int {
+		value := cell(column)
+		if value == "" {
+			return 0
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			errs = append(errs, column+" must be a whole number")
+		}
+		return parsed
+	}
+	list := func(column string) []string {
+		value := cell(column)
+		if value == "" {
+			return nil
+		}
+		if strings.EqualFold(value, noneValue) {
+			return []string{}
+		}
+		return splitOn(value, listSeparator)
+	}
+	jsonCell := func(column string, target any) {
+		if value := cell(column); value != "" {
+			if err := json.Unmarshal([]byte(value), target); err != nil {
+				errs = append(errs, column+" must be valid JSON")
+			}
+		}
+	}
+
+	var item MenuItem
+	item.SKU = cell("sku")
+	item.Name = cell("name")
+	item.Description = cell("description")
+	item.Category = cell("category")
+	item.Price = whole("price")
+	item.Type = cell("type")
+	if value := cell("isVeg"); value != "" {
+		isVeg, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, "isVeg must be true or false")
+		}
+		item.IsVeg = &isVeg
+	}
+	item.PreparationTime = whole("preparationTime")
+	item.AvailabilityStatus = cell("availabilityStatus")
+	item.Tags = list("tags")
+	item.DisplayImage = cell("displayImage")
+	item.Allergens = list("allergens")
+	item.DietaryLabels = list("dietaryLabels")
+	for _, column := range nutritionColumns {
+		if cell(column) != "" {
+			item.Nutrition = &model.Nutrition{
+				Calories:       whole("calories"),
+				ProteinG:       number("proteinG"),
+				CarbohydratesG: number("carbohydratesG"),
+				FatG:           number("fatG"),
+				FiberG:         number("fiberG"),
+				SugarG:         number("sugarG"),
+				SodiumMg:       number("sodiumMg"),
+			}
+			break
+		}
+	}
+	if strings.HasPrefix(cell("variants"), "[") {
+		jsonCell("variants", &item.Variants)
+	} else {
+		item.Variants, errs = shortVariants(cell("variants"), errs)
+	}
+	jsonCell("customizations", &item.Customizations)
+	jsonCell("schedule", &item.Schedule)
+	return item, errs
+}
+
+// writeMenuCSV writes items in the format parseMenuCSV reads.
+func writeMenuCSV(w io.Writer, items []MenuItem) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(menuColumns); err != nil {
+		return err
+	}
+	list := func(values []string) string {
+		if values != nil && len(values) == 0 {
+			return noneValue
+		}
+		return strings.Join(values, listSeparator)
+	}
+	jsonCell := func(value any, empty bool) string {
+		if empty {
+			return ""
+		}
+		encoded, _ := json.Marshal(value)
+		return string(encoded)
+	}
+	for _, item := range items {
+		nutrition := make([]string, len(nutritionColumns))
+		if n := item.Nutrition; n != nil {
+			nutrition = []string{
+				strconv.Itoa(n.Calories),
+				formatNumber(n.ProteinG),
+				formatNumber(n.CarbohydratesG),
+				formatNumber(n.FatG),
+				formatNumber(n.FiberG),
+				formatNumber(n.SugarG),
+				formatNumber(n.SodiumMg),
+			}
+		}
+		isVeg := ""
+		if item.IsVeg != nil {
+			isVeg = strconv.FormatBool(*item.IsVeg)
+		}
+		record := []string{
+			item.SKU,
+			item.Name,
+			item.Description,
+			item.Category,
+			strconv.Itoa(item.Price),
+			item.Type,
+			isVeg,
+			strconv.Itoa(item.PreparationTime),
+			item.AvailabilityStatus,
+			strings.Join(item.Tags, listSeparator),
+			item.DisplayImage,
+			list(item.Allergens),
+			strings.Join(item.DietaryLabels, listSeparator),
+		}
+		record = append(record, nutrition...)
+		record = append(record,
+			jsonCell(item.Variants, len(item.Variants) == 0),
+			jsonCell(item.Customizations, len(item.Customizations) == 0),
+			jsonCell(item.Schedule, item.Schedule == nil),
+		)
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// shortVariants reads variants written name:price and separated by |.
+func shortVariants(value string, errs []string) ([]model.Variant, []string) {
+	var variants []model.Variant
+	for _, entry := range splitOn(value, listSeparator) {
+		i := strings.LastIndex(entry, ":")
+		if i < 0 {
+			errs = append(errs, fmt.Sprintf("variant %q must be written name:price", entry))
+			continue
+		}
+		price, err := strconv.Atoi(strings.TrimSpace(entry[i+1:]))
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("variant %q must be written name:price", entry))
+			continue
+		}
+		variants = append(variants, model.Variant{Name: strings.TrimSpace(entry[:i]), Price: price})
+	}
+	return variants, errs
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// splitOn splits value on sep, trimming items and dropping empty ones.
+func splitOn(value string, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(value, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"bytes"
+	"dish-service/src/model"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestMenuCSVRoundTrip(t *testing.T) {
+	isVeg := true
+	items := []MenuItem{
+		{
+			AddDishInput: AddDishInput{
+				SKU:                "BIR-01",
+				Name:               "Chicken Biryani",
+				Description:        "Slow cooked, \"dum\" style,\nwith raita",
+				Category:           "Mains",
+				Price:              250,
+				Type:               "main",
+				PreparationTime:    30,
+				AvailabilityStatus: model.AvailabilityAvailable,
+				Tags:               []string{"rice", "spicy"},
+				Customizations: []model.CustomizationOption{
+					{Key: "spice", Label: "Spice level", Type: model.CustomizationRange, Min: 1, Max: 5, PriceDelta: 5},
+					{
+						Key:     "raita",
+						Label:   "Raita",
+						Type:    model.CustomizationSingleChoice,
+						Default: "plain",
+						Choices: []model.CustomizationChoice{{Key: "plain", Label: "Plain"}, {Key: "boondi", Label: "Boondi", PriceDelta: 10}},
+					},
+				},
+				Variants: []model.Variant{
+					{
+						ID:                 bson.NewObjectID(),
+						Name:               "Half",
+						Price:              250,
+						PreparationTime:    25,
+						AvailabilityStatus: model.AvailabilityUnavailable,
+						Nutrition:          &model.Nutrition{Calories: 450, ProteinG: 22.5},
+					},
+					{ID: bson.NewObjectID(), Name: "Full, family", Price: 450},
+				},
+				Schedule: &model.Schedule{
+					Timezone:   "Asia/Kolkata",
+					Windows:    []model.ScheduleWindow{{Days: []int{5, 6}, TimeWindow: model.TimeWindow{Start: "19:00", End: "01:00"}}},
+					Exceptions: []model.ScheduleException{{Date: "2024-12-25", Closed: true}},
+				},
+				Allergens:     []string{"milk"},
+				DietaryLabels: []string{"halal"},
+				Nutrition:     &model.Nutrition{Calories: 800, ProteinG: 35, CarbohydratesG: 90.5, FatG: 30, FiberG: 4, SugarG: 6, SodiumMg: 1200},
+			},
+			DisplayImage: "https://cdn.example.com/biryani.jpg",
+		},
+		{
+			AddDishInput: AddDishInput{
+				SKU:           "LAS-01",
+				Name:          "Sweet Lassi",
+				Price:         80,
+				Type:          "drink",
+				Tags:          []string{"cold"},
+				Allergens:     []string{},
+				DietaryLabels: []string{"vegetarian"},
+			},
+			IsVeg: &isVeg,
+		},
+		{AddDishInput: AddDishInput{Name: "Chef's special", Price: 300, Tags: []string{"special"}, DietaryLabels: []string{"spicy"}}},
+	}
+
+	var buf bytes.Buffer
+	if err := writeMenuCSV(&buf, items); err != nil {
+		t.Fatalf("writeMenuCSV() error = %v", err)
+	}
+	rows, err := parseMenuCSV(buf.Bytes())
+	if err != nil {
+		t.Fatalf("parseMenuCSV() error = %v", err)
+	}
+	if len(rows) != len(items) {
+		t.Fatalf("parseMenuCSV() read %d rows, want %d", len(rows), len(items))
+	}
+	for i, row := range rows {
+		if len(row.Errors) > 0 {
+			t.Errorf("row %d errors = %q", row.Row, row.Errors)
+			continue
+		}
+		if !reflect.DeepEqual(row.Item, items[i]) {
+			t.Errorf("row %d = %+v, want %+v", row.Row, row.Item, items[i])
+		}
+	}
+}
+
+func TestMenuCSVShortVariants(t *testing.T) {
+	body := "name,price,variants\nDosa,90,Plain:90|Masala: 120\nIdli,60,Single\n"
+	rows, err := parseMenuCSV([]byte(body))
+	if err != nil {
+		t.Fatalf("parseMenuCSV() error = %v", err)
+	}
+	want := []model.Variant{{Name: "Plain", Price: 90}, {Name: "Masala", Price: 120}}
+	if !reflect.DeepEqual(rows[0].Item.Variants, want) || len(rows[0].Errors) > 0 {
+		t.Errorf("variants = %+v, errors %q, want %+v", rows[0].Item.Variants, rows[0].Errors, want)
+	}
+	if len(rows[1].Errors) == 0 {
+		t.Errorf("a variant without a price was accepted")
+	}
+}
+
+func TestParseMenuCSVErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantErr    bool
+		wantErrors bool
+	}{
+		{name: "empty", body: "", wantErr: true},
+		{name: "unknown column", body: "name,colour\nDal,yellow\n", wantErr: true},
+		{name: "no name column", body: "sku,price\nD1,90\n", wantErr: true},
+		{name: "header with a byte order mark", body: "\ufeffName,Price\nDal,90\n"},
+		{name: "price not a number", body: "name,price\nDal,ninety\n", wantErrors: true},
+		{name: "isVeg not a bool", body: "name,isVeg\nDal,maybe\n", wantErrors: true},
+		{name: "invalid schedule JSON", body: "name,schedule\nDal,{\n", wantErrors: true},
+		{name: "missing cells", body: "name,price\nDal\n", wantErrors: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := parseMenuCSV([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMenuCSV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(rows) != 1 {
+				t.Fatalf("parseMenuCSV() read %d rows, want 1", len(rows))
+			}
+			if (len(rows[0].Errors) > 0) != tt.wantErrors {
+				t.Errorf("row errors = %q, wantErrors %v", rows[0].Errors, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestMenuItemFromDishLeavesOutStock(t *testing.T) {
+	dish := model.Dish{
+		Name:               "Paneer Tikka",
+		AvailabilityStatus: model.AvailabilitySoldOut,
+		Stock:              &model.Stock{},
+		Variants: []model.Variant{
+			{ID: bson.NewObjectID(), Name: "Half", AvailabilityStatus: model.AvailabilitySoldOut, Stock: &model.Stock{}},
+			{ID: bson.NewObjectID(), Name: "Full", AvailabilityStatus: model.AvailabilityUnavailable},
+		},
+	}
+
+	item := menuItemFromDish(dish)
+	if item.AvailabilityStatus != "" {
+		t.Errorf("dish availabilityStatus = %q, want it left out", item.AvailabilityStatus)
+	}
+	if item.Variants[0].AvailabilityStatus != "" || item.Variants[0].Stock != nil {
+		t.Errorf("sold out variant = %+v, want status and stock left out", item.Variants[0])
+	}
+	if item.Variants[1].AvailabilityStatus != model.AvailabilityUnavailable {
+		t.Errorf("unavailable variant status = %q, want it kept", item.Variants[1].AvailabilityStatus)
+	}
+	if dish.Variants[0].Stock == nil {
+		t.Errorf("menuItemFromDish() modified the dish's variants")
+	}
+
+	var buf bytes.Buffer
+	if err := writeMenuCSV(&buf, []MenuItem{item}); err != nil {
+		t.Fatalf("writeMenuCSV() error = %v", err)
+	}
+	if strings.Contains(buf.String(), model.AvailabilitySoldOut) {
+		t.Errorf("exported menu mentions %s:\n%s", model.AvailabilitySoldOut, buf.String())
+	}
+}
+
+func TestMenuDishKeepsIsVeg(t *testing.T) {
+	existing := &model.Dish{Name: "Dal Makhani", Price: 220, IsVeg: true}
+	no := false
+	tests := []struct {
+		name     string
+		isVeg    *bool
+		existing *model.Dish
+		want     bool
+	}{
+		{"left out keeps the dish's value", nil, existing, true},
+		{"given replaces it", &no, existing, false},
+		{"left out on a new dish", nil, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := MenuItem{AddDishInput: AddDishInput{Name: "Dal Makhani", Price: 220}, IsVeg: tt.isVeg}
+			dish, errs := menuDish(item, "r1", tt.existing)
+			if len(errs) > 0 {
+				t.Fatalf("menuDish() errors = %q", errs)
+			}
+			if dish.IsVeg != tt.want {
+				t.Errorf("IsVeg = %v, want %v", dish.IsVeg, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"context"
+	"dish-service/src/config"
+	"dish-service/src/model"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// NearbyDish is a dish returned by a location search, with the distance in
+// kilometres from the customer to the dish's restaurant.
+type NearbyDish struct {
+	DishListing `bson:",inline"`
+	DistanceKm  float64 `bson:"distanceKm" json:"distanceKm"`
+}
+
+// getNearbyDishes answers GetAllDishes when lat/lng are given: only dishes
+// from restaurants within radiusKm of the customer that also deliver that far
+// are returned, nearest restaurant first.
+func getNearbyDishes(c *gin.Context, input GetDishesFilter, filter bson.M, projection bson.M, page int64, limit int64) {
+	if input.Latitude == nil || input.Longitude == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lng must be provided together"})
+		return
+	}
+	lat, lng := *input.Latitude, *input.Longitude
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lat/lng"})
+		return
+	}
+	radiusKm := config.DefaultSearchRadiusKm()
+	if input.RadiusKm != nil {
+		if *input.RadiusKm <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "radiusKm must be positive"})
+			return
+		}
+		radiusKm = math.Min(*input.RadiusKm, config.MaxSearchRadiusKm())
+	}
+
+	restaurantIds, distancesKm, err := nearbyRestaurants(context.TODO(), lat, lng, radiusKm)
+	if err != nil {
+		log.Println("Error finding nearby restaurants:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dishes"})
+		return
+	}
+	if len(restaurantIds) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message":     "Dishes fetched successfully!",
+			"dishes":      []NearbyDish{},
+			"totalCount":  0,
+			"currentPage": page,
+			"totalPages":  0,
+		})
+		return
+	}
+
+	filter["restaurant"] = bson.M{"$in": restaurantIds}
+	nearbyProjection := bson.M{"restaurant": 1, "distanceKm": 1}
+	for field, value := range projection {
+		nearbyProjection[field] = value
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": filter},
+		bson.M{"$addFields": bson.M{
+			"distanceKm": bson.M{"$arrayElemAt": bson.A{
+				distancesKm,
+				bson.M{"$indexOfArray": bson.A{restaurantIds, "$restaurant"}},
+			}},
+		}},
+		bson.M{"$sort": bson.D{{Key: "distanceKm", Value: 1}, {Key: "_id", Value: 1}}},
+		bson.M{"$facet": bson.M{
+			"dishes": bson.A{
+				bson.M{"$skip": (page - 1) * limit},
+				bson.M{"$limit": limit},
+				bson.M{"$project": nearbyProjection},
+			},
+			"total": bson.A{bson.M{"$count": "count"}},
+		}},
+	}
+
+	cursor, err := config.DishCollection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		log.Println("Error fetching nearby dishes:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dishes"})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var result []struct {
+		Dishes []NearbyDish `bson:"dishes"`
+		Total  []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	if err := cursor.All(context.TODO(), &result); err != nil {
+		log.Println("Error decoding nearby dishes:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode dishes"})
+		return
+	}
+
+	dishes := []NearbyDish{}
+	var totalCount int64
+	if len(result) > 0 {
+		now := time.Now()
+		for _, dish := range result[0].Dishes {
+			dish.DishListing = listDish(dish.Dish, now)
+			dishes = append(dishes, dish)
+		}
+		if len(result[0].Total) > 0 {
+			totalCount = result[0].Total[0].Count
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Dishes fetched successfully!",
+		"dishes":      dishes,
+		"totalCount":  totalCount,
+		"currentPage": page,
+		"totalPages":  int64(math.Ceil(float64(totalCount) / float64(limit))),
+	})
+}
+
+// nearbyRestaurants returns the restaurants within radiusKm of the point
+// whose own delivery radius reaches it, nearest first, with their distances
+// in kilometres rounded to 10 metres.
+func nearbyRestaurants(ctx context.Context, lat float64, lng float64, radiusKm float64) ([]string, []float64, error) {
+	pipeline := bson.A{
+		bson.M{"$geoNear": bson.M{
+			"near":          model.NewGeoPoint(lat, lng),
+			"distanceField": "distance",
+			"maxDistance":   radiusKm * 1000,
+			"spherical":     true,
+		}},
+		bson.M{"$match": bson.M{"$expr": bson.M{
+			"$lte": bson.A{"$distance", bson.M{"$multiply": bson.A{"$deliveryRadiusKm", 1000}}},
+		}}},
+		bson.M{"$project": bson.M{"_id": 1, "distance": 1}},
+	}
+
+	cursor, err := config.RestaurantLocationCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, nil, err
+	}
+	var nearby []struct {
+		RestaurantId string  `bson:"_id"`
+		Distance     float64 `bson:"distance"`
+	}
+	if err := cursor.All(ctx, &nearby); err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]string, 0, len(nearby))
+	distancesKm := make([]float64, 0, len(nearby))
+	for _, restaurant := range nearby {
+		ids = append(ids, restaurant.RestaurantId)
+		distancesKm = append(distancesKm, math.Round(restaurant.Distance/10)/100)
+	}
+	return ids, distancesKm, nil
+}
@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"context"
+	"dish-service/src/config"
+	"dish-service/src/model"
+	"dish-service/src/search"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	maxSearchQueryLength = 100
+	// searchCandidates bounds how many dishes each of the text and fuzzy
+	// queries contributes before results are ranked and paginated.
+	searchCandidates = 200
+	// minFuzzyScore drops fuzzy-only candidates that barely resemble the query.
+	minFuzzyScore = 0.4
+)
+
+// SearchResult is a dish matched by SearchDishes. Highlights holds the
+// matched fields with every matching word wrapped in <em> tags.
+type SearchResult struct {
+	DishListing
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+type scoredDish struct {
+	model.Dish `bson:",inline"`
+	TextScore  float64 `bson:"textScore"`
+}
+
+var searchProjection = bson.M{
+	"name":               1,
+	"displayImage":       1,
+	"price":              1,
+	"description":        1,
+	"category":           1,
+	"isVeg":              1,
+	"tags":               1,
+	"variants":           1,
+	"availabilityStatus": 1,
+	"schedule":           1,
+}
+
+// SearchDishes ranks dishes by relevance to the q parameter. The Mongo text
+// index finds exact and stemmed word matches; trigram matching on searchGrams
+// finds misspellings. The usual listing filters still apply.
+func SearchDishes(client *mongo.Client, c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	if len(query) > maxSearchQueryLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is too long"})
+		return
+	}
+	terms := search.Words(query)
+	if len(terms) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q must contain letters or digits"})
+		return
+	}
+
+	var input GetDishesFilter
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+	page, limit := parsePagination(c)
+	filter := buildDishFilter(input)
+	if err := addDietaryFilter(filter, input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	textMatches, err := textSearch(context.TODO(), filter, strings.Join(terms, " "))
+	if err != nil {
+		log.Println("Error running text search:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search dishes"})
+		return
+	}
+	fuzzyMatches, err := fuzzySearch(context.TODO(), filter, terms)
+	if err != nil {
+		log.Println("Error running fuzzy search:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search dishes"})
+		return
+	}
+
+	results := rankSearchResults(terms, textMatches, fuzzyMatches)
+	totalCount := int64(len(results))
+	start := min((page-1)*limit, totalCount)
+	end := min(start+limit, totalCount)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Dishes fetched successfully!",
+		"dishes":      results[start:end],
+		"totalCount":  totalCount,
+		"currentPage": page,
+		"totalPages":  int64(math.Ceil(float64(totalCount) / float64(limit))),
+	})
+}
+
+func textSearch(ctx context.Context, filter bson.M, query string) ([]scoredDish, error) {
+	textFilter := bson.M{"$text": bson.M{"$search": query}}
+	for field, value := range filter {
+		textFilter[field] = value
+	}
+	projection := bson.M{"textScore": bson.M{"$meta": "textScore"}}
+	for field, value := range searchProjection {
+		projection[field] = value
+	}
+
+	cursor, err := config.DishCollection.Find(ctx, textFilter, options.Find().
+		SetProjection(projection).
+		SetSort(bson.D{{Key: "textScore", Value: bson.M{"$meta": "textScore"}}}).
+		SetLimit(searchCandidates))
+	if err != nil {
+		return nil, err
+	}
+	var dishes []scoredDish
+	err = cursor.All(ctx, &dishes)
+	return dishes, err
+}
+
+// fuzzySearch finds dishes sharing at least a third of the query's trigrams,
+// most shared first.
+func fuzzySearch(ctx context.Context, filter bson.M, terms []string) ([]model.Dish, error) {
+	grams := search.Grams(terms...)
+	fuzzyFilter := bson.M{"searchGrams": bson.M{"$in": grams}}
+	for field, value := range filter {
+		fuzzyFilter[field] = value
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": fuzzyFilter},
+		bson.M{"$addFields": bson.M{
+			"sharedGrams": bson.M{"$size": bson.M{"$setIntersection": bson.A{"$searchGrams", grams}}},
+		}},
+		bson.M{"$match": bson.M{"sharedGrams": bson.M{"$gte": max(1, len(grams)/3)}}},
+		bson.M{"$sort": bson.D{{Key: "sharedGrams", Value: -1}, {Key: "_id", Value: 1}}},
+		bson.M{"$limit": searchCandidates},
+		bson.M{"$project": searchProjection},
+	}
+	cursor, err := config.DishCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var dishes []model.Dish
+	err = cursor.All(ctx, &dishes)
+	return dishes, err
+}
+
+// rankSearchResults merges both candidate sets. A dish scores its trigram
+// similarity to the query plus its text score relative to the best text
+// match, so exact word matches rank above misspellings.
+func rankSearchResults(terms []string, textMatches []scoredDish, fuzzyMatches []model.Dish) []SearchResult {
+	bestTextScore := 0.0
+	for _, match := range textMatches {
+		bestTextScore = math.Max(bestTextScore, match.TextScore)
+	}
+
+	textScores := map[string]float64{}
+	dishes := map[string]model.Dish{}
+	for _, match := range textMatches {
+		id := match.ID.Hex()
+		dishes[id] = match.Dish
+		if bestTextScore > 0 {
+			textScores[id] = match.TextScore / bestTextScore
+		}
+	}
+	for _, dish := range fuzzyMatches {
+		if _, ok := dishes[dish.ID.Hex()]; !ok {
+			dishes[dish.ID.Hex()] = dish
+		}
+	}
+
+	now := time.Now()
+	results := make([]SearchResult, 0, len(dishes))
+	for id, dish := range dishes {
+		fuzzyScore := search.Score(terms, append([]string{dish.Name, dish.Category}, dish.Tags...)...)
+		textScore, textMatched := textScores[id]
+		if !textMatched && fuzzyScore < minFuzzyScore {
+			continue
+		}
+		results = append(results, SearchResult{
+			DishListing: listDish(dish, now),
+			Score:       math.Round((textScore+fuzzyScore)*1000) / 1000,
+			Highlights:  highlightDish(dish, terms),
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID.Hex() < results[j].ID.Hex()
+	})
+	return results
+}
+
+func highlightDish(dish model.Dish, terms []string) map[string]string {
+	highlights := map[string]string{}
+	fields := map[string]string{
+		"name":        dish.Name,
+		"description": dish.Description,
+		"category":    dish.Category,
+		"tags":        strings.Join(dish.Tags, ", "),
+	}
+	for field, text := range fields {
+		if highlighted, ok := search.Highlight(text, terms); ok {
+			highlights[field] = highlighted
+		}
+	}
+	return highlights
+}
@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"context"
+	"dish-service/src/config"
+	"dish-service/src/model"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// StockInput sets a daily stock count. Remaining defaults to DailyLimit.
+type StockInput struct {
+	DailyLimit        int    `json:"dailyLimit"`
+	Remaining         *int   `json:"remaining"`
+	LowStockThreshold int    `json:"lowStockThreshold"`
+	ResetTime         string `json:"resetTime"`
+	Timezone          string `json:"timezone"`
+}
+
+type VariantStock struct {
+	ID                 bson.ObjectID `json:"id"`
+	Name               string        `json:"name"`
+	AvailabilityStatus string        `json:"availabilityStatus,omitempty"`
+	Stock              *model.Stock  `json:"stock"`
+}
+
+func GetDishStock(client *mongo.Client, c *gin.Context) {
+	dish, ok := findOwnedDish(c)
+	if !ok {
+		return
+	}
+	variants := []VariantStock{}
+	for _, variant := range dish.Variants {
+		variants = append(variants, VariantStock{
+			ID:                 variant.ID,
+			Name:               variant.Name,
+			AvailabilityStatus: variant.AvailabilityStatus,
+			Stock:              variant.Stock,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Stock fetched successfully!",
+		"availabilityStatus": dish.AvailabilityStatus,
+		"stock":              dish.Stock,
+		"variants":           variants,
+	})
+}
+
+func SetDishStock(client *mongo.Client, c *gin.Context) {
+	stock, ok := bindStock(c)
+	if !ok {
+		return
+	}
+	dish, ok := findOwnedDish(c)
+	if !ok {
+		return
+	}
+	if len(dish.Variants) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This dish has variants, set stock on each variant instead"})
+		return
+	}
+
+	set := bson.M{"stock": stock}
+	if status, changed := stockStatus(dish.AvailabilityStatus, stock); changed {
+		set["availabilityStatus"] = status
+	}
+	_, err := config.DishCollection.UpdateOne(context.TODO(), bson.M{"_id": dish.ID}, bson.M{"$set": set})
+	if err != nil {
+		log.Println("Error updating stock:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update stock"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Stock updated successfully!", "stock": stock})
+}
+
+func DeleteDishStock(client *mongo.Client, c *gin.Context) {
+	dish, ok := findOwnedDish(c)
+	if !ok {
+		return
+	}
+
+	update := bson.M{"$unset": bson.M{"stock": ""}}
+	if dish.AvailabilityStatus == model.AvailabilitySoldOut {
+		update["$set"] = bson.M{"availabilityStatus": model.AvailabilityAvailable}
+	}
+	_, err := config.DishCollection.UpdateOne(context.TODO(), bson.M{"_id": dish.ID}, update)
+	if err != nil {
+		log.Println("Error removing stock:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove stock"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Stock tracking removed successfully!"})
+}
+
+func SetVariantStock(client *mongo.Client, c *gin.Context) {
+	stock, ok := bindStock(c)
+	if !ok {
+		return
+	}
+	dish, variant, ok := findOwnedVariant(c)
+	if !ok {
+		return
+	}
+
+	set := bson.M{"variants.$.stock": stock}
+	if status, changed := stockStatus(variant.AvailabilityStatus, stock); changed {
+		set["variants.$.availabilityStatus"] = status
+	}
+	_, err := config.DishCollection.UpdateOne(context.TODO(),
+		bson.M{"_id": dish.ID, "variants._id": variant.ID},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		log.Println("Error updating variant stock:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update stock"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Stock updated successfully!", "stock": stock})
+}
+
+func DeleteVariantStock(client *mongo.Client, c *gin.Context) {
+	dish, variant, ok := findOwnedVariant(c)
+	if !ok {
+		return
+	}
+
+	update := bson.M{"$unset": bson.M{"variants.$.stock": ""}}
+	if variant.AvailabilityStatus == model.AvailabilitySoldOut {
+		update["$set"] = bson.M{"variants.$.availabilityStatus": model.AvailabilityAvailable}
+	}
+	_, err := config.DishCollection.UpdateOne(context.TODO(),
+		bson.M{"_id": dish.ID, "variants._id": variant.ID},
+		update,
+	)
+	if err != nil {
+		log.Println("Error removing variant stock:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove stock"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Stock tracking removed successfully!"})
+}
+
+// bindStock reads and validates a StockInput. It writes the error response
+// and returns false when the input is invalid.
+func bindStock(c *gin.Context) (*model.Stock, bool) {
+	var input StockInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	stock := &model.Stock{
+		DailyLimit:        input.DailyLimit,
+		Remaining:         input.DailyLimit,
+		LowStockThreshold: input.LowStockThreshold,
+		ResetTime:         input.ResetTime,
+		Timezone:          input.Timezone,
+	}
+	if input.Remaining != nil {
+		stock.Remaining = *input.Remaining
+	}
+	if err := stock.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	stock.NextResetAt = stock.NextReset(time.Now())
+	return stock, true
+}
+
+// stockStatus returns the availability status a dish or variant should
+// have with stock, and whether it differs from current. Only the sold out
+// status is set or lifted; a dish switched off by hand stays off.
+func stockStatus(current string, stock *model.Stock) (string, bool) {
+	open := current == "" || strings.EqualFold(current, model.AvailabilityAvailable)
+	if stock.Remaining == 0 && open {
+		return model.AvailabilitySoldOut, true
+	}
+	if stock.Remaining > 0 && current == model.AvailabilitySoldOut {
+		return model.AvailabilityAvailable, true
+	}
+	return current, false
+}
+
+// findOwnedVariant loads the dish and variant named by the id and variantId
+// parameters, checking the dish belongs to the logged in restaurant.
+func findOwnedVariant(c *gin.Context) (*model.Dish, model.Variant, bool) {
+	variantId, err := bson.ObjectIDFromHex(c.Param("variantId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant ID"})
+		return nil, model.Variant{}, false
+	}
+	dish, ok := findOwnedDish(c)
+	if !ok {
+		return nil, model.Variant{}, false
+	}
+	variant, ok := dish.Variant(variantId)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Variant not found"})
+		return nil, model.Variant{}, false
+	}
+	return dish, variant, true
+}
@@ -0,0 +1,95 @@
+package inventory
+
+import (
+	"context"
+	"dish-service/src/config"
+	"dish-service/src/model"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ResetDueStock refills every dish and variant whose daily reset time has
+// passed and lifts the sold out status of those that were sold out. Each
+// update matches the reset time it read, so a reset is applied once even
+// when several instances sweep at the same time.
+func ResetDueStock(ctx context.Context, now time.Time) error {
+	cursor, err := config.DishCollection.Find(ctx, bson.M{"$or": bson.A{
+		bson.M{"stock.nextResetAt": bson.M{"$lte": now}},
+		bson.M{"variants.stock.nextResetAt": bson.M{"$lte": now}},
+	}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	reset := 0
+	for cursor.Next(ctx) {
+		var dish model.Dish
+		if err := cursor.Decode(&dish); err != nil {
+			return err
+		}
+		if dish.Stock != nil && !dish.Stock.NextResetAt.After(now) {
+			set := bson.M{
+				"stock.remaining":   dish.Stock.DailyLimit,
+				"stock.nextResetAt": dish.Stock.NextReset(now),
+			}
+			if dish.AvailabilityStatus == model.AvailabilitySoldOut && dish.Stock.DailyLimit > 0 {
+				set["availabilityStatus"] = model.AvailabilityAvailable
+			}
+			_, err := config.DishCollection.UpdateOne(ctx,
+				bson.M{"_id": dish.ID, "stock.nextResetAt": dish.Stock.NextResetAt},
+				bson.M{"$set": set},
+			)
+			if err != nil {
+				return err
+			}
+			reset++
+		}
+		for _, variant := range dish.Variants {
+			if variant.Stock == nil || variant.Stock.NextResetAt.After(now) {
+				continue
+			}
+			set := bson.M{
+				"variants.$.stock.remaining":   variant.Stock.DailyLimit,
+				"variants.$.stock.nextResetAt": variant.Stock.NextReset(now),
+			}
+			if variant.AvailabilityStatus == model.AvailabilitySoldOut && variant.Stock.DailyLimit > 0 {
+				set["variants.$.availabilityStatus"] = model.AvailabilityAvailable
+			}
+			_, err := config.DishCollection.UpdateOne(ctx,
+				bson.M{"_id": dish.ID, "variants": bson.M{"$elemMatch": bson.M{
+					"_id": variant.ID, "stock.nextResetAt": variant.Stock.NextResetAt,
+				}}},
+				bson.M{"$set": set},
+			)
+			if err != nil {
+				return err
+			}
+			reset++
+		}
+	}
+	if reset > 0 {
+		log.Printf("Reset daily stock for %d dishes and variants\n", reset)
+	}
+	return cursor.Err()
+}
+
+// RunStockReset calls ResetDueStock and RecoverAbandonedReservations every
+// interval. It never returns.
+func RunStockReset(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		now := time.Now()
+		if err := ResetDueStock(ctx, now); err != nil {
+			log.Println("Error resetting daily stock:", err)
+		}
+		if err := RecoverAbandonedReservations(ctx, now.Add(-config.StockReservationTimeout())); err != nil {
+			log.Println("Error recovering abandoned stock reservations:", err)
+		}
+		cancel()
+	}
+}
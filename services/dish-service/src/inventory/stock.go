@@ -0,0 +1,475 @@
+package inventory
+
+import (
+	"context"
+	"dish-service/src/config"
+	"dish-service/src/model"
+	"dish-service/src/queue"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Reserve takes the stock for every item of request, or none of it. Items
+// whose dish or variant does not track stock always succeed. The outcome is
+// recorded under the reservation id, so a retried request gets the same
+// answer without taking stock again. Each item is recorded on the
+// reservation as it is taken, so stock taken by a Reserve that never
+// finished can be given back by RecoverAbandonedReservations.
+func Reserve(request queue.StockReservationRequest) queue.StockReservationReply {
+	if request.ReservationId == "" {
+		return queue.StockReservationReply{Error: "reservationId is required"}
+	}
+	items, err := mergeItems(request.Items)
+	if err != nil {
+		return queue.StockReservationReply{Error: err.Error()}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	reservation := model.StockReservation{
+		ID:        request.ReservationId,
+		Items:     []model.StockReservationItem{},
+		Status:    model.ReservationPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	_, err = config.StockReservationCollection.InsertOne(ctx, reservation)
+	if mongo.IsDuplicateKeyError(err) {
+		return previousOutcome(ctx, request.ReservationId)
+	}
+	if err != nil {
+		log.Println("Error recording stock reservation:", err)
+		return queue.StockReservationReply{Error: "failed to reserve stock"}
+	}
+
+	var shortages []queue.StockShortage
+	for _, item := range items {
+		shortage, err := take(ctx, request.ReservationId, item)
+		if err != nil {
+			log.Println("Error taking stock:", err)
+			abandon(ctx, request.ReservationId, "failed to reserve stock")
+			return queue.StockReservationReply{Error: "failed to reserve stock"}
+		}
+		if shortage != nil {
+			shortages = append(shortages, *shortage)
+		}
+	}
+	if len(shortages) > 0 {
+		message := shortageMessage(shortages[0])
+		abandon(ctx, request.ReservationId, message)
+		return queue.StockReservationReply{Message: message, Shortages: shortages}
+	}
+
+	var reserved model.StockReservation
+	err = config.StockReservationCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": request.ReservationId, "status": model.ReservationPending},
+		bson.M{"$set": bson.M{"status": model.ReservationReserved, "updatedAt": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&reserved)
+	if err != nil {
+		// the stock stays taken but could not be recorded, so give it back
+		// rather than leak it
+		log.Println("Error updating stock reservation", request.ReservationId, err)
+		abandon(ctx, request.ReservationId, "failed to reserve stock")
+		return queue.StockReservationReply{Error: "failed to reserve stock"}
+	}
+	if reserved.ReleaseRequested {
+		// the order gave up on the reservation while it was being made
+		if err := Release(queue.StockReleaseMessage{ReservationId: reserved.ID, OrderId: reserved.OrderId}); err != nil {
+			log.Println("Error releasing stock reservation", reserved.ID, err)
+		}
+		return queue.StockReservationReply{Message: "reservation was released"}
+	}
+	return queue.StockReservationReply{Reserved: true}
+}
+
+// Release gives back the stock taken by a reservation. Releasing a
+// reservation that failed, was already released or no longer exists does
+// nothing. A release that arrives while the reservation is still being made
+// is recorded and applied by Reserve when it finishes, and one that arrives
+// before it has started keeps it from taking any stock.
+func Release(message queue.StockReleaseMessage) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for attempt := 0; attempt < 3; attempt++ {
+		// claim the reservation first so a redelivered release cannot give
+		// the stock back twice
+		var reservation model.StockReservation
+		err := config.StockReservationCollection.FindOneAndUpdate(ctx,
+			bson.M{"_id": message.ReservationId, "status": model.ReservationReserved},
+			bson.M{"$set": bson.M{"status": model.ReservationReleasing, "orderId": message.OrderId, "updatedAt": time.Now()}},
+		).Decode(&reservation)
+		if err == nil {
+			return giveBackReservation(ctx, reservation)
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+
+		result, err := config.StockReservationCollection.UpdateOne(ctx,
+			bson.M{"_id": message.ReservationId, "status": model.ReservationPending},
+			bson.M{"$set": bson.M{"releaseRequested": true, "orderId": message.OrderId}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount > 0 {
+			return nil
+		}
+
+		now := time.Now()
+		_, err = config.StockReservationCollection.InsertOne(ctx, model.StockReservation{
+			ID:               message.ReservationId,
+			OrderId:          message.OrderId,
+			Items:            []model.StockReservationItem{},
+			Status:           model.ReservationReleased,
+			ReleaseRequested: true,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		})
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+		// the reservation exists, but its status changed in between
+	}
+	return nil
+}
+
+// giveBackReservation gives back the stock of a reservation claimed for
+// release and marks it released.
+func giveBackReservation(ctx context.Context, reservation model.StockReservation) error {
+	err := giveBackAll(ctx, reservation, bson.M{"status": model.ReservationReleased, "updatedAt": time.Now()})
+	if err != nil {
+		// nothing was given back, so let the release be retried
+		_, resetErr := config.StockReservationCollection.UpdateOne(ctx,
+			bson.M{"_id": reservation.ID, "status": model.ReservationReleasing},
+			bson.M{"$set": bson.M{"status": model.ReservationReserved, "updatedAt": time.Now()}},
+		)
+		if resetErr != nil {
+			log.Println("Error reopening stock reservation", reservation.ID, resetErr)
+		}
+	}
+	return err
+}
+
+// abandon gives back whatever a pending reservation has taken so far and
+// marks it failed with reason. Failures are logged; a reservation left
+// pending is picked up by RecoverAbandonedReservations.
+func abandon(ctx context.Context, reservationId string, reason string) {
+	var reservation model.StockReservation
+	err := config.StockReservationCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": reservationId, "status": model.ReservationPending},
+		bson.M{"$set": bson.M{"status": model.ReservationReleasing, "updatedAt": time.Now()}},
+	).Decode(&reservation)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return
+	}
+	if err != nil {
+		log.Println("Error updating stock reservation", reservationId, err)
+		return
+	}
+	err = giveBackAll(ctx, reservation, bson.M{"status": model.ReservationFailed, "error": reason, "updatedAt": time.Now()})
+	if err != nil {
+		_, resetErr := config.StockReservationCollection.UpdateOne(ctx,
+			bson.M{"_id": reservationId, "status": model.ReservationReleasing},
+			bson.M{"$set": bson.M{"status": model.ReservationPending}},
+		)
+		if resetErr != nil {
+			log.Println("Error reopening stock reservation", reservationId, resetErr)
+		}
+	}
+}
+
+// RecoverAbandonedReservations gives back the stock of reservations still
+// pending since before staleBefore, left behind by a Reserve that stopped
+// part way.
+func RecoverAbandonedReservations(ctx context.Context, staleBefore time.Time) error {
+	cursor, err := config.StockReservationCollection.Find(ctx,
+		bson.M{"status": model.ReservationPending, "updatedAt": bson.M{"$lte": staleBefore}},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	var stale []model.StockReservation
+	if err := cursor.All(ctx, &stale); err != nil {
+		return err
+	}
+	for _, reservation := range stale {
+		log.Println("Giving back stock of abandoned reservation", reservation.ID)
+		abandon(ctx, reservation.ID, "reservation was abandoned")
+	}
+	return nil
+}
+
+// mergeItems adds up the quantities asked for the same dish or variant, so
+// each is checked against its stock once.
+func mergeItems(items []queue.StockItem) ([]queue.StockItem, error) {
+	var merged []queue.StockItem
+	index := map[string]int{}
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity must be positive")
+		}
+		key := item.DishID.Hex()
+		if item.VariantID != nil {
+			key += "/" + item.VariantID.Hex()
+		}
+		if i, ok := index[key]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged, nil
+}
+
+func previousOutcome(ctx context.Context, reservationId string) queue.StockReservationReply {
+	var reservation model.StockReservation
+	err := config.StockReservationCollection.FindOne(ctx, bson.M{"_id": reservationId}).Decode(&reservation)
+	if err != nil {
+		log.Println("Error fetching stock reservation:", err)
+		return queue.StockReservationReply{Error: "failed to reserve stock"}
+	}
+	switch reservation.Status {
+	case model.ReservationReserved:
+		return queue.StockReservationReply{Reserved: true}
+	case model.ReservationFailed:
+		return queue.StockReservationReply{Message: reservation.Error}
+	case model.ReservationPending:
+		return queue.StockReservationReply{Error: "reservation is still in progress"}
+	}
+	return queue.StockReservationReply{Message: "reservation was released"}
+}
+
+func shortageMessage(shortage queue.StockShortage) string {
+	if shortage.Remaining <= 0 {
+		return shortage.Name + " is sold out"
+	}
+	return fmt.Sprintf("Only %d of %s left", shortage.Remaining, shortage.Name)
+}
+
+// take decrements the stock of item in a single conditional update, so
+// concurrent orders can never take more than is left, and records what was
+// taken on the reservation in the same transaction. It returns a shortage
+// when there is not enough left; stock that is not tracked is never short.
+func take(ctx context.Context, reservationId string, item queue.StockItem) (*queue.StockShortage, error) {
+	filter := bson.M{"_id": item.DishID, "stock.remaining": bson.M{"$gte": item.Quantity}}
+	update := bson.M{"$inc": bson.M{"stock.remaining": -item.Quantity}}
+	if item.VariantID != nil {
+		filter = bson.M{"_id": item.DishID, "variants": bson.M{"$elemMatch": bson.M{
+			"_id": *item.VariantID, "stock.remaining": bson.M{"$gte": item.Quantity},
+		}}}
+		update = bson.M{"$inc": bson.M{"variants.$.stock.remaining": -item.Quantity}}
+	}
+
+	session, err := config.DishCollection.Database().Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	var dish model.Dish
+	var taken bool
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		taken = false
+		err := config.DishCollection.FindOneAndUpdate(ctx, filter, update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&dish)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		stock, _ := stockOf(dish, item.VariantID)
+		result, err := config.StockReservationCollection.UpdateOne(ctx,
+			bson.M{"_id": reservationId, "status": model.ReservationPending},
+			bson.M{
+				"$push": bson.M{"items": model.StockReservationItem{
+					DishID:    item.DishID,
+					VariantID: item.VariantID,
+					Quantity:  item.Quantity,
+					ResetAt:   stock.NextResetAt,
+				}},
+				"$set": bson.M{"updatedAt": time.Now()},
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 0 {
+			return nil, fmt.Errorf("stock reservation %s is no longer pending", reservationId)
+		}
+		taken = true
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !taken {
+		return shortfall(ctx, item)
+	}
+
+	stock, name := stockOf(dish, item.VariantID)
+	afterTake(ctx, dish, item.VariantID, name, *stock, item.Quantity)
+	return nil, nil
+}
+
+// shortfall works out why item could not be taken: either its stock is not
+// tracked, and there is nothing to take, or there is not enough left.
+func shortfall(ctx context.Context, item queue.StockItem) (*queue.StockShortage, error) {
+	var dish model.Dish
+	err := config.DishCollection.FindOne(ctx, bson.M{"_id": item.DishID}).Decode(&dish)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &queue.StockShortage{DishID: item.DishID, VariantID: item.VariantID, Name: "A dish"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if item.VariantID != nil {
+		if _, ok := dish.Variant(*item.VariantID); !ok {
+			return &queue.StockShortage{DishID: item.DishID, VariantID: item.VariantID, Name: dish.Name}, nil
+		}
+	}
+	stock, name := stockOf(dish, item.VariantID)
+	if stock == nil {
+		return nil, nil
+	}
+	return &queue.StockShortage{
+		DishID:    item.DishID,
+		VariantID: item.VariantID,
+		Name:      name,
+		Remaining: stock.Remaining,
+	}, nil
+}
+
+// stockOf returns the stock item is counted against and the name to show
+// for it.
+func stockOf(dish model.Dish, variantID *bson.ObjectID) (*model.Stock, string) {
+	if variantID == nil {
+		return dish.Stock, dish.Name
+	}
+	variant, ok := dish.Variant(*variantID)
+	if !ok {
+		return nil, dish.Name
+	}
+	return variant.Stock, dish.Name + " (" + variant.Name + ")"
+}
+
+// afterTake marks the dish or variant sold out when its stock reaches zero
+// and publishes an event when the stock crosses its low stock threshold or
+// runs out. Failures are logged, the stock has been taken either way.
+func afterTake(ctx context.Context, dish model.Dish, variantID *bson.ObjectID, name string, stock model.Stock, quantity int) {
+	event := queue.DishStockEvent{
+		DishID:            dish.ID,
+		VariantID:         variantID,
+		RestaurantId:      dish.RestaurantId,
+		Name:              name,
+		Remaining:         stock.Remaining,
+		DailyLimit:        stock.DailyLimit,
+		LowStockThreshold: stock.LowStockThreshold,
+		OccurredAt:        time.Now(),
+	}
+	switch {
+	case stock.Remaining == 0:
+		if err := markSoldOut(ctx, dish.ID, variantID); err != nil {
+			log.Println("Error marking dish sold out:", err)
+		}
+		event.Type = queue.DishSoldOut
+	case stock.Remaining <= stock.LowStockThreshold && stock.Remaining+quantity > stock.LowStockThreshold:
+		event.Type = queue.DishLowStock
+	default:
+		return
+	}
+	if err := queue.PublishDishStockEvent(event); err != nil {
+		log.Println("Error publishing stock event:", err)
+	}
+}
+
+// openStatus matches the statuses that are switched to sold out, leaving
+// dishes a restaurant switched off by hand alone.
+var openStatus = bson.M{"$in": bson.A{nil, "", model.AvailabilityAvailable}}
+
+func markSoldOut(ctx context.Context, dishID bson.ObjectID, variantID *bson.ObjectID) error {
+	filter := bson.M{"_id": dishID, "stock.remaining": 0, "availabilityStatus": openStatus}
+	update := bson.M{"$set": bson.M{"availabilityStatus": model.AvailabilitySoldOut}}
+	if variantID != nil {
+		filter = bson.M{"_id": dishID, "variants": bson.M{"$elemMatch": bson.M{
+			"_id": *variantID, "stock.remaining": 0, "availabilityStatus": openStatus,
+		}}}
+		update = bson.M{"$set": bson.M{"variants.$.availabilityStatus": model.AvailabilitySoldOut}}
+	}
+	_, err := config.DishCollection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// giveBackAll returns every item of a reservation claimed for release and
+// applies set to it in one transaction, so a failure part way gives nothing
+// back and retrying it cannot give any item back twice.
+func giveBackAll(ctx context.Context, reservation model.StockReservation, set bson.M) error {
+	session, err := config.DishCollection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		for _, item := range reservation.Items {
+			if err := giveBack(ctx, item); err != nil {
+				return nil, err
+			}
+		}
+		result, err := config.StockReservationCollection.UpdateOne(ctx,
+			bson.M{"_id": reservation.ID, "status": model.ReservationReleasing},
+			bson.M{"$set": set},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 0 {
+			return nil, fmt.Errorf("stock reservation %s is no longer being released", reservation.ID)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		log.Println("Error giving back stock of reservation", reservation.ID, err)
+	}
+	return err
+}
+
+// giveBack returns item to the stock it was taken from, unless that stock
+// has been reset since, and lifts a sold out status it caused.
+func giveBack(ctx context.Context, item model.StockReservationItem) error {
+	filter := bson.M{"_id": item.DishID, "stock.nextResetAt": item.ResetAt}
+	update := bson.M{"$inc": bson.M{"stock.remaining": item.Quantity}}
+	reopenFilter := bson.M{"_id": item.DishID, "availabilityStatus": model.AvailabilitySoldOut, "stock.remaining": bson.M{"$gt": 0}}
+	reopen := bson.M{"$set": bson.M{"availabilityStatus": model.AvailabilityAvailable}}
+	if item.VariantID != nil {
+		filter = bson.M{"_id": item.DishID, "variants": bson.M{"$elemMatch": bson.M{
+			"_id": *item.VariantID, "stock.nextResetAt": item.ResetAt,
+		}}}
+		update = bson.M{"$inc": bson.M{"variants.$.stock.remaining": item.Quantity}}
+		reopenFilter = bson.M{"_id": item.DishID, "variants": bson.M{"$elemMatch": bson.M{
+			"_id": *item.VariantID, "availabilityStatus": model.AvailabilitySoldOut, "stock.remaining": bson.M{"$gt": 0},
+		}}}
+		reopen = bson.M{"$set": bson.M{"variants.$.availabilityStatus": model.AvailabilityAvailable}}
+	}
+
+	result, err := config.DishCollection.UpdateOne(ctx, filter, update)
+	if err != nil || result.ModifiedCount == 0 {
+		return err
+	}
+	_, err = config.DishCollection.UpdateOne(ctx, reopenFilter, reopen)
+	return err
+}
@@ -0,0 +1,182 @@
+package media
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"dish-service/src/config"
+	"dish-service/src/model"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// acceptedTypes are the image types a dish image may be uploaded as, by the
+// content type sniffed from the file, with the extension it is stored under.
+var acceptedTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// rendition is a resized copy made of every upload. Square renditions are
+// cropped to fill size by size, the others are scaled to fit within size
+// and never enlarged.
+type rendition struct {
+	name   string
+	size   int
+	square bool
+}
+
+var renditions = []rendition{
+	{name: model.RenditionThumbnail, size: 200, square: true},
+	{name: model.RenditionSmall, size: 480},
+	{name: model.RenditionMedium, size: 960},
+	{name: model.RenditionLarge, size: 1920},
+}
+
+const jpegQuality = 82
+
+// ImageError reports an upload that is not an acceptable dish image.
+type ImageError struct {
+	Message string
+}
+
+func (e *ImageError) Error() string {
+	return e.Message
+}
+
+// File is one rendition ready to be stored.
+type File struct {
+	Name        string
+	Ext         string
+	ContentType string
+	Width       int
+	Height      int
+	Body        []byte
+}
+
+// Processed is a validated upload with its renditions. Hash identifies the
+// uploaded content.
+type Processed struct {
+	Hash        string
+	ContentType string
+	Width       int
+	Height      int
+	Files       []File
+}
+
+// Process checks that data is a JPEG, PNG or WebP image of an accepted size
+// and renders its resized copies. The dimensions are checked before the
+// image is decoded, so an oversized image is never held in memory.
+func Process(data []byte) (*Processed, error) {
+	if len(data) == 0 {
+		return nil, &ImageError{Message: "image is empty"}
+	}
+	if maxBytes := config.DishImageMaxBytes(); len(data) > maxBytes {
+		return nil, &ImageError{Message: fmt.Sprintf("image cannot be larger than %d MB", maxBytes>>20)}
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := acceptedTypes[contentType]
+	if !ok {
+		return nil, &ImageError{Message: fmt.Sprintf("unsupported image type %s, expected JPEG, PNG or WebP", contentType)}
+	}
+
+	imageConfig, err := decodeConfig(contentType, data)
+	if err != nil {
+		return nil, &ImageError{Message: "image could not be read"}
+	}
+	shorter, longer := min(imageConfig.Width, imageConfig.Height), max(imageConfig.Width, imageConfig.Height)
+	if minSide := config.DishImageMinSide(); shorter < minSide {
+		return nil, &ImageError{Message: fmt.Sprintf("image must be at least %dx%d pixels", minSide, minSide)}
+	}
+	if maxSide := config.DishImageMaxSide(); longer > maxSide {
+		return nil, &ImageError{Message: fmt.Sprintf("image cannot be wider or taller than %d pixels", maxSide)}
+	}
+	source, err := decode(contentType, data)
+	if err != nil {
+		return nil, &ImageError{Message: "image could not be read"}
+	}
+
+	sum := sha256.Sum256(data)
+	processed := &Processed{
+		Hash:        hex.EncodeToString(sum[:12]),
+		ContentType: contentType,
+		Width:       imageConfig.Width,
+		Height:      imageConfig.Height,
+		Files: []File{{
+			Name:        model.RenditionOriginal,
+			Ext:         ext,
+			ContentType: contentType,
+			Width:       imageConfig.Width,
+			Height:      imageConfig.Height,
+			Body:        data,
+		}},
+	}
+	for _, r := range renditions {
+		resized := resize(source, r)
+		var body bytes.Buffer
+		if err := jpeg.Encode(&body, resized, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+		processed.Files = append(processed.Files, File{
+			Name:        r.name,
+			Ext:         ".jpg",
+			ContentType: "image/jpeg",
+			Width:       resized.Bounds().Dx(),
+			Height:      resized.Bounds().Dy(),
+			Body:        body.Bytes(),
+		})
+	}
+	return processed, nil
+}
+
+func decodeConfig(contentType string, data []byte) (image.Config, error) {
+	switch contentType {
+	case "image/png":
+		return png.DecodeConfig(bytes.NewReader(data))
+	case "image/webp":
+		return webp.DecodeConfig(bytes.NewReader(data))
+	default:
+		return jpeg.DecodeConfig(bytes.NewReader(data))
+	}
+}
+
+func decode(contentType string, data []byte) (image.Image, error) {
+	switch contentType {
+	case "image/png":
+		return png.Decode(bytes.NewReader(data))
+	case "image/webp":
+		return webp.Decode(bytes.NewReader(data))
+	default:
+		return jpeg.Decode(bytes.NewReader(data))
+	}
+}
+
+// resize scales source for r onto a white background, as JPEG has no
+// transparency.
+func resize(source image.Image, r rendition) *image.RGBA {
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	crop := bounds
+	if r.square {
+		side := min(width, height)
+		x := bounds.Min.X + (width-side)/2
+		y := bounds.Min.Y + (height-side)/2
+		crop = image.Rect(x, y, x+side, y+side)
+		width, height = min(side, r.size), min(side, r.size)
+	} else if longer := max(width, height); longer > r.size {
+		width = max(1, width*r.size/longer)
+		height = max(1, height*r.size/longer)
+	}
+
+	resized := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(resized, resized.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(resized, resized.Bounds(), source, crop, draw.Over, nil)
+	return resized
+}
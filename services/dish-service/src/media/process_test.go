@@ -0,0 +1,171 @@
+package media
+
+import (
+	"bytes"
+	"dish-service/src/model"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(width, height)); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(width, height), nil); err != nil {
+		t.Fatalf("jpeg.Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessRejects(t *testing.T) {
+	t.Setenv("DISH_IMAGE_MAX_MB", "1")
+	t.Setenv("DISH_IMAGE_MIN_SIDE_PX", "300")
+	t.Setenv("DISH_IMAGE_MAX_SIDE_PX", "1000")
+
+	pngData := encodePNG(t, 400, 400)
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"too many bytes", make([]byte, 1<<20+1)},
+		{"unsupported type", []byte("GIF89a not really an image")},
+		{"plain text", []byte("a photo of dal makhani")},
+		{"truncated", pngData[:len(pngData)/2]},
+		{"corrupt header", append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...)},
+		{"shorter side too small", encodePNG(t, 800, 299)},
+		{"longer side too large", encodePNG(t, 1001, 400)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processed, err := Process(tt.data)
+			var imageErr *ImageError
+			if !errors.As(err, &imageErr) {
+				t.Fatalf("Process() = %+v, %v, want an ImageError", processed, err)
+			}
+		})
+	}
+}
+
+func TestProcessRenditions(t *testing.T) {
+	t.Setenv("DISH_IMAGE_MAX_MB", "10")
+	t.Setenv("DISH_IMAGE_MIN_SIDE_PX", "300")
+	t.Setenv("DISH_IMAGE_MAX_SIDE_PX", "6000")
+
+	type size struct{ width, height int }
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		ext         string
+		want        map[string]size
+	}{
+		{
+			name:        "landscape PNG is never enlarged",
+			data:        encodePNG(t, 600, 400),
+			contentType: "image/png",
+			ext:         ".png",
+			want: map[string]size{
+				model.RenditionOriginal:  {600, 400},
+				model.RenditionThumbnail: {200, 200},
+				model.RenditionSmall:     {480, 320},
+				model.RenditionMedium:    {600, 400},
+				model.RenditionLarge:     {600, 400},
+			},
+		},
+		{
+			name:        "portrait JPEG",
+			data:        encodeJPEG(t, 300, 1000),
+			contentType: "image/jpeg",
+			ext:         ".jpg",
+			want: map[string]size{
+				model.RenditionOriginal:  {300, 1000},
+				model.RenditionThumbnail: {200, 200},
+				model.RenditionSmall:     {144, 480},
+				model.RenditionMedium:    {288, 960},
+				model.RenditionLarge:     {300, 1000},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processed, err := Process(tt.data)
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+			if processed.ContentType != tt.contentType {
+				t.Errorf("ContentType = %q, want %q", processed.ContentType, tt.contentType)
+			}
+			if len(processed.Hash) != 24 {
+				t.Errorf("Hash = %q, want 24 hex characters", processed.Hash)
+			}
+			if len(processed.Files) != len(tt.want) {
+				t.Fatalf("Process() made %d files, want %d", len(processed.Files), len(tt.want))
+			}
+			for _, file := range processed.Files {
+				want, ok := tt.want[file.Name]
+				if !ok {
+					t.Errorf("unexpected rendition %q", file.Name)
+					continue
+				}
+				if file.Width != want.width || file.Height != want.height {
+					t.Errorf("%s is %dx%d, want %dx%d", file.Name, file.Width, file.Height, want.width, want.height)
+				}
+				if file.Name == model.RenditionOriginal {
+					if file.Ext != tt.ext || file.ContentType != tt.contentType || !bytes.Equal(file.Body, tt.data) {
+						t.Errorf("original = %s %s, want the upload as %s %s", file.Ext, file.ContentType, tt.ext, tt.contentType)
+					}
+					continue
+				}
+				decoded, err := jpeg.DecodeConfig(bytes.NewReader(file.Body))
+				if err != nil {
+					t.Fatalf("%s is not a JPEG: %v", file.Name, err)
+				}
+				if file.Ext != ".jpg" || decoded.Width != want.width || decoded.Height != want.height {
+					t.Errorf("%s body is %s %dx%d, want .jpg %dx%d", file.Name, file.Ext, decoded.Width, decoded.Height, want.width, want.height)
+				}
+			}
+		})
+	}
+
+	t.Run("same upload, same hash", func(t *testing.T) {
+		data := encodePNG(t, 400, 400)
+		first, err := Process(data)
+		if err != nil {
+			t.Fatalf("Process() error = %v", err)
+		}
+		second, err := Process(data)
+		if err != nil {
+			t.Fatalf("Process() error = %v", err)
+		}
+		other, err := Process(encodePNG(t, 400, 401))
+		if err != nil {
+			t.Fatalf("Process() error = %v", err)
+		}
+		if first.Hash != second.Hash || first.Hash == other.Hash {
+			t.Errorf("hashes = %q, %q and %q for another image", first.Hash, second.Hash, other.Hash)
+		}
+	})
+}
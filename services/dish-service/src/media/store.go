@@ -0,0 +1,68 @@
+package media
+
+import (
+	"dish-service/src/model"
+	"dish-service/src/utils"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Every file of a dish lives under dishPrefix, and every rendition of one
+// image under that image's hash, so uploads never overwrite each other.
+func dishPrefix(restaurantId string, dishId string) string {
+	return fmt.Sprintf("dishes/%s/%s/", restaurantId, dishId)
+}
+
+// Store uploads the renditions of a processed image for a dish. If any
+// upload fails the ones already made are removed again.
+func Store(restaurantId string, dishId string, processed *Processed) (model.DishImage, error) {
+	image := model.DishImage{
+		ID:          processed.Hash,
+		ContentType: processed.ContentType,
+		Width:       processed.Width,
+		Height:      processed.Height,
+		UploadedAt:  time.Now(),
+	}
+	for _, file := range processed.Files {
+		key := dishPrefix(restaurantId, dishId) + processed.Hash + "/" + file.Name + file.Ext
+		url, err := utils.SaveFile(key, file.Body, file.ContentType)
+		if err != nil {
+			Delete(image)
+			return model.DishImage{}, err
+		}
+		image.Renditions = append(image.Renditions, model.ImageRendition{
+			Name:        file.Name,
+			Key:         key,
+			URL:         url,
+			ContentType: file.ContentType,
+			Width:       file.Width,
+			Height:      file.Height,
+			Size:        len(file.Body),
+		})
+	}
+	return image, nil
+}
+
+// Delete removes the stored files of images. Failures are logged rather
+// than returned, as the dish has already let go of them.
+func Delete(images ...model.DishImage) {
+	var keys []string
+	for _, image := range images {
+		keys = append(keys, image.Keys()...)
+	}
+	if len(keys) == 0 {
+		return
+	}
+	if err := utils.DeleteFiles(keys); err != nil {
+		log.Println("Error deleting dish images:", err)
+	}
+}
+
+// DeleteDish removes every stored file of a deleted dish, including any
+// left behind by uploads that never made it onto the dish.
+func DeleteDish(restaurantId string, dishId string) {
+	if err := utils.DeleteFilesWithPrefix(dishPrefix(restaurantId, dishId)); err != nil {
+		log.Println("Error deleting images of dish", dishId+":", err)
+	}
+}
@@ -0,0 +1,258 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Combo is a bundle of existing dishes sold at its own price, such as a
+// burger with fries and a drink. Each group is one slot of the bundle: a
+// group with a single option is a fixed component, one with several lets
+// the customer pick, like "pick one drink".
+type Combo struct {
+	ID                 bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	RestaurantId       string        `bson:"restaurant" json:"restaurantId"`
+	Name               string        `bson:"name" json:"name"`
+	Description        string        `bson:"description" json:"description"`
+	DisplayImage       string        `bson:"displayImage" json:"displayImage"`
+	Price              int           `bson:"price" json:"price"`
+	Tags               []string      `bson:"tags" json:"tags"`
+	Groups             []ComboGroup  `bson:"groups" json:"groups"`
+	AvailabilityStatus string        `bson:"availabilityStatus" json:"availabilityStatus"`
+}
+
+// ComboGroup asks the customer to pick between MinChoices and MaxChoices of
+// Options. Both default to 1.
+type ComboGroup struct {
+	Key        string        `bson:"key" json:"key"`
+	Label      string        `bson:"label" json:"label"`
+	MinChoices int           `bson:"minChoices" json:"minChoices"`
+	MaxChoices int           `bson:"maxChoices" json:"maxChoices"`
+	Options    []ComboOption `bson:"options" json:"options"`
+}
+
+// ComboOption is a dish (or one variant of it) that can fill a group.
+// PriceDelta is added to the combo price when it is picked.
+type ComboOption struct {
+	ID         bson.ObjectID  `bson:"_id" json:"id"`
+	DishID     bson.ObjectID  `bson:"dishId" json:"dishId"`
+	VariantID  *bson.ObjectID `bson:"variantId,omitempty" json:"variantId,omitempty"`
+	Quantity   int            `bson:"quantity" json:"quantity"`
+	PriceDelta int            `bson:"priceDelta,omitempty" json:"priceDelta,omitempty"`
+}
+
+// ComboError reports a combo or a selection that does not fit it.
+type ComboError struct {
+	Message string
+}
+
+func (e *ComboError) Error() string {
+	return e.Message
+}
+
+func comboErrorf(format string, args ...any) error {
+	return &ComboError{Message: fmt.Sprintf(format, args...)}
+}
+
+// IsAvailable reports whether the combo itself is switched on. Whether its
+// components can be ordered is checked separately with Availability.
+func (c Combo) IsAvailable() bool {
+	return c.AvailabilityStatus == "" || strings.EqualFold(c.AvailabilityStatus, AvailabilityAvailable)
+}
+
+// PrepareCombo validates the combo's shape against its component dishes,
+// keyed by hex id, fills in defaults and gives new options an id. Options
+// that carry an id must be among existing.
+func PrepareCombo(combo *Combo, dishes map[string]Dish, existing []ComboGroup) error {
+	combo.Name = strings.TrimSpace(combo.Name)
+	if combo.Name == "" {
+		return comboErrorf("name is required")
+	}
+	if combo.Price <= 0 {
+		return comboErrorf("price must be positive")
+	}
+	if len(combo.Groups) == 0 {
+		return comboErrorf("a combo needs at least one group")
+	}
+
+	known := map[bson.ObjectID]bool{}
+	for _, group := range existing {
+		for _, option := range group.Options {
+			known[option.ID] = true
+		}
+	}
+	keys := map[string]bool{}
+	ids := map[bson.ObjectID]bool{}
+	for i := range combo.Groups {
+		group := &combo.Groups[i]
+		if !customizationKey.MatchString(group.Key) {
+			return comboErrorf("group key %q must start with a letter and contain only letters, digits and underscores", group.Key)
+		}
+		if keys[group.Key] {
+			return comboErrorf("duplicate group %q", group.Key)
+		}
+		keys[group.Key] = true
+		if len(group.Options) == 0 {
+			return comboErrorf("group %q needs at least one option", group.Key)
+		}
+		if group.MinChoices == 0 && group.MaxChoices == 0 {
+			group.MinChoices, group.MaxChoices = 1, 1
+		}
+		if group.MinChoices < 0 || group.MaxChoices < group.MinChoices || group.MaxChoices > len(group.Options) || group.MaxChoices == 0 {
+			return comboErrorf("group %q must allow between 1 and %d choices with minChoices <= maxChoices", group.Key, len(group.Options))
+		}
+
+		for j := range group.Options {
+			option := &group.Options[j]
+			if option.Quantity == 0 {
+				option.Quantity = 1
+			}
+			if option.Quantity < 0 || option.PriceDelta < 0 {
+				return comboErrorf("group %q has an option with a negative quantity or price", group.Key)
+			}
+			dish, ok := dishes[option.DishID.Hex()]
+			if !ok {
+				return comboErrorf("dish %s does not exist", option.DishID.Hex())
+			}
+			if dish.RestaurantId != combo.RestaurantId {
+				return comboErrorf("dish %s does not belong to this restaurant", option.DishID.Hex())
+			}
+			if len(dish.Variants) > 0 {
+				if option.VariantID == nil {
+					return comboErrorf("dish %s is sold in variants; pick one", option.DishID.Hex())
+				}
+				if _, ok := dish.Variant(*option.VariantID); !ok {
+					return comboErrorf("variant %s does not exist for dish %s", option.VariantID.Hex(), option.DishID.Hex())
+				}
+			} else if option.VariantID != nil {
+				return comboErrorf("dish %s has no variants", option.DishID.Hex())
+			}
+			if option.ID.IsZero() {
+				option.ID = bson.NewObjectID()
+			} else if !known[option.ID] || ids[option.ID] {
+				return comboErrorf("unknown option id %s", option.ID.Hex())
+			}
+			ids[option.ID] = true
+		}
+	}
+	return nil
+}
+
+// ComponentIDs returns the distinct dishes the combo is made of.
+func (c Combo) ComponentIDs() []bson.ObjectID {
+	seen := map[bson.ObjectID]bool{}
+	var ids []bson.ObjectID
+	for _, group := range c.Groups {
+		for _, option := range group.Options {
+			if !seen[option.DishID] {
+				seen[option.DishID] = true
+				ids = append(ids, option.DishID)
+			}
+		}
+	}
+	return ids
+}
+
+// optionAvailable reports whether the dish, and variant if any, behind an
+// option can be ordered at now.
+func optionAvailable(option ComboOption, dishes map[string]Dish, now time.Time) bool {
+	dish, ok := dishes[option.DishID.Hex()]
+	if !ok {
+		return false
+	}
+	if available, _ := dish.AvailableAt(now); !available {
+		return false
+	}
+	if option.VariantID == nil {
+		return true
+	}
+	variant, ok := dish.Variant(*option.VariantID)
+	return ok && variant.IsAvailable()
+}
+
+// Availability reports whether the combo can be ordered at now: it must be
+// switched on and every group must still have enough orderable options.
+func (c Combo) Availability(dishes map[string]Dish, now time.Time) bool {
+	if !c.IsAvailable() {
+		return false
+	}
+	for _, group := range c.Groups {
+		available := 0
+		for _, option := range group.Options {
+			if optionAvailable(option, dishes, now) {
+				available++
+			}
+		}
+		if available < group.MinChoices {
+			return false
+		}
+	}
+	return true
+}
+
+// ComboComponent is one dish the kitchen prepares for a combo.
+type ComboComponent struct {
+	DishID    bson.ObjectID
+	VariantID *bson.ObjectID
+	Quantity  int
+}
+
+// Select checks the customer's picks, option ids keyed by group key, and
+// returns the components to prepare and the price of one combo. Groups with
+// a single option may be left out.
+func (c Combo) Select(selections map[string][]bson.ObjectID, dishes map[string]Dish, now time.Time) ([]ComboComponent, int, error) {
+	groups := map[string]bool{}
+	for _, group := range c.Groups {
+		groups[group.Key] = true
+	}
+	for key := range selections {
+		if !groups[key] {
+			return nil, 0, comboErrorf("unknown group %q", key)
+		}
+	}
+
+	var components []ComboComponent
+	price := c.Price
+	for _, group := range c.Groups {
+		picked, ok := selections[group.Key]
+		if !ok && len(group.Options) == 1 {
+			picked = []bson.ObjectID{group.Options[0].ID}
+		}
+		if len(picked) < group.MinChoices || len(picked) > group.MaxChoices {
+			return nil, 0, comboErrorf("group %q needs between %d and %d choices", group.Key, group.MinChoices, group.MaxChoices)
+		}
+		seen := map[bson.ObjectID]bool{}
+		for _, id := range picked {
+			if seen[id] {
+				return nil, 0, comboErrorf("option %s picked twice in group %q", id.Hex(), group.Key)
+			}
+			seen[id] = true
+			option, ok := group.option(id)
+			if !ok {
+				return nil, 0, comboErrorf("option %s is not in group %q", id.Hex(), group.Key)
+			}
+			if !optionAvailable(option, dishes, now) {
+				return nil, 0, comboErrorf("option %s in group %q is currently unavailable", id.Hex(), group.Key)
+			}
+			price += option.PriceDelta
+			components = append(components, ComboComponent{
+				DishID:    option.DishID,
+				VariantID: option.VariantID,
+				Quantity:  option.Quantity,
+			})
+		}
+	}
+	return components, price, nil
+}
+
+func (g ComboGroup) option(id bson.ObjectID) (ComboOption, bool) {
+	for _, option := range g.Options {
+		if option.ID == id {
+			return option, true
+		}
+	}
+	return ComboOption{}, false
+}
@@ -0,0 +1,223 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestPrepareCombo(t *testing.T) {
+	restaurant := "r1"
+	burgerID := bson.NewObjectID()
+	drinkID := bson.NewObjectID()
+	foreignID := bson.NewObjectID()
+	smallID := bson.NewObjectID()
+	existingID := bson.NewObjectID()
+
+	dishes := map[string]Dish{
+		burgerID.Hex():  {ID: burgerID, RestaurantId: restaurant},
+		drinkID.Hex():   {ID: drinkID, RestaurantId: restaurant, Variants: []Variant{{ID: smallID, Name: "Small"}}},
+		foreignID.Hex(): {ID: foreignID, RestaurantId: "r2"},
+	}
+	existing := []ComboGroup{{Key: "main", Options: []ComboOption{{ID: existingID, DishID: burgerID}}}}
+
+	burger := ComboOption{DishID: burgerID}
+	smallDrink := ComboOption{DishID: drinkID, VariantID: &smallID}
+	combo := func(groups ...ComboGroup) Combo {
+		return Combo{RestaurantId: restaurant, Name: " Burger meal ", Price: 299, Groups: groups}
+	}
+	group := func(key string, options ...ComboOption) ComboGroup {
+		return ComboGroup{Key: key, Options: options}
+	}
+
+	tests := []struct {
+		name    string
+		combo   Combo
+		wantErr bool
+	}{
+		{name: "fixed and choice groups", combo: combo(group("main", burger), group("drink", smallDrink))},
+		{name: "existing option keeps its id", combo: combo(group("main", ComboOption{ID: existingID, DishID: burgerID}))},
+		{name: "missing name", combo: Combo{RestaurantId: restaurant, Name: "  ", Price: 299, Groups: []ComboGroup{group("main", burger)}}, wantErr: true},
+		{name: "free combo", combo: Combo{RestaurantId: restaurant, Name: "Meal", Groups: []ComboGroup{group("main", burger)}}, wantErr: true},
+		{name: "no groups", combo: combo(), wantErr: true},
+		{name: "invalid group key", combo: combo(group("main dish", burger)), wantErr: true},
+		{name: "duplicate group", combo: combo(group("main", burger), group("main", burger)), wantErr: true},
+		{name: "empty group", combo: combo(group("main")), wantErr: true},
+		{
+			name:    "more choices than options",
+			combo:   combo(ComboGroup{Key: "main", MinChoices: 1, MaxChoices: 2, Options: []ComboOption{burger}}),
+			wantErr: true,
+		},
+		{
+			name:    "min above max",
+			combo:   combo(ComboGroup{Key: "main", MinChoices: 2, MaxChoices: 1, Options: []ComboOption{burger, burger}}),
+			wantErr: true,
+		},
+		{name: "negative quantity", combo: combo(group("main", ComboOption{DishID: burgerID, Quantity: -1})), wantErr: true},
+		{name: "negative price delta", combo: combo(group("main", ComboOption{DishID: burgerID, PriceDelta: -10})), wantErr: true},
+		{name: "unknown dish", combo: combo(group("main", ComboOption{DishID: bson.NewObjectID()})), wantErr: true},
+		{name: "another restaurant's dish", combo: combo(group("main", ComboOption{DishID: foreignID})), wantErr: true},
+		{name: "variant required", combo: combo(group("drink", ComboOption{DishID: drinkID})), wantErr: true},
+		{name: "unknown variant", combo: combo(group("drink", ComboOption{DishID: drinkID, VariantID: &existingID})), wantErr: true},
+		{name: "variant of a dish without variants", combo: combo(group("main", ComboOption{DishID: burgerID, VariantID: &smallID})), wantErr: true},
+		{name: "unknown option id", combo: combo(group("main", ComboOption{ID: bson.NewObjectID(), DishID: burgerID})), wantErr: true},
+		{
+			name:    "option id used twice",
+			combo:   combo(group("main", ComboOption{ID: existingID, DishID: burgerID}), group("side", ComboOption{ID: existingID, DishID: burgerID})),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := PrepareCombo(&tt.combo, dishes, existing)
+			if tt.wantErr {
+				var comboErr *ComboError
+				if !errors.As(err, &comboErr) {
+					t.Fatalf("PrepareCombo() error = %v, want a ComboError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PrepareCombo() error = %v", err)
+			}
+			if tt.combo.Name != "Burger meal" {
+				t.Errorf("Name = %q, want it trimmed", tt.combo.Name)
+			}
+			for _, group := range tt.combo.Groups {
+				if group.MinChoices != 1 || group.MaxChoices != 1 {
+					t.Errorf("group %q choices = %d..%d, want 1..1", group.Key, group.MinChoices, group.MaxChoices)
+				}
+				for _, option := range group.Options {
+					if option.ID.IsZero() {
+						t.Errorf("group %q has an option without an id", group.Key)
+					}
+					if option.Quantity != 1 {
+						t.Errorf("group %q option quantity = %d, want 1", group.Key, option.Quantity)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestComboSelect(t *testing.T) {
+	now := time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)
+	burgerID := bson.NewObjectID()
+	colaID := bson.NewObjectID()
+	lassiID := bson.NewObjectID()
+	soldOutID := bson.NewObjectID()
+
+	dishes := map[string]Dish{
+		burgerID.Hex():  {ID: burgerID},
+		colaID.Hex():    {ID: colaID},
+		lassiID.Hex():   {ID: lassiID},
+		soldOutID.Hex(): {ID: soldOutID, AvailabilityStatus: AvailabilityUnavailable},
+	}
+	burger := ComboOption{ID: bson.NewObjectID(), DishID: burgerID, Quantity: 1}
+	cola := ComboOption{ID: bson.NewObjectID(), DishID: colaID, Quantity: 1}
+	lassi := ComboOption{ID: bson.NewObjectID(), DishID: lassiID, Quantity: 1, PriceDelta: 30}
+	soldOut := ComboOption{ID: bson.NewObjectID(), DishID: soldOutID, Quantity: 1}
+
+	combo := Combo{
+		Price: 299,
+		Groups: []ComboGroup{
+			{Key: "main", MinChoices: 1, MaxChoices: 1, Options: []ComboOption{burger}},
+			{Key: "drink", MinChoices: 1, MaxChoices: 1, Options: []ComboOption{cola, lassi, soldOut}},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		selections     map[string][]bson.ObjectID
+		wantPrice      int
+		wantComponents []bson.ObjectID
+		wantErr        bool
+	}{
+		{
+			name:           "fixed group may be left out",
+			selections:     map[string][]bson.ObjectID{"drink": {cola.ID}},
+			wantPrice:      299,
+			wantComponents: []bson.ObjectID{burgerID, colaID},
+		},
+		{
+			name:           "price delta is added",
+			selections:     map[string][]bson.ObjectID{"main": {burger.ID}, "drink": {lassi.ID}},
+			wantPrice:      329,
+			wantComponents: []bson.ObjectID{burgerID, lassiID},
+		},
+		{name: "choice group left out", selections: map[string][]bson.ObjectID{}, wantErr: true},
+		{name: "too many picks", selections: map[string][]bson.ObjectID{"drink": {cola.ID, lassi.ID}}, wantErr: true},
+		{name: "unknown group", selections: map[string][]bson.ObjectID{"drink": {cola.ID}, "dessert": {cola.ID}}, wantErr: true},
+		{name: "option of another group", selections: map[string][]bson.ObjectID{"drink": {burger.ID}}, wantErr: true},
+		{name: "unavailable option", selections: map[string][]bson.ObjectID{"drink": {soldOut.ID}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			components, price, err := combo.Select(tt.selections, dishes, now)
+			if tt.wantErr {
+				var comboErr *ComboError
+				if !errors.As(err, &comboErr) {
+					t.Fatalf("Select() error = %v, want a ComboError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Select() error = %v", err)
+			}
+			if price != tt.wantPrice {
+				t.Errorf("Select() price = %d, want %d", price, tt.wantPrice)
+			}
+			if len(components) != len(tt.wantComponents) {
+				t.Fatalf("Select() components = %+v, want dishes %v", components, tt.wantComponents)
+			}
+			for i, component := range components {
+				if component.DishID != tt.wantComponents[i] || component.Quantity != 1 {
+					t.Errorf("component %d = %+v, want dish %v", i, component, tt.wantComponents[i])
+				}
+			}
+		})
+	}
+
+	t.Run("picked twice", func(t *testing.T) {
+		twoDrinks := Combo{Price: 299, Groups: []ComboGroup{
+			{Key: "drinks", MinChoices: 1, MaxChoices: 2, Options: []ComboOption{cola, lassi}},
+		}}
+		_, _, err := twoDrinks.Select(map[string][]bson.ObjectID{"drinks": {cola.ID, cola.ID}}, dishes, now)
+		var comboErr *ComboError
+		if !errors.As(err, &comboErr) {
+			t.Errorf("Select() error = %v, want a ComboError", err)
+		}
+	})
+}
+
+func TestComboAvailability(t *testing.T) {
+	now := time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)
+	colaID := bson.NewObjectID()
+	soldOutID := bson.NewObjectID()
+	dishes := map[string]Dish{
+		colaID.Hex():    {ID: colaID},
+		soldOutID.Hex(): {ID: soldOutID, AvailabilityStatus: AvailabilityUnavailable},
+	}
+	cola := ComboOption{ID: bson.NewObjectID(), DishID: colaID}
+	soldOut := ComboOption{ID: bson.NewObjectID(), DishID: soldOutID}
+
+	tests := []struct {
+		name  string
+		combo Combo
+		want  bool
+	}{
+		{"one option left is enough", Combo{Groups: []ComboGroup{{Key: "drink", MinChoices: 1, Options: []ComboOption{cola, soldOut}}}}, true},
+		{"switched off", Combo{AvailabilityStatus: AvailabilityUnavailable, Groups: []ComboGroup{{Key: "drink", MinChoices: 1, Options: []ComboOption{cola}}}}, false},
+		{"group cannot be filled", Combo{Groups: []ComboGroup{{Key: "drink", MinChoices: 2, Options: []ComboOption{cola, soldOut}}}}, false},
+		{"component dish missing", Combo{Groups: []ComboGroup{{Key: "drink", MinChoices: 1, Options: []ComboOption{{DishID: bson.NewObjectID()}}}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.combo.Availability(dishes, now); got != tt.want {
+				t.Errorf("Availability() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// Customization option types.
+const (
+	CustomizationRange        = "range"
+	CustomizationBoolean      = "boolean"
+	CustomizationSingleChoice = "single_choice"
+	CustomizationMultiChoice  = "multi_choice"
+)
+
+var customizationKey = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]{0,39}$`)
+
+// CustomizationOption is one way a customer may customize a dish.
+//
+// A range option takes an integer between Min and Max and costs PriceDelta
+// for every step above Min. A boolean option costs PriceDelta when switched
+// on. Choice options pick one (or, for multi_choice, up to MaxChoices) of
+// Choices and cost the sum of the picked choices' PriceDelta.
+//
+// Default is used when the customer does not submit a value: an integer for
+// range, a bool for boolean, a choice key for single_choice and a list of
+// choice keys for multi_choice. A Required option without a Default must be
+// submitted.
+type CustomizationOption struct {
+	Key        string                `bson:"key" json:"key"`
+	Label      string                `bson:"label" json:"label"`
+	Type       string                `bson:"type" json:"type"`
+	Required   bool                  `bson:"required" json:"required"`
+	Min        int                   `bson:"min,omitempty" json:"min,omitempty"`
+	Max        int                   `bson:"max,omitempty" json:"max,omitempty"`
+	Default    any                   `bson:"default" json:"default,omitempty"`
+	PriceDelta int                   `bson:"priceDelta,omitempty" json:"priceDelta,omitempty"`
+	Choices    []CustomizationChoice `bson:"choices,omitempty" json:"choices,omitempty"`
+	MaxChoices int                   `bson:"maxChoices,omitempty" json:"maxChoices,omitempty"`
+}
+
+type CustomizationChoice struct {
+	Key        string `bson:"key" json:"key"`
+	Label      string `bson:"label" json:"label"`
+	PriceDelta int    `bson:"priceDelta,omitempty" json:"priceDelta,omitempty"`
+}
+
+// CustomizationError reports an option or submitted value that does not fit
+// the dish's schema.
+type CustomizationError struct {
+	Key     string
+	Message string
+}
+
+func (e *CustomizationError) Error() string {
+	if e.Key == "" {
+		return e.Message
+	}
+	return e.Key + ": " + e.Message
+}
+
+func customizationErrorf(key string, format string, args ...any) error {
+	return &CustomizationError{Key: key, Message: fmt.Sprintf(format, args...)}
+}
+
+// ValidateCustomizationOptions checks a dish's customization schema and
+// rewrites each Default into its canonical Go type.
+func ValidateCustomizationOptions(options []CustomizationOption) error {
+	seen := map[string]bool{}
+	for i := range options {
+		option := &options[i]
+		if !customizationKey.MatchString(option.Key) {
+			return customizationErrorf(option.Key, "key must start with a letter and contain only letters, digits and underscores")
+		}
+		if seen[option.Key] {
+			return customizationErrorf(option.Key, "duplicate option")
+		}
+		seen[option.Key] = true
+		if option.PriceDelta < 0 {
+			return customizationErrorf(option.Key, "priceDelta cannot be negative")
+		}
+
+		switch option.Type {
+		case CustomizationRange:
+			if option.Min > option.Max {
+				return customizationErrorf(option.Key, "min cannot be greater than max")
+			}
+			if len(option.Choices) > 0 {
+				return customizationErrorf(option.Key, "range options have no choices")
+			}
+		case CustomizationBoolean:
+			if len(option.Choices) > 0 {
+				return customizationErrorf(option.Key, "boolean options have no choices")
+			}
+		case CustomizationSingleChoice, CustomizationMultiChoice:
+			if len(option.Choices) == 0 {
+				return customizationErrorf(option.Key, "choice options need at least one choice")
+			}
+			choiceKeys := map[string]bool{}
+			for _, choice := range option.Choices {
+				if !customizationKey.MatchString(choice.Key) {
+					return customizationErrorf(option.Key, "invalid choice key %q", choice.Key)
+				}
+				if choiceKeys[choice.Key] {
+					return customizationErrorf(option.Key, "duplicate choice %q", choice.Key)
+				}
+				if choice.PriceDelta < 0 {
+					return customizationErrorf(option.Key, "choice %q priceDelta cannot be negative", choice.Key)
+				}
+				choiceKeys[choice.Key] = true
+			}
+			if option.MaxChoices < 0 || option.MaxChoices > len(option.Choices) {
+				return customizationErrorf(option.Key, "maxChoices must be between 0 and the number of choices")
+			}
+		default:
+			return customizationErrorf(option.Key, "unknown type %q", option.Type)
+		}
+
+		if option.Default != nil {
+			raw, err := json.Marshal(option.Default)
+			if err != nil {
+				return customizationErrorf(option.Key, "invalid default")
+			}
+			value, _, err := option.Apply(raw)
+			if err != nil {
+				return customizationErrorf(option.Key, "invalid default: %s", err.(*CustomizationError).Message)
+			}
+			option.Default = value
+		}
+	}
+	return nil
+}
+
+// Apply validates a submitted value for the option and returns it in
+// canonical form (int, bool, string or []string) with its per-unit surcharge.
+func (o CustomizationOption) Apply(raw json.RawMessage) (any, int, error) {
+	switch o.Type {
+	case CustomizationRange:
+		var value int
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, 0, customizationErrorf(o.Key, "must be a whole number")
+		}
+		if value < o.Min || value > o.Max {
+			return nil, 0, customizationErrorf(o.Key, "must be between %d and %d", o.Min, o.Max)
+		}
+		return value, o.PriceDelta * (value - o.Min), nil
+	case CustomizationBoolean:
+		var value bool
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, 0, customizationErrorf(o.Key, "must be true or false")
+		}
+		if value {
+			return value, o.PriceDelta, nil
+		}
+		return value, 0, nil
+	case CustomizationSingleChoice:
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, 0, customizationErrorf(o.Key, "must be one choice")
+		}
+		choice, ok := o.choice(value)
+		if !ok {
+			return nil, 0, customizationErrorf(o.Key, "unknown choice %q", value)
+		}
+		return value, choice.PriceDelta, nil
+	case CustomizationMultiChoice:
+		var values []string
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return nil, 0, customizationErrorf(o.Key, "must be a list of choices")
+		}
+		if o.MaxChoices > 0 && len(values) > o.MaxChoices {
+			return nil, 0, customizationErrorf(o.Key, "at most %d choices allowed", o.MaxChoices)
+		}
+		picked := map[string]bool{}
+		surcharge := 0
+		for _, value := range values {
+			choice, ok := o.choice(value)
+			if !ok {
+				return nil, 0, customizationErrorf(o.Key, "unknown choice %q", value)
+			}
+			if picked[value] {
+				return nil, 0, customizationErrorf(o.Key, "choice %q picked twice", value)
+			}
+			picked[value] = true
+			surcharge += choice.PriceDelta
+		}
+		if values == nil {
+			values = []string{}
+		}
+		return values, surcharge, nil
+	}
+	return nil, 0, customizationErrorf(o.Key, "unknown type %q", o.Type)
+}
+
+func (o CustomizationOption) choice(key string) (CustomizationChoice, bool) {
+	for _, choice := range o.Choices {
+		if choice.Key == key {
+			return choice, true
+		}
+	}
+	return CustomizationChoice{}, false
+}
+
+// PriceCustomizations validates submitted customizations against the dish's
+// options, fills in defaults and returns the customizations to store on the
+// order with the surcharge per unit.
+func (d Dish) PriceCustomizations(submitted map[string]json.RawMessage) (map[string]any, int, error) {
+	known := map[string]bool{}
+	for _, option := range d.Customizations {
+		known[option.Key] = true
+	}
+	for key := range submitted {
+		if !known[key] {
+			return nil, 0, customizationErrorf(key, "not offered for this dish")
+		}
+	}
+
+	customizations := map[string]any{}
+	surcharge := 0
+	for _, option := range d.Customizations {
+		raw, ok := submitted[option.Key]
+		if !ok || string(raw) == "null" {
+			if option.Default == nil {
+				if option.Required {
+					return nil, 0, customizationErrorf(option.Key, "is required")
+				}
+				continue
+			}
+			defaultRaw, err := json.Marshal(option.Default)
+			if err != nil {
+				return nil, 0, customizationErrorf(option.Key, "invalid default")
+			}
+			raw = defaultRaw
+		}
+		value, delta, err := option.Apply(raw)
+		if err != nil {
+			return nil, 0, err
+		}
+		customizations[option.Key] = value
+		surcharge += delta
+	}
+	return customizations, surcharge, nil
+}
@@ -0,0 +1,246 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateCustomizationOptions(t *testing.T) {
+	spice := CustomizationOption{Key: "spice", Type: CustomizationRange, Min: 1, Max: 5}
+	cheese := CustomizationOption{Key: "extra_cheese", Type: CustomizationBoolean, PriceDelta: 20}
+	bread := CustomizationOption{
+		Key:  "bread",
+		Type: CustomizationSingleChoice,
+		Choices: []CustomizationChoice{
+			{Key: "naan"},
+			{Key: "garlic_naan", PriceDelta: 15},
+		},
+	}
+
+	withDefault := func(option CustomizationOption, value any) CustomizationOption {
+		option.Default = value
+		return option
+	}
+
+	tests := []struct {
+		name        string
+		options     []CustomizationOption
+		wantErr     bool
+		wantDefault any
+	}{
+		{name: "no options", options: nil},
+		{name: "one of each type", options: []CustomizationOption{spice, cheese, bread}},
+		{name: "invalid key", options: []CustomizationOption{{Key: "1spice", Type: CustomizationBoolean}}, wantErr: true},
+		{name: "duplicate key", options: []CustomizationOption{cheese, cheese}, wantErr: true},
+		{name: "negative price", options: []CustomizationOption{{Key: "cheese", Type: CustomizationBoolean, PriceDelta: -1}}, wantErr: true},
+		{name: "unknown type", options: []CustomizationOption{{Key: "cheese", Type: "slider"}}, wantErr: true},
+		{name: "range min above max", options: []CustomizationOption{{Key: "spice", Type: CustomizationRange, Min: 5, Max: 1}}, wantErr: true},
+		{
+			name:    "range with choices",
+			options: []CustomizationOption{{Key: "spice", Type: CustomizationRange, Max: 5, Choices: bread.Choices}},
+			wantErr: true,
+		},
+		{
+			name:    "boolean with choices",
+			options: []CustomizationOption{{Key: "cheese", Type: CustomizationBoolean, Choices: bread.Choices}},
+			wantErr: true,
+		},
+		{name: "choice without choices", options: []CustomizationOption{{Key: "bread", Type: CustomizationSingleChoice}}, wantErr: true},
+		{
+			name: "duplicate choice",
+			options: []CustomizationOption{{
+				Key:     "bread",
+				Type:    CustomizationSingleChoice,
+				Choices: []CustomizationChoice{{Key: "naan"}, {Key: "naan"}},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "negative choice price",
+			options: []CustomizationOption{{
+				Key:     "bread",
+				Type:    CustomizationSingleChoice,
+				Choices: []CustomizationChoice{{Key: "naan", PriceDelta: -5}},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "maxChoices above the number of choices",
+			options: []CustomizationOption{{
+				Key:        "toppings",
+				Type:       CustomizationMultiChoice,
+				Choices:    bread.Choices,
+				MaxChoices: 3,
+			}},
+			wantErr: true,
+		},
+		{name: "range default is canonicalised", options: []CustomizationOption{withDefault(spice, float64(3))}, wantDefault: 3},
+		{name: "range default out of bounds", options: []CustomizationOption{withDefault(spice, 9)}, wantErr: true},
+		{name: "boolean default", options: []CustomizationOption{withDefault(cheese, true)}, wantDefault: true},
+		{name: "boolean default of the wrong type", options: []CustomizationOption{withDefault(cheese, "yes")}, wantErr: true},
+		{name: "choice default", options: []CustomizationOption{withDefault(bread, "naan")}, wantDefault: "naan"},
+		{name: "unknown choice default", options: []CustomizationOption{withDefault(bread, "roti")}, wantErr: true},
+		{
+			name: "multi choice default is canonicalised",
+			options: []CustomizationOption{{
+				Key:     "breads",
+				Type:    CustomizationMultiChoice,
+				Choices: bread.Choices,
+				Default: []any{"naan"},
+			}},
+			wantDefault: []string{"naan"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCustomizationOptions(tt.options)
+			if tt.wantErr {
+				var customizationErr *CustomizationError
+				if !errors.As(err, &customizationErr) {
+					t.Fatalf("ValidateCustomizationOptions() error = %v, want a CustomizationError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateCustomizationOptions() error = %v", err)
+			}
+			if tt.wantDefault != nil && !reflect.DeepEqual(tt.options[0].Default, tt.wantDefault) {
+				t.Errorf("Default = %#v, want %#v", tt.options[0].Default, tt.wantDefault)
+			}
+		})
+	}
+}
+
+func TestCustomizationOptionApply(t *testing.T) {
+	spice := CustomizationOption{Key: "spice", Type: CustomizationRange, Min: 1, Max: 5, PriceDelta: 5}
+	cheese := CustomizationOption{Key: "extra_cheese", Type: CustomizationBoolean, PriceDelta: 20}
+	bread := CustomizationOption{
+		Key:     "bread",
+		Type:    CustomizationSingleChoice,
+		Choices: []CustomizationChoice{{Key: "naan"}, {Key: "garlic_naan", PriceDelta: 15}},
+	}
+	toppings := CustomizationOption{
+		Key:        "toppings",
+		Type:       CustomizationMultiChoice,
+		MaxChoices: 2,
+		Choices: []CustomizationChoice{
+			{Key: "onion", PriceDelta: 10},
+			{Key: "olive", PriceDelta: 25},
+			{Key: "corn", PriceDelta: 15},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		option        CustomizationOption
+		raw           string
+		want          any
+		wantSurcharge int
+		wantErr       bool
+	}{
+		{name: "range at min is free", option: spice, raw: `1`, want: 1},
+		{name: "range charges per step above min", option: spice, raw: `4`, want: 4, wantSurcharge: 15},
+		{name: "range below min", option: spice, raw: `0`, wantErr: true},
+		{name: "range above max", option: spice, raw: `6`, wantErr: true},
+		{name: "range fraction", option: spice, raw: `2.5`, wantErr: true},
+		{name: "boolean on", option: cheese, raw: `true`, want: true, wantSurcharge: 20},
+		{name: "boolean off", option: cheese, raw: `false`, want: false},
+		{name: "boolean as string", option: cheese, raw: `"true"`, wantErr: true},
+		{name: "single choice", option: bread, raw: `"garlic_naan"`, want: "garlic_naan", wantSurcharge: 15},
+		{name: "unknown single choice", option: bread, raw: `"roti"`, wantErr: true},
+		{name: "single choice as list", option: bread, raw: `["naan"]`, wantErr: true},
+		{name: "multi choice sums surcharges", option: toppings, raw: `["onion","olive"]`, want: []string{"onion", "olive"}, wantSurcharge: 35},
+		{name: "multi choice empty", option: toppings, raw: `[]`, want: []string{}},
+		{name: "multi choice null", option: toppings, raw: `null`, want: []string{}},
+		{name: "too many choices", option: toppings, raw: `["onion","olive","corn"]`, wantErr: true},
+		{name: "choice picked twice", option: toppings, raw: `["onion","onion"]`, wantErr: true},
+		{name: "unknown multi choice", option: toppings, raw: `["pineapple"]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, surcharge, err := tt.option.Apply(json.RawMessage(tt.raw))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply(%s) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) || surcharge != tt.wantSurcharge {
+				t.Errorf("Apply(%s) = %#v, %d, want %#v, %d", tt.raw, got, surcharge, tt.want, tt.wantSurcharge)
+			}
+		})
+	}
+}
+
+func TestPriceCustomizations(t *testing.T) {
+	dish := Dish{Customizations: []CustomizationOption{
+		{Key: "spice", Type: CustomizationRange, Min: 1, Max: 5, Required: true},
+		{Key: "extra_cheese", Type: CustomizationBoolean, PriceDelta: 20},
+		{
+			Key:     "bread",
+			Type:    CustomizationSingleChoice,
+			Default: "naan",
+			Choices: []CustomizationChoice{{Key: "naan"}, {Key: "garlic_naan", PriceDelta: 15}},
+		},
+	}}
+
+	tests := []struct {
+		name          string
+		submitted     map[string]json.RawMessage
+		want          map[string]any
+		wantSurcharge int
+		wantErr       bool
+	}{
+		{
+			name:      "defaults fill in missing options",
+			submitted: map[string]json.RawMessage{"spice": json.RawMessage(`3`)},
+			want:      map[string]any{"spice": 3, "bread": "naan"},
+		},
+		{
+			name: "surcharges add up",
+			submitted: map[string]json.RawMessage{
+				"spice":        json.RawMessage(`2`),
+				"extra_cheese": json.RawMessage(`true`),
+				"bread":        json.RawMessage(`"garlic_naan"`),
+			},
+			want:          map[string]any{"spice": 2, "extra_cheese": true, "bread": "garlic_naan"},
+			wantSurcharge: 35,
+		},
+		{
+			name:      "null falls back to the default",
+			submitted: map[string]json.RawMessage{"spice": json.RawMessage(`1`), "bread": json.RawMessage(`null`)},
+			want:      map[string]any{"spice": 1, "bread": "naan"},
+		},
+		{name: "required option missing", submitted: map[string]json.RawMessage{}, wantErr: true},
+		{
+			name:      "option not offered",
+			submitted: map[string]json.RawMessage{"spice": json.RawMessage(`1`), "sauce": json.RawMessage(`"mint"`)},
+			wantErr:   true,
+		},
+		{
+			name:      "invalid value",
+			submitted: map[string]json.RawMessage{"spice": json.RawMessage(`7`)},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, surcharge, err := dish.PriceCustomizations(tt.submitted)
+			if tt.wantErr {
+				var customizationErr *CustomizationError
+				if !errors.As(err, &customizationErr) {
+					t.Fatalf("PriceCustomizations() error = %v, want a CustomizationError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PriceCustomizations() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) || surcharge != tt.wantSurcharge {
+				t.Errorf("PriceCustomizations() = %#v, %d, want %#v, %d", got, surcharge, tt.want, tt.wantSurcharge)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Allergens a dish can declare, following the major allergen groups food
+// labelling rules require.
+var Allergens = []string{
+	"celery",
+	"crustacean",
+	"egg",
+	"fish",
+	"gluten",
+	"lupin",
+	"milk",
+	"mollusc",
+	"mustard",
+	"peanut",
+	"sesame",
+	"soy",
+	"sulphite",
+	"tree_nut",
+}
+
+// Dietary labels a dish can carry.
+const (
+	DietVegetarian = "vegetarian"
+	DietVegan      = "vegan"
+	DietJain       = "jain"
+	DietGlutenFree = "gluten_free"
+	DietDairyFree  = "dairy_free"
+	DietNutFree    = "nut_free"
+	DietHalal      = "halal"
+)
+
+var DietaryLabels = []string{
+	DietVegetarian,
+	DietVegan,
+	DietJain,
+	DietGlutenFree,
+	DietDairyFree,
+	DietNutFree,
+	DietHalal,
+}
+
+// dietRules lists, per label, whether the dish must be vegetarian and
+// which allergens it cannot contain.
+var dietRules = map[string]struct {
+	veg      bool
+	excludes []string
+}{
+	DietVegetarian: {veg: true},
+	DietVegan:      {veg: true, excludes: []string{"egg", "milk"}},
+	DietJain:       {veg: true, excludes: []string{"egg"}},
+	DietGlutenFree: {excludes: []string{"gluten"}},
+	DietDairyFree:  {excludes: []string{"milk"}},
+	DietNutFree:    {excludes: []string{"peanut", "tree_nut"}},
+}
+
+// nonVegAllergens cannot be in a vegetarian dish.
+var nonVegAllergens = []string{"crustacean", "fish", "mollusc"}
+
+// Nutrition is per serving, of the dish or of one variant. Calories are
+// kcal, sodium is milligrams and everything else grams.
+type Nutrition struct {
+	Calories       int     `bson:"calories" json:"calories"`
+	ProteinG       float64 `bson:"proteinG" json:"proteinG"`
+	CarbohydratesG float64 `bson:"carbohydratesG" json:"carbohydratesG"`
+	FatG           float64 `bson:"fatG" json:"fatG"`
+	FiberG         float64 `bson:"fiberG,omitempty" json:"fiberG,omitempty"`
+	SugarG         float64 `bson:"sugarG,omitempty" json:"sugarG,omitempty"`
+	SodiumMg       float64 `bson:"sodiumMg,omitempty" json:"sodiumMg,omitempty"`
+}
+
+// DietaryError reports allergens, labels or nutrition that cannot be saved
+// or filtered on.
+type DietaryError struct {
+	Message string
+}
+
+func (e *DietaryError) Error() string {
+	return e.Message
+}
+
+func (n Nutrition) Validate() error {
+	if n.Calories < 0 || n.ProteinG < 0 || n.CarbohydratesG < 0 || n.FatG < 0 ||
+		n.FiberG < 0 || n.SugarG < 0 || n.SodiumMg < 0 {
+		return &DietaryError{Message: "nutrition values cannot be negative"}
+	}
+	return nil
+}
+
+// NormalizeAllergens lowercases, deduplicates and sorts allergens, rejecting
+// any outside the taxonomy. nil stays nil, meaning not declared, while an
+// empty list declares the dish free of all of them.
+func NormalizeAllergens(allergens []string) ([]string, error) {
+	return normalizeTerms(allergens, Allergens, "allergen")
+}
+
+// NormalizeDietaryLabels is NormalizeAllergens for dietary labels.
+func NormalizeDietaryLabels(labels []string) ([]string, error) {
+	return normalizeTerms(labels, DietaryLabels, "dietary label")
+}
+
+func normalizeTerms(values []string, known []string, kind string) ([]string, error) {
+	if values == nil {
+		return nil, nil
+	}
+	normalized := []string{}
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if !slices.Contains(known, value) {
+			return nil, &DietaryError{Message: fmt.Sprintf("unknown %s %q, expected one of %s", kind, value, strings.Join(known, ", "))}
+		}
+		if !slices.Contains(normalized, value) {
+			normalized = append(normalized, value)
+		}
+	}
+	slices.Sort(normalized)
+	return normalized, nil
+}
+
+// CheckDietary rejects labels that contradict the dish, such as a vegan dish
+// that is not vegetarian or a gluten free dish that contains gluten.
+func CheckDietary(isVeg bool, allergens []string, labels []string) error {
+	if isVeg {
+		for _, allergen := range allergens {
+			if slices.Contains(nonVegAllergens, allergen) {
+				return &DietaryError{Message: fmt.Sprintf("a vegetarian dish cannot contain %s", allergen)}
+			}
+		}
+	}
+	for _, label := range labels {
+		rule := dietRules[label]
+		if rule.veg && !isVeg {
+			return &DietaryError{Message: fmt.Sprintf("only vegetarian dishes can be labelled %s", label)}
+		}
+		for _, allergen := range rule.excludes {
+			if slices.Contains(allergens, allergen) {
+				return &DietaryError{Message: fmt.Sprintf("a %s dish cannot contain %s", label, allergen)}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package model
+
+import "time"
+
+// Image renditions stored for every uploaded dish image. DisplayImage points
+// at the RenditionMedium of the dish's first image.
+const (
+	RenditionOriginal  = "original"
+	RenditionThumbnail = "thumbnail"
+	RenditionSmall     = "small"
+	RenditionMedium    = "medium"
+	RenditionLarge     = "large"
+)
+
+// DishImage is one image in a dish's gallery. ID is the hash of the
+// uploaded file, so the same image cannot be added to a dish twice.
+type DishImage struct {
+	ID          string           `bson:"_id" json:"id"`
+	ContentType string           `bson:"contentType" json:"contentType"`
+	Width       int              `bson:"width" json:"width"`
+	Height      int              `bson:"height" json:"height"`
+	Renditions  []ImageRendition `bson:"renditions" json:"renditions"`
+	UploadedAt  time.Time        `bson:"uploadedAt" json:"uploadedAt"`
+}
+
+// ImageRendition is one stored file of a DishImage.
+type ImageRendition struct {
+	Name        string `bson:"name" json:"name"`
+	Key         string `bson:"key" json:"-"`
+	URL         string `bson:"url" json:"url"`
+	ContentType string `bson:"contentType" json:"contentType"`
+	Width       int    `bson:"width" json:"width"`
+	Height      int    `bson:"height" json:"height"`
+	Size        int    `bson:"size" json:"size"`
+}
+
+// Rendition returns the named rendition, falling back to the original.
+func (i DishImage) Rendition(name string) ImageRendition {
+	var original ImageRendition
+	for _, rendition := range i.Renditions {
+		if rendition.Name == name {
+			return rendition
+		}
+		if rendition.Name == RenditionOriginal {
+			original = rendition
+		}
+	}
+	return original
+}
+
+// Keys are the storage keys of all the image's renditions.
+func (i DishImage) Keys() []string {
+	keys := make([]string, 0, len(i.Renditions))
+	for _, rendition := range i.Renditions {
+		keys = append(keys, rendition.Key)
+	}
+	return keys
+}
+
+// Image returns the gallery image with the given ID.
+func (d Dish) Image(id string) (DishImage, bool) {
+	for _, image := range d.Images {
+		if image.ID == id {
+			return image, true
+		}
+	}
+	return DishImage{}, false
+}
@@ -1,15 +1,34 @@
 package model
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	AvailabilityAvailable   = "available"
+	AvailabilityUnavailable = "unavailable"
+	// AvailabilitySoldOut is set when tracked stock runs out and cleared
+	// when stock is given back or reset.
+	AvailabilitySoldOut = "sold_out"
+)
 
 type Dish struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	ID        bson.ObjectID `bson:"_id,omitempty"`
 	RestaurantId string `bson:"restaurant"`
+	// SKU is the restaurant's own code for the dish, unique within the
+	// restaurant. Menu imports update the dish with the same SKU.
+	SKU string `bson:"sku,omitempty"`
 	Name string `bson:"name"`
 	Description string `bson:"description"`
 	Category string `bson:"category"`
 	Price int `bson:"price"`
 	DisplayImage string `bson:"displayImage"`
+	// Images is the dish's gallery of uploaded images, the first being the
+	// one shown as DisplayImage.
+	Images []DishImage `bson:"images,omitempty"`
 	Type string `bson:"type"`
 	IsVeg bool `bson:"isVeg"`
 	Popularity int `bson:"popularity"`
@@ -17,4 +36,41 @@ type Dish struct {
 	PreparationTime int `bson:"preparationTime"`
 	AvailabilityStatus string `bson:"availabilityStatus"`
 	Tags []string `bson:"tags"`
-}
\ No newline at end of file
+	Customizations []CustomizationOption `bson:"customizations"`
+	// Variants are the sizes or portions the dish is sold in. Price is the
+	// cheapest variant's when there are any.
+	Variants []Variant `bson:"variants"`
+	// Schedule limits when the dish can be ordered; nil means any time.
+	Schedule *Schedule `bson:"schedule,omitempty"`
+	// Allergens are from the Allergens taxonomy. nil means the restaurant
+	// has not declared them, an empty list that there are none.
+	Allergens     []string `bson:"allergens"`
+	DietaryLabels []string `bson:"dietaryLabels"`
+	// Nutrition is per serving; variants may carry their own.
+	Nutrition *Nutrition `bson:"nutrition,omitempty"`
+	// Stock is the dish's daily portion count; nil means it is not tracked.
+	// Dishes with variants track stock per variant instead.
+	Stock *Stock `bson:"stock,omitempty"`
+	// SearchGrams are the trigrams of name, category and tags, used for typo
+	// tolerant search. See search.DishGrams.
+	SearchGrams []string `bson:"searchGrams" json:"-"`
+}
+
+// IsAvailable reports whether the dish can currently be ordered. Dishes
+// without an explicit status are treated as available.
+func (d Dish) IsAvailable() bool {
+	return d.AvailabilityStatus == "" || strings.EqualFold(d.AvailabilityStatus, AvailabilityAvailable)
+}
+
+// AvailableAt reports whether the dish can be ordered at t: it must be
+// switched on and, if it has a schedule, inside one of its windows. When it
+// is only outside its schedule, next is when it can be ordered again.
+func (d Dish) AvailableAt(t time.Time) (available bool, next *time.Time) {
+	if !d.IsAvailable() {
+		return false, nil
+	}
+	if d.Schedule == nil || d.Schedule.OpenAt(t) {
+		return true, nil
+	}
+	return false, d.Schedule.NextOpening(t)
+}
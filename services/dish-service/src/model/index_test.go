@@ -0,0 +1,66 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Dish, variant and combo ids must be stored as BSON ObjectIDs, or lookups
+// by _id never match, and travel over JSON as hex strings.
+func TestObjectIDRoundTrip(t *testing.T) {
+	dishID := bson.NewObjectID()
+	variantID := bson.NewObjectID()
+	comboID := bson.NewObjectID()
+
+	tests := []struct {
+		name  string
+		value any
+		field string
+		want  bson.ObjectID
+	}{
+		{"dish", Dish{ID: dishID}, "_id", dishID},
+		{"variant", Variant{ID: variantID}, "_id", variantID},
+		{"combo", Combo{ID: comboID}, "_id", comboID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := bson.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("bson.Marshal() error = %v", err)
+			}
+			value, err := bson.Raw(data).LookupErr(tt.field)
+			if err != nil {
+				t.Fatalf("%s missing: %v", tt.field, err)
+			}
+			if value.Type != bson.TypeObjectID {
+				t.Fatalf("%s is stored as %v, want an ObjectID", tt.field, value.Type)
+			}
+			if got := value.ObjectID(); got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.field, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		data, err := json.Marshal(Variant{ID: variantID, Name: "Full", Price: 250})
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		var fields map[string]any
+		if err := json.Unmarshal(data, &fields); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		if fields["id"] != variantID.Hex() {
+			t.Errorf("id = %v, want %q", fields["id"], variantID.Hex())
+		}
+		var decoded Variant
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		if decoded.ID != variantID {
+			t.Errorf("decoded id = %v, want %v", decoded.ID, variantID)
+		}
+	})
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// GeoPoint is a GeoJSON point. Coordinates are [longitude, latitude] as
+// required by Mongo's 2dsphere index.
+type GeoPoint struct {
+	Type        string    `bson:"type"`
+	Coordinates []float64 `bson:"coordinates"`
+}
+
+func NewGeoPoint(latitude, longitude float64) GeoPoint {
+	return GeoPoint{Type: "Point", Coordinates: []float64{longitude, latitude}}
+}
+
+// RestaurantLocation is dish-service's copy of where a restaurant is and how
+// far it delivers, kept in sync from restaurant events. The id is the
+// restaurant id, matching Dish.RestaurantId.
+type RestaurantLocation struct {
+	RestaurantId     string    `bson:"_id"`
+	Location         GeoPoint  `bson:"location"`
+	DeliveryRadiusKm float64   `bson:"deliveryRadiusKm"`
+	UpdatedAt        time.Time `bson:"updatedAt"`
+}
@@ -0,0 +1,212 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	// Schedules name IANA time zones; embed the database so they resolve on
+	// hosts without one.
+	_ "time/tzdata"
+)
+
+// scheduleLookahead bounds how far ahead NextOpening searches.
+const scheduleLookahead = 14
+
+// Schedule limits when a dish can be ordered. Windows repeat weekly in the
+// restaurant's Timezone; an exception replaces the windows of one date,
+// either closing it or giving it its own windows.
+type Schedule struct {
+	Timezone   string              `bson:"timezone" json:"timezone"`
+	Windows    []ScheduleWindow    `bson:"windows" json:"windows"`
+	Exceptions []ScheduleException `bson:"exceptions,omitempty" json:"exceptions,omitempty"`
+}
+
+// TimeWindow is a local time range in HH:MM. A window whose End is not after
+// its Start runs past midnight into the next day.
+type TimeWindow struct {
+	Start string `bson:"start" json:"start"`
+	End   string `bson:"end" json:"end"`
+}
+
+// ScheduleWindow applies TimeWindow on Days, 0 for Sunday through 6 for
+// Saturday.
+type ScheduleWindow struct {
+	Days       []int `bson:"days" json:"days"`
+	TimeWindow `bson:",inline"`
+}
+
+// ScheduleException overrides the weekly windows on Date (YYYY-MM-DD), such
+// as a holiday.
+type ScheduleException struct {
+	Date    string       `bson:"date" json:"date"`
+	Closed  bool         `bson:"closed" json:"closed"`
+	Windows []TimeWindow `bson:"windows,omitempty" json:"windows,omitempty"`
+}
+
+// ScheduleError reports a schedule that cannot be saved.
+type ScheduleError struct {
+	Message string
+}
+
+func (e *ScheduleError) Error() string {
+	return e.Message
+}
+
+func scheduleErrorf(format string, args ...any) error {
+	return &ScheduleError{Message: fmt.Sprintf(format, args...)}
+}
+
+// Validate checks the schedule's time zone, days, times and dates.
+func (s Schedule) Validate() error {
+	if s.Timezone == "" {
+		return scheduleErrorf("timezone is required")
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return scheduleErrorf("unknown timezone %q", s.Timezone)
+	}
+	for _, window := range s.Windows {
+		if len(window.Days) == 0 {
+			return scheduleErrorf("window %s-%s has no days", window.Start, window.End)
+		}
+		for _, day := range window.Days {
+			if day < 0 || day > 6 {
+				return scheduleErrorf("day %d must be between 0 (Sunday) and 6 (Saturday)", day)
+			}
+		}
+		if err := window.TimeWindow.validate(); err != nil {
+			return err
+		}
+	}
+	dates := map[string]bool{}
+	for _, exception := range s.Exceptions {
+		if _, err := time.Parse(time.DateOnly, exception.Date); err != nil {
+			return scheduleErrorf("exception date %q must be YYYY-MM-DD", exception.Date)
+		}
+		if dates[exception.Date] {
+			return scheduleErrorf("duplicate exception for %s", exception.Date)
+		}
+		dates[exception.Date] = true
+		if exception.Closed && len(exception.Windows) > 0 {
+			return scheduleErrorf("exception for %s cannot be closed and have windows", exception.Date)
+		}
+		for _, window := range exception.Windows {
+			if err := window.validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w TimeWindow) validate() error {
+	if _, ok := parseClock(w.Start); !ok {
+		return scheduleErrorf("start %q must be HH:MM", w.Start)
+	}
+	if _, ok := parseClock(w.End); !ok {
+		return scheduleErrorf("end %q must be HH:MM", w.End)
+	}
+	return nil
+}
+
+// parseClock returns the minutes since midnight of an HH:MM time.
+func parseClock(clock string) (int, bool) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// period is one concrete opening of a window.
+type period struct {
+	start time.Time
+	end   time.Time
+}
+
+// periodsStarting returns the openings that start on the local date of day,
+// using the date's exception if it has one.
+func (s Schedule) periodsStarting(day time.Time) []period {
+	windows := []TimeWindow{}
+	date := day.Format(time.DateOnly)
+	excepted := false
+	for _, exception := range s.Exceptions {
+		if exception.Date == date {
+			excepted = true
+			windows = exception.Windows
+		}
+	}
+	if !excepted {
+		for _, window := range s.Windows {
+			for _, weekday := range window.Days {
+				if time.Weekday(weekday) == day.Weekday() {
+					windows = append(windows, window.TimeWindow)
+					break
+				}
+			}
+		}
+	}
+
+	var periods []period
+	for _, window := range windows {
+		startMinute, _ := parseClock(window.Start)
+		endMinute, _ := parseClock(window.End)
+		start := localClock(day, startMinute)
+		endDay := day
+		if endMinute <= startMinute {
+			endDay = day.AddDate(0, 0, 1)
+		}
+		end := localClock(endDay, endMinute)
+		periods = append(periods, period{start: start, end: end})
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].start.Before(periods[j].start) })
+	return periods
+}
+
+// localClock returns minute past midnight on day's date. A clock time
+// skipped when daylight saving starts resolves to the same time after the
+// gap, so a 02:30 opening on that night opens at 03:30.
+func localClock(day time.Time, minute int) time.Time {
+	t := time.Date(day.Year(), day.Month(), day.Day(), minute/60, minute%60, 0, 0, day.Location())
+	if skipped := (minute - t.Hour()*60 - t.Minute() + 24*60) % (24 * 60); skipped != 0 {
+		t = t.Add(time.Duration(skipped) * time.Minute)
+	}
+	return t
+}
+
+func (s Schedule) location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// OpenAt reports whether t falls in one of the schedule's openings.
+func (s Schedule) OpenAt(t time.Time) bool {
+	local := t.In(s.location())
+	// Yesterday's windows can run past midnight into today.
+	for _, day := range []time.Time{local.AddDate(0, 0, -1), local} {
+		for _, p := range s.periodsStarting(day) {
+			if !t.Before(p.start) && t.Before(p.end) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NextOpening returns when the schedule next opens after t, or nil if it
+// does not open within the next two weeks.
+func (s Schedule) NextOpening(t time.Time) *time.Time {
+	local := t.In(s.location())
+	for offset := 0; offset <= scheduleLookahead; offset++ {
+		for _, p := range s.periodsStarting(local.AddDate(0, 0, offset)) {
+			if p.start.After(t) {
+				start := p.start
+				return &start
+			}
+		}
+	}
+	return nil
+}
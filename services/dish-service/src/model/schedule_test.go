@@ -0,0 +1,204 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%q) error = %v", name, err)
+	}
+	return loc
+}
+
+func TestScheduleValidate(t *testing.T) {
+	lunch := ScheduleWindow{Days: []int{1, 2, 3, 4, 5}, TimeWindow: TimeWindow{Start: "11:00", End: "15:00"}}
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		wantErr  bool
+	}{
+		{name: "weekday lunch", schedule: Schedule{Timezone: "Asia/Kolkata", Windows: []ScheduleWindow{lunch}}},
+		{
+			name: "late night window and holiday",
+			schedule: Schedule{
+				Timezone:   "America/New_York",
+				Windows:    []ScheduleWindow{{Days: []int{5, 6}, TimeWindow: TimeWindow{Start: "22:00", End: "02:00"}}},
+				Exceptions: []ScheduleException{{Date: "2024-12-25", Closed: true}, {Date: "2024-12-31", Windows: []TimeWindow{{Start: "18:00", End: "03:00"}}}},
+			},
+		},
+		{name: "missing timezone", schedule: Schedule{Windows: []ScheduleWindow{lunch}}, wantErr: true},
+		{name: "unknown timezone", schedule: Schedule{Timezone: "Mars/Olympus_Mons"}, wantErr: true},
+		{name: "window without days", schedule: Schedule{Timezone: "UTC", Windows: []ScheduleWindow{{TimeWindow: lunch.TimeWindow}}}, wantErr: true},
+		{name: "day out of range", schedule: Schedule{Timezone: "UTC", Windows: []ScheduleWindow{{Days: []int{7}, TimeWindow: lunch.TimeWindow}}}, wantErr: true},
+		{name: "invalid start", schedule: Schedule{Timezone: "UTC", Windows: []ScheduleWindow{{Days: []int{1}, TimeWindow: TimeWindow{Start: "11am", End: "15:00"}}}}, wantErr: true},
+		{name: "invalid end", schedule: Schedule{Timezone: "UTC", Windows: []ScheduleWindow{{Days: []int{1}, TimeWindow: TimeWindow{Start: "11:00", End: "24:30"}}}}, wantErr: true},
+		{name: "invalid exception date", schedule: Schedule{Timezone: "UTC", Exceptions: []ScheduleException{{Date: "25/12/2024", Closed: true}}}, wantErr: true},
+		{
+			name:     "duplicate exception",
+			schedule: Schedule{Timezone: "UTC", Exceptions: []ScheduleException{{Date: "2024-12-25", Closed: true}, {Date: "2024-12-25", Closed: true}}},
+			wantErr:  true,
+		},
+		{
+			name:     "closed exception with windows",
+			schedule: Schedule{Timezone: "UTC", Exceptions: []ScheduleException{{Date: "2024-12-25", Closed: true, Windows: []TimeWindow{lunch.TimeWindow}}}},
+			wantErr:  true,
+		},
+		{
+			name:     "invalid exception window",
+			schedule: Schedule{Timezone: "UTC", Exceptions: []ScheduleException{{Date: "2024-12-25", Windows: []TimeWindow{{Start: "noon", End: "15:00"}}}}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schedule.Validate()
+			if tt.wantErr {
+				var scheduleErr *ScheduleError
+				if !errors.As(err, &scheduleErr) {
+					t.Fatalf("Validate() error = %v, want a ScheduleError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestScheduleOpenAt(t *testing.T) {
+	kolkata := mustLoadLocation(t, "Asia/Kolkata")
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	// 2024-05-03 is a Friday.
+	lunch := Schedule{
+		Timezone: "Asia/Kolkata",
+		Windows:  []ScheduleWindow{{Days: []int{1, 2, 3, 4, 5}, TimeWindow: TimeWindow{Start: "11:00", End: "15:00"}}},
+		Exceptions: []ScheduleException{
+			{Date: "2024-05-01", Closed: true},
+			{Date: "2024-05-04", Windows: []TimeWindow{{Start: "12:00", End: "13:00"}}},
+		},
+	}
+	lateNight := Schedule{
+		Timezone: "Asia/Kolkata",
+		Windows:  []ScheduleWindow{{Days: []int{5}, TimeWindow: TimeWindow{Start: "22:00", End: "02:00"}}},
+	}
+	// 2024-03-10 and 2024-11-03 are the Sundays New York springs forward
+	// and falls back.
+	sundayNight := Schedule{
+		Timezone: "America/New_York",
+		Windows:  []ScheduleWindow{{Days: []int{0}, TimeWindow: TimeWindow{Start: "00:00", End: "03:00"}}},
+	}
+	// Runs past midnight into the spring forward gap, so it closes at 03:30.
+	saturdayNight := Schedule{
+		Timezone: "America/New_York",
+		Windows:  []ScheduleWindow{{Days: []int{6}, TimeWindow: TimeWindow{Start: "23:00", End: "02:30"}}},
+	}
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		at       time.Time
+		want     bool
+	}{
+		{"inside the window", lunch, time.Date(2024, 5, 3, 12, 0, 0, 0, kolkata), true},
+		{"start is inclusive", lunch, time.Date(2024, 5, 3, 11, 0, 0, 0, kolkata), true},
+		{"end is exclusive", lunch, time.Date(2024, 5, 3, 15, 0, 0, 0, kolkata), false},
+		{"times in other zones are converted", lunch, time.Date(2024, 5, 3, 6, 0, 0, 0, time.UTC), true},
+		{"outside the window's days", lunch, time.Date(2024, 5, 5, 12, 0, 0, 0, kolkata), false},
+		{"closed exception", lunch, time.Date(2024, 5, 1, 12, 0, 0, 0, kolkata), false},
+		{"exception window on a day off", lunch, time.Date(2024, 5, 4, 12, 30, 0, 0, kolkata), true},
+		{"outside the exception window", lunch, time.Date(2024, 5, 4, 14, 0, 0, 0, kolkata), false},
+
+		{"before a past midnight window", lateNight, time.Date(2024, 5, 3, 21, 59, 0, 0, kolkata), false},
+		{"past midnight window before midnight", lateNight, time.Date(2024, 5, 3, 23, 0, 0, 0, kolkata), true},
+		{"past midnight window after midnight", lateNight, time.Date(2024, 5, 4, 1, 30, 0, 0, kolkata), true},
+		{"past midnight window has ended", lateNight, time.Date(2024, 5, 4, 2, 0, 0, 0, kolkata), false},
+		{"past midnight window only runs from its own day", lateNight, time.Date(2024, 5, 3, 1, 0, 0, 0, kolkata), false},
+
+		{"spring forward before the gap", sundayNight, time.Date(2024, 3, 10, 6, 30, 0, 0, time.UTC), true},
+		{"spring forward last minute", sundayNight, time.Date(2024, 3, 10, 1, 59, 0, 0, newYork), true},
+		{"spring forward window is an hour shorter", sundayNight, time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC), false},
+		{"past midnight window ending in the gap", saturdayNight, time.Date(2024, 3, 10, 3, 15, 0, 0, newYork), true},
+		{"past midnight window ending in the gap has ended", saturdayNight, time.Date(2024, 3, 10, 3, 30, 0, 0, newYork), false},
+		{"fall back first 01:30", sundayNight, time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC), true},
+		{"fall back second 01:30", sundayNight, time.Date(2024, 11, 3, 6, 30, 0, 0, time.UTC), true},
+		{"fall back window is an hour longer", sundayNight, time.Date(2024, 11, 3, 7, 30, 0, 0, time.UTC), true},
+		{"fall back window has ended", sundayNight, time.Date(2024, 11, 3, 8, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.OpenAt(tt.at); got != tt.want {
+				t.Errorf("OpenAt(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleNextOpening(t *testing.T) {
+	kolkata := mustLoadLocation(t, "Asia/Kolkata")
+
+	lunch := Schedule{
+		Timezone:   "Asia/Kolkata",
+		Windows:    []ScheduleWindow{{Days: []int{1, 2, 3, 4, 5}, TimeWindow: TimeWindow{Start: "11:00", End: "15:00"}}},
+		Exceptions: []ScheduleException{{Date: "2024-05-06", Closed: true}},
+	}
+	twoWindows := Schedule{
+		Timezone: "Asia/Kolkata",
+		Windows: []ScheduleWindow{
+			{Days: []int{3}, TimeWindow: TimeWindow{Start: "19:00", End: "23:00"}},
+			{Days: []int{3}, TimeWindow: TimeWindow{Start: "11:00", End: "15:00"}},
+		},
+	}
+	// Opens at 02:30 on the day New York springs forward, a time that does
+	// not exist that night.
+	inTheGap := Schedule{
+		Timezone: "America/New_York",
+		Windows:  []ScheduleWindow{{Days: []int{0}, TimeWindow: TimeWindow{Start: "02:30", End: "05:00"}}},
+	}
+	at := func(year int, month time.Month, day, hour, minute int) *time.Time {
+		t := time.Date(year, month, day, hour, minute, 0, 0, kolkata)
+		return &t
+	}
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		after    time.Time
+		want     *time.Time
+	}{
+		{"later the same day", lunch, time.Date(2024, 5, 3, 9, 0, 0, 0, kolkata), at(2024, 5, 3, 11, 0)},
+		{"while open, the next opening", lunch, time.Date(2024, 5, 2, 12, 0, 0, 0, kolkata), at(2024, 5, 3, 11, 0)},
+		{"skips the weekend and a closed Monday", lunch, time.Date(2024, 5, 3, 16, 0, 0, 0, kolkata), at(2024, 5, 7, 11, 0)},
+		{"an opening exactly at t is not after it", lunch, time.Date(2024, 5, 3, 11, 0, 0, 0, kolkata), at(2024, 5, 7, 11, 0)},
+		{"windows are taken in order", twoWindows, time.Date(2024, 5, 1, 8, 0, 0, 0, kolkata), at(2024, 5, 1, 11, 0)},
+		{"second window of the day", twoWindows, time.Date(2024, 5, 1, 16, 0, 0, 0, kolkata), at(2024, 5, 1, 19, 0)},
+		{"next week", twoWindows, time.Date(2024, 5, 1, 20, 0, 0, 0, kolkata), at(2024, 5, 8, 11, 0)},
+		{"no windows", Schedule{Timezone: "Asia/Kolkata"}, time.Date(2024, 5, 1, 8, 0, 0, 0, kolkata), nil},
+		{"opening inside the spring forward gap", inTheGap, time.Date(2024, 3, 10, 5, 0, 0, 0, time.UTC), func() *time.Time {
+			// 02:30 EST, which the clocks show as 03:30 EDT.
+			t := time.Date(2024, 3, 10, 7, 30, 0, 0, time.UTC)
+			return &t
+		}()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.schedule.NextOpening(tt.after)
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("NextOpening(%v) = %v, want nil", tt.after, got)
+				}
+				return
+			}
+			if got == nil || !got.Equal(*tt.want) {
+				t.Errorf("NextOpening(%v) = %v, want %v", tt.after, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Stock is a daily portion count for a dish or one of its variants.
+// Remaining is set back to DailyLimit every day at ResetTime (HH:MM) in
+// Timezone. Reaching LowStockThreshold publishes a low stock event.
+type Stock struct {
+	DailyLimit        int       `bson:"dailyLimit" json:"dailyLimit"`
+	Remaining         int       `bson:"remaining" json:"remaining"`
+	LowStockThreshold int       `bson:"lowStockThreshold" json:"lowStockThreshold"`
+	ResetTime         string    `bson:"resetTime" json:"resetTime"`
+	Timezone          string    `bson:"timezone" json:"timezone"`
+	NextResetAt       time.Time `bson:"nextResetAt" json:"nextResetAt"`
+}
+
+// StockError reports stock settings that cannot be saved.
+type StockError struct {
+	Message string
+}
+
+func (e *StockError) Error() string {
+	return e.Message
+}
+
+// Validate checks the stock settings, defaulting ResetTime to midnight.
+func (s *Stock) Validate() error {
+	if s.DailyLimit < 0 || s.Remaining < 0 || s.LowStockThreshold < 0 {
+		return &StockError{Message: "stock counts cannot be negative"}
+	}
+	if s.ResetTime == "" {
+		s.ResetTime = "00:00"
+	}
+	if _, ok := parseClock(s.ResetTime); !ok {
+		return &StockError{Message: "resetTime must be HH:MM"}
+	}
+	if s.Timezone == "" {
+		return &StockError{Message: "timezone is required"}
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return &StockError{Message: "unknown timezone " + s.Timezone}
+	}
+	return nil
+}
+
+// NextReset returns the first reset time after t.
+func (s Stock) NextReset(t time.Time) time.Time {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	minute, _ := parseClock(s.ResetTime)
+	local := t.In(loc)
+	reset := time.Date(local.Year(), local.Month(), local.Day(), minute/60, minute%60, 0, 0, loc)
+	for !reset.After(t) {
+		reset = reset.AddDate(0, 0, 1)
+	}
+	return reset
+}
+
+// Stock reservation statuses.
+const (
+	ReservationPending   = "pending"
+	ReservationReserved  = "reserved"
+	ReservationReleasing = "releasing"
+	ReservationFailed    = "failed"
+	ReservationReleased  = "released"
+)
+
+// StockReservation records the stock taken for one order so a retried
+// request is not taken twice and a cancellation gives back exactly what was
+// taken. Items lists only the dishes and variants whose stock is tracked,
+// each with the NextResetAt of the stock it was taken from; stock that has
+// been reset since is not given back. ReleaseRequested is set when the
+// order gave up on the reservation before it was finished.
+type StockReservation struct {
+	ID               string                 `bson:"_id"`
+	OrderId          int                    `bson:"orderId,omitempty"`
+	Items            []StockReservationItem `bson:"items"`
+	Status           string                 `bson:"status"`
+	Error            string                 `bson:"error,omitempty"`
+	ReleaseRequested bool                   `bson:"releaseRequested,omitempty"`
+	CreatedAt        time.Time              `bson:"createdAt"`
+	UpdatedAt        time.Time              `bson:"updatedAt"`
+}
+
+type StockReservationItem struct {
+	DishID    bson.ObjectID  `bson:"dishId"`
+	VariantID *bson.ObjectID `bson:"variantId,omitempty"`
+	Quantity  int            `bson:"quantity"`
+	ResetAt   time.Time      `bson:"resetAt"`
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Variant is a size or portion of a dish with its own price, such as half
+// and full. A dish with variants is ordered by variant; its Price is then
+// the cheapest variant's and only shown as a starting price.
+type Variant struct {
+	ID                 bson.ObjectID `bson:"_id" json:"id"`
+	Name               string        `bson:"name" json:"name"`
+	Price              int           `bson:"price" json:"price"`
+	PreparationTime    int           `bson:"preparationTime,omitempty" json:"preparationTime,omitempty"`
+	AvailabilityStatus string        `bson:"availabilityStatus,omitempty" json:"availabilityStatus,omitempty"`
+	Nutrition          *Nutrition    `bson:"nutrition,omitempty" json:"nutrition,omitempty"`
+	Stock              *Stock        `bson:"stock,omitempty" json:"stock,omitempty"`
+}
+
+// IsAvailable reports whether the variant can currently be ordered.
+// Variants without an explicit status are treated as available.
+func (v Variant) IsAvailable() bool {
+	return v.AvailabilityStatus == "" || strings.EqualFold(v.AvailabilityStatus, AvailabilityAvailable)
+}
+
+// VariantError reports a variant list that cannot be saved.
+type VariantError struct {
+	Message string
+}
+
+func (e *VariantError) Error() string {
+	return e.Message
+}
+
+// PrepareVariants validates variants and gives new ones an id. Variants
+// that carry an id must be among existing, so clients cannot invent ids.
+// Stock is managed separately, so existing variants keep theirs.
+func PrepareVariants(variants []Variant, existing []Variant) error {
+	known := map[bson.ObjectID]*Stock{}
+	for _, variant := range existing {
+		known[variant.ID] = variant.Stock
+	}
+	names := map[string]bool{}
+	ids := map[bson.ObjectID]bool{}
+	for i := range variants {
+		variant := &variants[i]
+		variant.Name = strings.TrimSpace(variant.Name)
+		if variant.Name == "" {
+			return &VariantError{Message: "variant name is required"}
+		}
+		if names[strings.ToLower(variant.Name)] {
+			return &VariantError{Message: fmt.Sprintf("duplicate variant %q", variant.Name)}
+		}
+		names[strings.ToLower(variant.Name)] = true
+		if variant.Price <= 0 {
+			return &VariantError{Message: fmt.Sprintf("variant %q must have a positive price", variant.Name)}
+		}
+		if variant.PreparationTime < 0 {
+			return &VariantError{Message: fmt.Sprintf("variant %q preparation time cannot be negative", variant.Name)}
+		}
+		if variant.Nutrition != nil {
+			if err := variant.Nutrition.Validate(); err != nil {
+				return &VariantError{Message: fmt.Sprintf("variant %q: %v", variant.Name, err)}
+			}
+		}
+		stock, isKnown := known[variant.ID]
+		if variant.ID.IsZero() {
+			variant.ID = bson.NewObjectID()
+		} else if !isKnown || ids[variant.ID] {
+			return &VariantError{Message: fmt.Sprintf("unknown variant id %s", variant.ID.Hex())}
+		}
+		variant.Stock = stock
+		ids[variant.ID] = true
+	}
+	return nil
+}
+
+// StartingPrice is the price shown for a dish: its cheapest variant, or
+// price when it has none.
+func StartingPrice(price int, variants []Variant) int {
+	if len(variants) == 0 {
+		return price
+	}
+	lowest := variants[0].Price
+	for _, variant := range variants[1:] {
+		lowest = min(lowest, variant.Price)
+	}
+	return lowest
+}
+
+// Variant returns the dish's variant with the given id.
+func (d Dish) Variant(id bson.ObjectID) (Variant, bool) {
+	for _, variant := range d.Variants {
+		if variant.ID == id {
+			return variant, true
+		}
+	}
+	return Variant{}, false
+}
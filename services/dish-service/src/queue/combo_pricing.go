@@ -0,0 +1,164 @@
+package queue
+
+import (
+	"context"
+	"dish-service/src/config"
+	"dish-service/src/model"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// ComboPricingRequest asks for the price of each combo line item of an
+// order with the customer's picks, option ids keyed by group key.
+type ComboPricingRequest struct {
+	Items []ComboSelection `json:"items"`
+}
+
+type ComboSelection struct {
+	ComboID    bson.ObjectID              `json:"comboId"`
+	Selections map[string][]bson.ObjectID `json:"selections"`
+}
+
+// ComboPrice is the reply for one combo line item, in request order. Price
+// is per combo and Components are what the kitchen prepares for one combo.
+// PreparationTime is the slowest component's, in minutes.
+type ComboPrice struct {
+	ComboID         string           `json:"comboId"`
+	Name            string           `json:"name"`
+	RestaurantId    string           `json:"restaurantId"`
+	Price           int              `json:"price"`
+	PreparationTime int              `json:"preparationTime"`
+	Components      []ComboComponent `json:"components"`
+	Error           string           `json:"error,omitempty"`
+}
+
+type ComboComponent struct {
+	DishID      bson.ObjectID  `json:"dishId"`
+	DishName    string         `json:"dishName"`
+	VariantID   *bson.ObjectID `json:"variantId,omitempty"`
+	VariantName string         `json:"variantName,omitempty"`
+	Quantity    int            `json:"quantity"`
+}
+
+// ComboPricingBatch is the reply on the combo_pricing queue.
+type ComboPricingBatch struct {
+	Items []ComboPrice `json:"items"`
+	Error string       `json:"error,omitempty"`
+}
+
+func ComboPricingResponder(client *mongo.Client) {
+	serve("combo_pricing", func(body []byte) any {
+		return priceCombos(body)
+	})
+}
+
+func priceCombos(body []byte) ComboPricingBatch {
+	var request ComboPricingRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		log.Println("Error decoding combo pricing request:", err)
+		return ComboPricingBatch{Error: "invalid request"}
+	}
+	response := ComboPricingBatch{Items: []ComboPrice{}}
+	if len(request.Items) == 0 {
+		return response
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var comboIds []bson.ObjectID
+	for _, item := range request.Items {
+		comboIds = append(comboIds, item.ComboID)
+	}
+	cursor, err := config.ComboCollection.Find(ctx, bson.M{"_id": bson.M{"$in": comboIds}})
+	if err != nil {
+		log.Println("Error fetching combos:", err)
+		return ComboPricingBatch{Error: "failed to fetch combos"}
+	}
+	var combos []model.Combo
+	if err := cursor.All(ctx, &combos); err != nil {
+		log.Println("Error decoding combos:", err)
+		return ComboPricingBatch{Error: "failed to fetch combos"}
+	}
+
+	byId := map[string]model.Combo{}
+	var dishIds []bson.ObjectID
+	for _, combo := range combos {
+		byId[combo.ID.Hex()] = combo
+		dishIds = append(dishIds, combo.ComponentIDs()...)
+	}
+	dishes := map[string]model.Dish{}
+	if len(dishIds) > 0 {
+		cursor, err := config.DishCollection.Find(ctx, bson.M{"_id": bson.M{"$in": dishIds}})
+		if err != nil {
+			log.Println("Error fetching dishes:", err)
+			return ComboPricingBatch{Error: "failed to fetch dishes"}
+		}
+		var found []model.Dish
+		if err := cursor.All(ctx, &found); err != nil {
+			log.Println("Error decoding dishes:", err)
+			return ComboPricingBatch{Error: "failed to fetch dishes"}
+		}
+		for _, dish := range found {
+			dishes[dish.ID.Hex()] = dish
+		}
+	}
+
+	for _, item := range request.Items {
+		response.Items = append(response.Items, priceCombo(item, byId, dishes))
+	}
+	return response
+}
+
+func priceCombo(item ComboSelection, combos map[string]model.Combo, dishes map[string]model.Dish) ComboPrice {
+	price := ComboPrice{ComboID: item.ComboID.Hex()}
+	combo, ok := combos[price.ComboID]
+	if !ok {
+		price.Error = "combo not found"
+		return price
+	}
+	if !combo.IsAvailable() {
+		price.Error = "combo is currently unavailable"
+		return price
+	}
+	components, total, err := combo.Select(item.Selections, dishes, time.Now())
+	var comboErr *model.ComboError
+	if errors.As(err, &comboErr) {
+		price.Error = comboErr.Error()
+		return price
+	}
+	if err != nil {
+		log.Println("Error pricing combo:", err)
+		price.Error = "failed to price combo"
+		return price
+	}
+
+	price.Name = combo.Name
+	price.RestaurantId = combo.RestaurantId
+	price.Price = total
+	for _, component := range components {
+		dish := dishes[component.DishID.Hex()]
+		preparationTime := dish.PreparationTime
+		priced := ComboComponent{
+			DishID:    component.DishID,
+			DishName:  dish.Name,
+			VariantID: component.VariantID,
+			Quantity:  component.Quantity,
+		}
+		if component.VariantID != nil {
+			variant, _ := dish.Variant(*component.VariantID)
+			priced.VariantName = variant.Name
+			if variant.PreparationTime > 0 {
+				preparationTime = variant.PreparationTime
+			}
+		}
+		price.PreparationTime = max(price.PreparationTime, preparationTime)
+		price.Components = append(price.Components, priced)
+	}
+	return price
+}
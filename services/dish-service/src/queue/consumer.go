@@ -0,0 +1,73 @@
+package queue
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	"github.com/streadway/amqp"
+)
+
+// errMalformed marks a message that can never be handled, so it is dropped
+// rather than redelivered.
+var errMalformed = errors.New("malformed message")
+
+func rabbitMQURL() string {
+	if url := os.Getenv("RABBITMQ_URL"); url != "" {
+		return url
+	}
+	return "amqp://localhost"
+}
+
+// consumeBound binds a durable queue to a topic exchange and passes every
+// message to handle. Messages are acked when handle succeeds, dropped when
+// it fails with errMalformed and requeued on any other error.
+func consumeBound(queueName string, exchange string, bindingKey string, handle func(msg amqp.Delivery) error) {
+	conn, err := amqp.Dial(rabbitMQURL())
+	if err != nil {
+		log.Fatalf("Failed to connect to RabbitMQ: %v", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		log.Fatalf("Failed to open a RabbitMQ channel: %v", err)
+	}
+	defer ch.Close()
+
+	if err := ch.ExchangeDeclare(exchange, "topic", true, false, false, false, nil); err != nil {
+		log.Fatalf("Failed to declare an exchange: %v", err)
+	}
+	_, err = ch.QueueDeclare(
+		queueName, true, false, false, false, nil,
+	)
+	if err != nil {
+		log.Fatalf("Failed to declare a queue: %v", err)
+	}
+	if err := ch.QueueBind(queueName, bindingKey, exchange, false, nil); err != nil {
+		log.Fatalf("Failed to bind a queue: %v", err)
+	}
+
+	msgs, err := ch.Consume(
+		queueName, "", false, false, false, false, nil,
+	)
+	if err != nil {
+		log.Fatalf("Failed to consume messages: %v", err)
+	}
+
+	log.Printf(" [*] Waiting for %s messages...\n", queueName)
+
+	for msg := range msgs {
+		err := handle(msg)
+		switch {
+		case err == nil:
+			msg.Ack(false)
+		case errors.Is(err, errMalformed):
+			log.Printf("Dropping %s message: %v\n", queueName, err)
+			msg.Nack(false, false)
+		default:
+			log.Printf("Error handling %s message: %v\n", queueName, err)
+			msg.Nack(false, true)
+		}
+	}
+}
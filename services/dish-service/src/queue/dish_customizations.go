@@ -0,0 +1,105 @@
+package queue
+
+import (
+	"context"
+	"dish-service/src/config"
+	"dish-service/src/model"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// CustomizationRequest asks dish-service to validate and price the
+// customizations submitted for each line item of an order.
+type CustomizationRequest struct {
+	Items []CustomizationItem `json:"items"`
+}
+
+type CustomizationItem struct {
+	DishID         bson.ObjectID              `json:"dishId"`
+	Customizations map[string]json.RawMessage `json:"customizations"`
+}
+
+// PricedCustomization is the reply for one line item, in request order.
+// Customizations has defaults filled in and Surcharge is per unit. Error is
+// set when the submitted customizations do not fit the dish.
+type PricedCustomization struct {
+	DishID         string         `json:"dishId"`
+	Customizations map[string]any `json:"customizations"`
+	Surcharge      int            `json:"surcharge"`
+	Error          string         `json:"error,omitempty"`
+}
+
+// CustomizationBatch is the reply on the dish_customizations queue.
+type CustomizationBatch struct {
+	Items []PricedCustomization `json:"items"`
+	Error string                `json:"error,omitempty"`
+}
+
+func DishCustomizationsResponder(client *mongo.Client) {
+	serve("dish_customizations", func(body []byte) any {
+		return priceCustomizations(body)
+	})
+}
+
+func priceCustomizations(body []byte) CustomizationBatch {
+	var request CustomizationRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		log.Println("Error decoding customization request:", err)
+		return CustomizationBatch{Error: "invalid request"}
+	}
+	response := CustomizationBatch{Items: []PricedCustomization{}}
+	if len(request.Items) == 0 {
+		return response
+	}
+
+	var dishIds []bson.ObjectID
+	for _, item := range request.Items {
+		dishIds = append(dishIds, item.DishID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := config.DishCollection.Find(ctx, bson.M{"_id": bson.M{"$in": dishIds}})
+	if err != nil {
+		log.Println("Error fetching dishes:", err)
+		return CustomizationBatch{Error: "failed to fetch dishes"}
+	}
+	var dishes []model.Dish
+	if err := cursor.All(ctx, &dishes); err != nil {
+		log.Println("Error decoding dishes:", err)
+		return CustomizationBatch{Error: "failed to fetch dishes"}
+	}
+	byId := map[string]model.Dish{}
+	for _, dish := range dishes {
+		byId[dish.ID.Hex()] = dish
+	}
+
+	for _, item := range request.Items {
+		priced := PricedCustomization{DishID: item.DishID.Hex()}
+		dish, ok := byId[priced.DishID]
+		if !ok {
+			priced.Error = "dish not found"
+			response.Items = append(response.Items, priced)
+			continue
+		}
+		customizations, surcharge, err := dish.PriceCustomizations(item.Customizations)
+		var customizationErr *model.CustomizationError
+		if errors.As(err, &customizationErr) {
+			priced.Error = customizationErr.Error()
+		} else if err != nil {
+			log.Println("Error pricing customizations:", err)
+			priced.Error = "failed to price customizations"
+		} else {
+			priced.Customizations = customizations
+			priced.Surcharge = surcharge
+		}
+		response.Items = append(response.Items, priced)
+	}
+	return response
+}
@@ -0,0 +1,122 @@
+package queue
+
+import (
+	"context"
+	"dish-service/src/config"
+	"dish-service/src/model"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// DishDetails is the reply order-services expects on the dish_details queue.
+// Found is false (and Error set) when the dish could not be returned.
+type DishDetails struct {
+	Name     string `json:"dish_name"`
+	Image    string `json:"dish_image"`
+	Category string `json:"dish_category"`
+	IsVeg    bool   `json:"dish_is_veg"`
+	Found    bool   `json:"found"`
+	Error    string `json:"error,omitempty"`
+}
+
+// DishDetailsBatch is the reply on the dish_details_batch queue, keyed by dish id.
+type DishDetailsBatch struct {
+	Items   map[string]DishDetails `json:"items"`
+	Missing []string               `json:"missing,omitempty"`
+	Error   string                 `json:"error,omitempty"`
+}
+
+// BatchRequest is the body of every *_batch request.
+type BatchRequest struct {
+	IDs []bson.ObjectID `json:"ids"`
+}
+
+func DishDetailsResponder(client *mongo.Client) {
+	serve("dish_details", func(body []byte) any {
+		return lookupDishDetails(body)
+	})
+}
+
+func DishDetailsBatchResponder(client *mongo.Client) {
+	serve("dish_details_batch", func(body []byte) any {
+		return lookupDishDetailsBatch(body)
+	})
+}
+
+// lookupDishDetails decodes the requested dish id and builds the reply for it.
+func lookupDishDetails(body []byte) DishDetails {
+	var dishId bson.ObjectID
+	if err := json.Unmarshal(body, &dishId); err != nil {
+		log.Println("Error decoding dish id:", err)
+		return DishDetails{Error: "invalid dish id"}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var dish model.Dish
+	err := config.DishCollection.FindOne(ctx, bson.M{"_id": dishId}).Decode(&dish)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return DishDetails{Error: "dish not found"}
+	}
+	if err != nil {
+		log.Println("Error fetching dish:", err)
+		return DishDetails{Error: "failed to fetch dish"}
+	}
+
+	return DishDetails{
+		Name:     dish.Name,
+		Image:    dish.DisplayImage,
+		Category: dish.Category,
+		IsVeg:    dish.IsVeg,
+		Found:    true,
+	}
+}
+
+// lookupDishDetailsBatch resolves every requested dish with a single query.
+func lookupDishDetailsBatch(body []byte) DishDetailsBatch {
+	var request BatchRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		log.Println("Error decoding batch request:", err)
+		return DishDetailsBatch{Error: "invalid request"}
+	}
+	response := DishDetailsBatch{Items: map[string]DishDetails{}}
+	if len(request.IDs) == 0 {
+		return response
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := config.DishCollection.Find(ctx, bson.M{"_id": bson.M{"$in": request.IDs}})
+	if err != nil {
+		log.Println("Error fetching dishes:", err)
+		return DishDetailsBatch{Error: "failed to fetch dishes"}
+	}
+	var dishes []model.Dish
+	if err := cursor.All(ctx, &dishes); err != nil {
+		log.Println("Error decoding dishes:", err)
+		return DishDetailsBatch{Error: "failed to fetch dishes"}
+	}
+
+	for _, dish := range dishes {
+		response.Items[dish.ID.Hex()] = DishDetails{
+			Name:     dish.Name,
+			Image:    dish.DisplayImage,
+			Category: dish.Category,
+			IsVeg:    dish.IsVeg,
+			Found:    true,
+		}
+	}
+	for _, id := range request.IDs {
+		if _, ok := response.Items[id.Hex()]; !ok {
+			response.Missing = append(response.Missing, id.Hex())
+		}
+	}
+	return response
+}
@@ -0,0 +1,107 @@
+package queue
+
+import (
+	"context"
+	"dish-service/src/config"
+	"dish-service/src/model"
+	"encoding/json"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// DishPrice is the authoritative price and availability of a dish, used by
+// order-services when pricing a new order. PreparationTime is in minutes.
+// Dishes with variants are priced by variant, keyed by variant id.
+// AvailableFrom is set when the dish is unavailable only because it is
+// outside its schedule.
+type DishPrice struct {
+	Price           int                     `json:"price"`
+	RestaurantId    string                  `json:"restaurantId"`
+	Available       bool                    `json:"available"`
+	AvailableFrom   *time.Time              `json:"availableFrom,omitempty"`
+	PreparationTime int                     `json:"preparationTime"`
+	Variants        map[string]VariantPrice `json:"variants,omitempty"`
+}
+
+type VariantPrice struct {
+	Name            string `json:"name"`
+	Price           int    `json:"price"`
+	Available       bool   `json:"available"`
+	PreparationTime int    `json:"preparationTime"`
+}
+
+// DishPricingBatch is the reply on the dish_pricing queue, keyed by dish id.
+type DishPricingBatch struct {
+	Items   map[string]DishPrice `json:"items"`
+	Missing []string             `json:"missing,omitempty"`
+	Error   string               `json:"error,omitempty"`
+}
+
+func DishPricingResponder(client *mongo.Client) {
+	serve("dish_pricing", func(body []byte) any {
+		return lookupDishPricing(body)
+	})
+}
+
+func lookupDishPricing(body []byte) DishPricingBatch {
+	var request BatchRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		log.Println("Error decoding pricing request:", err)
+		return DishPricingBatch{Error: "invalid request"}
+	}
+	response := DishPricingBatch{Items: map[string]DishPrice{}}
+	if len(request.IDs) == 0 {
+		return response
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := config.DishCollection.Find(ctx, bson.M{"_id": bson.M{"$in": request.IDs}})
+	if err != nil {
+		log.Println("Error fetching dishes:", err)
+		return DishPricingBatch{Error: "failed to fetch dishes"}
+	}
+	var dishes []model.Dish
+	if err := cursor.All(ctx, &dishes); err != nil {
+		log.Println("Error decoding dishes:", err)
+		return DishPricingBatch{Error: "failed to fetch dishes"}
+	}
+
+	now := time.Now()
+	for _, dish := range dishes {
+		available, availableFrom := dish.AvailableAt(now)
+		price := DishPrice{
+			Price:           dish.Price,
+			RestaurantId:    dish.RestaurantId,
+			Available:       available,
+			AvailableFrom:   availableFrom,
+			PreparationTime: dish.PreparationTime,
+		}
+		if len(dish.Variants) > 0 {
+			price.Variants = map[string]VariantPrice{}
+		}
+		for _, variant := range dish.Variants {
+			preparationTime := variant.PreparationTime
+			if preparationTime == 0 {
+				preparationTime = dish.PreparationTime
+			}
+			price.Variants[variant.ID.Hex()] = VariantPrice{
+				Name:            variant.Name,
+				Price:           variant.Price,
+				Available:       variant.IsAvailable(),
+				PreparationTime: preparationTime,
+			}
+		}
+		response.Items[dish.ID.Hex()] = price
+	}
+	for _, id := range request.IDs {
+		if _, ok := response.Items[id.Hex()]; !ok {
+			response.Missing = append(response.Missing, id.Hex())
+		}
+	}
+	return response
+}
@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// publisher sends events to topic exchanges over one shared connection,
+// opened on first use and reopened after any failure.
+type publisher struct {
+	mu       sync.Mutex // guards conn, ch and declared, and serialises publishes
+	conn     *amqp.Connection
+	ch       *amqp.Channel
+	declared map[string]bool
+}
+
+var eventPublisher publisher
+
+// publishEvent sends event as JSON to exchange under routingKey.
+func publishEvent(exchange string, routingKey string, event any) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("%s: encode event: %w", exchange, err)
+	}
+	return eventPublisher.publish(exchange, routingKey, body)
+}
+
+func (p *publisher) publish(exchange string, routingKey string, body []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil || p.conn.IsClosed() {
+		if err := p.connect(); err != nil {
+			return fmt.Errorf("%s: %w", exchange, err)
+		}
+	}
+	if !p.declared[exchange] {
+		if err := p.ch.ExchangeDeclare(exchange, "topic", true, false, false, false, nil); err != nil {
+			p.disconnect()
+			return fmt.Errorf("%s: declare exchange: %w", exchange, err)
+		}
+		p.declared[exchange] = true
+	}
+	err := p.ch.Publish(exchange, routingKey, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Type:         routingKey,
+		Timestamp:    time.Now(),
+		Body:         body,
+	})
+	if err != nil {
+		p.disconnect()
+		return fmt.Errorf("%s: publish: %w", exchange, err)
+	}
+	return nil
+}
+
+func (p *publisher) connect() error {
+	p.disconnect()
+	conn, err := amqp.Dial(rabbitMQURL())
+	if err != nil {
+		return err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	p.conn = conn
+	p.ch = ch
+	p.declared = map[string]bool{}
+	return nil
+}
+
+func (p *publisher) disconnect() {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.conn = nil
+	p.ch = nil
+}
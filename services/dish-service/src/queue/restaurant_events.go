@@ -0,0 +1,107 @@
+package queue
+
+import (
+	"context"
+	"dish-service/src/config"
+	"dish-service/src/model"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/streadway/amqp"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Restaurant event types, also used as routing keys on the restaurant
+// events exchange.
+const (
+	RestaurantCreated = "restaurant.created"
+	RestaurantUpdated = "restaurant.updated"
+	RestaurantDeleted = "restaurant.deleted"
+)
+
+// RestaurantEvent is published by the restaurant service whenever a
+// restaurant's profile changes. Latitude and Longitude are nil when the
+// restaurant has not set a location yet.
+type RestaurantEvent struct {
+	Type             string    `json:"type"`
+	RestaurantId     string    `json:"restaurantId"`
+	Latitude         *float64  `json:"latitude,omitempty"`
+	Longitude        *float64  `json:"longitude,omitempty"`
+	DeliveryRadiusKm float64   `json:"deliveryRadiusKm,omitempty"`
+	OccurredAt       time.Time `json:"occurredAt"`
+}
+
+func RestaurantEventsExchange() string {
+	if exchange := os.Getenv("RESTAURANT_EVENTS_EXCHANGE"); exchange != "" {
+		return exchange
+	}
+	return "restaurant_events"
+}
+
+// SyncRestaurantLocations keeps the restaurantLocations collection in step
+// with restaurant events so dishes can be searched by distance.
+func SyncRestaurantLocations(client *mongo.Client) {
+	consumeBound("dish_restaurant_locations", RestaurantEventsExchange(), "restaurant.*", func(msg amqp.Delivery) error {
+		var event RestaurantEvent
+		if err := json.Unmarshal(msg.Body, &event); err != nil {
+			return fmt.Errorf("%w: %v", errMalformed, err)
+		}
+		if event.RestaurantId == "" {
+			return fmt.Errorf("%w: restaurant event without restaurantId", errMalformed)
+		}
+		if !validCoordinates(event) {
+			return fmt.Errorf("%w: invalid coordinates for %s", errMalformed, event.RestaurantId)
+		}
+		if event.Type == "" {
+			event.Type = msg.RoutingKey
+		}
+		if event.OccurredAt.IsZero() {
+			event.OccurredAt = time.Now()
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return applyRestaurantEvent(ctx, event)
+	})
+}
+
+// applyRestaurantEvent upserts or removes the restaurant's location. Events
+// older than the stored location are ignored so redeliveries cannot move a
+// restaurant back to where it used to be.
+func applyRestaurantEvent(ctx context.Context, event RestaurantEvent) error {
+	notNewer := bson.M{"_id": event.RestaurantId, "updatedAt": bson.M{"$not": bson.M{"$gt": event.OccurredAt}}}
+
+	if event.Type == RestaurantDeleted || event.Latitude == nil || event.Longitude == nil {
+		_, err := config.RestaurantLocationCollection.DeleteOne(ctx, notNewer)
+		return err
+	}
+
+	radius := event.DeliveryRadiusKm
+	if radius <= 0 {
+		radius = config.DefaultDeliveryRadiusKm()
+	}
+	location := model.RestaurantLocation{
+		RestaurantId:     event.RestaurantId,
+		Location:         model.NewGeoPoint(*event.Latitude, *event.Longitude),
+		DeliveryRadiusKm: radius,
+		UpdatedAt:        event.OccurredAt,
+	}
+	_, err := config.RestaurantLocationCollection.ReplaceOne(ctx, notNewer, location, options.Replace().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// A newer location is already stored.
+		return nil
+	}
+	return err
+}
+
+func validCoordinates(event RestaurantEvent) bool {
+	if event.Latitude == nil || event.Longitude == nil {
+		return true
+	}
+	return *event.Latitude >= -90 && *event.Latitude <= 90 &&
+		*event.Longitude >= -180 && *event.Longitude <= 180
+}
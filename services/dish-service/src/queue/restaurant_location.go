@@ -0,0 +1,58 @@
+package queue
+
+import (
+	"context"
+	"dish-service/src/config"
+	"dish-service/src/model"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// RestaurantLocation is the reply on the restaurant_location queue, which
+// order-services calls to dispatch orders and estimate delivery times.
+type RestaurantLocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// RestaurantLocationResponder answers restaurant_location requests from
+// the locations synced by SyncRestaurantLocations.
+func RestaurantLocationResponder(client *mongo.Client) {
+	serve("restaurant_location", func(body []byte) any {
+		return lookupRestaurantLocation(body)
+	})
+}
+
+func lookupRestaurantLocation(body []byte) RestaurantLocation {
+	var restaurantId string
+	if err := json.Unmarshal(body, &restaurantId); err != nil || restaurantId == "" {
+		log.Println("Error decoding restaurant id:", err)
+		return RestaurantLocation{Error: "invalid restaurant id"}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var location model.RestaurantLocation
+	err := config.RestaurantLocationCollection.FindOne(ctx, bson.M{"_id": restaurantId}).Decode(&location)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return RestaurantLocation{Error: "restaurant location not found"}
+	}
+	if err != nil {
+		log.Println("Error fetching restaurant location:", err)
+		return RestaurantLocation{Error: "failed to fetch restaurant location"}
+	}
+	if len(location.Location.Coordinates) != 2 {
+		return RestaurantLocation{Error: "restaurant location not found"}
+	}
+	return RestaurantLocation{
+		Latitude:  location.Location.Coordinates[1],
+		Longitude: location.Location.Coordinates[0],
+	}
+}
@@ -0,0 +1,75 @@
+package queue
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+
+	"github.com/streadway/amqp"
+)
+
+// serve consumes requestQueue and answers every request with the value
+// returned by handle, published to the caller's ReplyTo queue.
+func serve(requestQueue string, handle func(body []byte) any) {
+	rabbitMQURL := os.Getenv("RABBITMQ_URL")
+	if rabbitMQURL == "" {
+		rabbitMQURL = "amqp://localhost"
+	}
+
+	conn, err := amqp.Dial(rabbitMQURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to RabbitMQ: %v", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		log.Fatalf("Failed to open a RabbitMQ channel: %v", err)
+	}
+	defer ch.Close()
+
+	_, err = ch.QueueDeclare(
+		requestQueue, true, false, false, false, nil,
+	)
+	if err != nil {
+		log.Fatalf("Failed to declare a queue: %v", err)
+	}
+
+	msgs, err := ch.Consume(
+		requestQueue, "", false, false, false, false, nil,
+	)
+	if err != nil {
+		log.Fatalf("Failed to consume messages: %v", err)
+	}
+
+	log.Printf(" [*] Waiting for %s requests...\n", requestQueue)
+
+	for msg := range msgs {
+		response := handle(msg.Body)
+		if err := reply(ch, msg, response); err != nil {
+			log.Printf("Error sending %s reply: %v\n", requestQueue, err)
+			msg.Nack(false, true)
+			continue
+		}
+		msg.Ack(false)
+	}
+}
+
+// reply publishes response to the caller's ReplyTo queue with its CorrelationId.
+func reply(ch *amqp.Channel, msg amqp.Delivery, response any) error {
+	if msg.ReplyTo == "" {
+		return nil
+	}
+	body, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return ch.Publish(
+		"", msg.ReplyTo, false, false,
+		amqp.Publishing{
+			CorrelationId: msg.CorrelationId,
+			ContentType:   "application/json",
+			Body:          body,
+		},
+	)
+}
@@ -0,0 +1,123 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/streadway/amqp"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// StockItem is a quantity of a dish, or of one of its variants, to reserve.
+type StockItem struct {
+	DishID    bson.ObjectID  `json:"dishId"`
+	VariantID *bson.ObjectID `json:"variantId,omitempty"`
+	Quantity  int            `json:"quantity"`
+}
+
+// StockReservationRequest is sent on the dish_stock_reserve queue when an
+// order is placed. ReservationId is chosen by the caller, so a retried
+// request is answered from the first attempt instead of reserving twice.
+type StockReservationRequest struct {
+	ReservationId string      `json:"reservationId"`
+	Items         []StockItem `json:"items"`
+}
+
+// StockReservationReply says whether every item was reserved. Nothing is
+// reserved when Reserved is false; Message then says why and Shortages
+// lists the items there was not enough of. Error is set when the request
+// itself failed.
+type StockReservationReply struct {
+	Reserved  bool            `json:"reserved"`
+	Message   string          `json:"message,omitempty"`
+	Shortages []StockShortage `json:"shortages,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+type StockShortage struct {
+	DishID    bson.ObjectID  `json:"dishId"`
+	VariantID *bson.ObjectID `json:"variantId,omitempty"`
+	Name      string         `json:"name"`
+	Remaining int            `json:"remaining"`
+}
+
+// StockReleaseMessage gives back the stock taken by a reservation, for
+// example when its order is cancelled. It is published to the stock
+// exchange under StockReleaseKey.
+type StockReleaseMessage struct {
+	ReservationId string `json:"reservationId"`
+	OrderId       int    `json:"orderId,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+const StockReleaseKey = "stock.release"
+
+// Dish stock event types, also used as routing keys on the dish events
+// exchange.
+const (
+	DishLowStock = "dish.low_stock"
+	DishSoldOut  = "dish.sold_out"
+)
+
+// DishStockEvent is published when a dish or variant reaches its low stock
+// threshold or runs out.
+type DishStockEvent struct {
+	Type              string         `json:"type"`
+	DishID            bson.ObjectID  `json:"dishId"`
+	VariantID         *bson.ObjectID `json:"variantId,omitempty"`
+	RestaurantId      string         `json:"restaurantId"`
+	Name              string         `json:"name"`
+	Remaining         int            `json:"remaining"`
+	DailyLimit        int            `json:"dailyLimit"`
+	LowStockThreshold int            `json:"lowStockThreshold"`
+	OccurredAt        time.Time      `json:"occurredAt"`
+}
+
+func StockExchange() string {
+	if exchange := os.Getenv("DISH_STOCK_EXCHANGE"); exchange != "" {
+		return exchange
+	}
+	return "dish_stock"
+}
+
+func DishEventsExchange() string {
+	if exchange := os.Getenv("DISH_EVENTS_EXCHANGE"); exchange != "" {
+		return exchange
+	}
+	return "dish_events"
+}
+
+// StockReservationResponder answers dish_stock_reserve requests with reserve.
+func StockReservationResponder(client *mongo.Client, reserve func(request StockReservationRequest) StockReservationReply) {
+	serve("dish_stock_reserve", func(body []byte) any {
+		var request StockReservationRequest
+		if err := json.Unmarshal(body, &request); err != nil {
+			log.Println("Error decoding stock reservation:", err)
+			return StockReservationReply{Error: "invalid request"}
+		}
+		return reserve(request)
+	})
+}
+
+// ConsumeStockReleases passes every stock release message to release.
+func ConsumeStockReleases(client *mongo.Client, release func(message StockReleaseMessage) error) {
+	consumeBound("dish_stock_releases", StockExchange(), StockReleaseKey, func(msg amqp.Delivery) error {
+		var message StockReleaseMessage
+		if err := json.Unmarshal(msg.Body, &message); err != nil {
+			return fmt.Errorf("%w: %v", errMalformed, err)
+		}
+		if message.ReservationId == "" {
+			return fmt.Errorf("%w: stock release without reservationId", errMalformed)
+		}
+		return release(message)
+	})
+}
+
+// PublishDishStockEvent publishes event to the dish events exchange.
+func PublishDishStockEvent(event DishStockEvent) error {
+	return publishEvent(DishEventsExchange(), event.Type, event)
+}
@@ -16,6 +16,18 @@ func Routes(r *gin.Engine, client *mongo.Client) {
 		controllers.GetAllDishes(client, ctx)
 	})
 
+	r.GET("/search", func(ctx *gin.Context) {
+		controllers.SearchDishes(client, ctx)
+	})
+
+	r.POST("/import", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controllers.ImportMenu(client, ctx)
+	})
+
+	r.GET("/export", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controllers.ExportMenu(client, ctx)
+	})
+
 	r.GET("/:id", func(ctx *gin.Context) {
 		controllers.GetDishDetails(client, ctx)
 	})
@@ -27,4 +39,76 @@ func Routes(r *gin.Engine, client *mongo.Client) {
 	r.DELETE("/:id",middleware.AuthMiddleware(), func(ctx *gin.Context) {
 		controllers.DeleteDish(client, ctx)
 	})
-}
\ No newline at end of file
+
+	r.GET("/:id/customizations", func(ctx *gin.Context) {
+		controllers.GetDishCustomizations(client, ctx)
+	})
+
+	r.POST("/:id/customizations", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controllers.AddDishCustomization(client, ctx)
+	})
+
+	r.PUT("/:id/customizations/:key", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controllers.UpdateDishCustomization(client, ctx)
+	})
+
+	r.DELETE("/:id/customizations/:key", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controllers.DeleteDishCustomization(client, ctx)
+	})
+
+	r.POST("/:id/images", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controllers.AddDishImage(client, ctx)
+	})
+
+	r.PUT("/:id/images/:imageId/primary", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controllers.SetPrimaryDishImage(client, ctx)
+	})
+
+	r.DELETE("/:id/images/:imageId", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controllers.DeleteDishImage(client, ctx)
+	})
+
+	r.GET("/:id/stock", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controllers.GetDishStock(client, ctx)
+	})
+
+	r.PUT("/:id/stock", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controllers.SetDishStock(client, ctx)
+	})
+
+	r.DELETE("/:id/stock", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controllers.DeleteDishStock(client, ctx)
+	})
+
+	r.PUT("/:id/variants/:variantId/stock", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controllers.SetVariantStock(client, ctx)
+	})
+
+	r.DELETE("/:id/variants/:variantId/stock", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controllers.DeleteVariantStock(client, ctx)
+	})
+
+	r.POST("/combos", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controllers.AddCombo(client, ctx)
+	})
+
+	r.GET("/combos", func(ctx *gin.Context) {
+		controllers.GetAllCombos(client, ctx)
+	})
+
+	r.GET("/combos/:id", func(ctx *gin.Context) {
+		controllers.GetComboDetails(client, ctx)
+	})
+
+	r.PATCH("/combos/:id", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controllers.UpdateCombo(client, ctx)
+	})
+
+	r.DELETE("/combos/:id", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controllers.DeleteCombo(client, ctx)
+	})
+
+	r.GET("/menu/:restaurantId", func(ctx *gin.Context) {
+		controllers.GetRestaurantMenu(client, ctx)
+	})
+}
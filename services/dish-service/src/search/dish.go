@@ -0,0 +1,47 @@
+package search
+
+import (
+	"context"
+	"dish-service/src/config"
+	"dish-service/src/model"
+	"log"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// DishGrams returns the trigrams stored on a dish for fuzzy search.
+// Descriptions are left out so long copy does not drown the name.
+func DishGrams(dish model.Dish) []string {
+	texts := append([]string{dish.Name, dish.Category}, dish.Tags...)
+	return Grams(texts...)
+}
+
+// BackfillDishGrams fills searchGrams on dishes created before fuzzy search
+// existed. It is safe to run on every start.
+func BackfillDishGrams(ctx context.Context) error {
+	cursor, err := config.DishCollection.Find(ctx, bson.M{"searchGrams": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	updated := 0
+	for cursor.Next(ctx) {
+		var dish model.Dish
+		if err := cursor.Decode(&dish); err != nil {
+			return err
+		}
+		_, err := config.DishCollection.UpdateOne(ctx,
+			bson.M{"_id": dish.ID},
+			bson.M{"$set": bson.M{"searchGrams": DishGrams(dish)}},
+		)
+		if err != nil {
+			return err
+		}
+		updated++
+	}
+	if updated > 0 {
+		log.Printf("Backfilled search grams for %d dishes\n", updated)
+	}
+	return cursor.Err()
+}
@@ -0,0 +1,48 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// MinSimilarity is the trigram similarity above which a word counts as a
+// misspelling of a query word.
+const MinSimilarity = 0.35
+
+// Highlight wraps every word of text that matches a query word in <em> tags.
+// The rest of text is HTML escaped so the result is safe to render. ok is
+// false when nothing matched.
+func Highlight(text string, query []string) (highlighted string, ok bool) {
+	var b strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		j := i
+		isWord := isWordRune(runes[i])
+		for j < len(runes) && isWordRune(runes[j]) == isWord {
+			j++
+		}
+		segment := html.EscapeString(string(runes[i:j]))
+		if isWord && matchesAny(strings.ToLower(string(runes[i:j])), query) {
+			b.WriteString("<em>" + segment + "</em>")
+			ok = true
+		} else {
+			b.WriteString(segment)
+		}
+		i = j
+	}
+	return b.String(), ok
+}
+
+func matchesAny(word string, query []string) bool {
+	for _, q := range query {
+		if Match(q, word) >= MinSimilarity {
+			return true
+		}
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
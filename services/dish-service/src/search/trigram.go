@@ -0,0 +1,106 @@
+// Package search holds the text helpers behind dish search: trigram
+// generation for typo tolerant matching and highlighting of matched words.
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Words lowercases s and splits it into runs of letters and digits.
+func Words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Trigrams returns the distinct trigrams of a single word, padded the way
+// pg_trgm does so short words and word starts still produce grams.
+func Trigrams(word string) []string {
+	runes := []rune("  " + word + " ")
+	seen := map[string]bool{}
+	var grams []string
+	for i := 0; i+3 <= len(runes); i++ {
+		gram := string(runes[i : i+3])
+		if !seen[gram] {
+			seen[gram] = true
+			grams = append(grams, gram)
+		}
+	}
+	return grams
+}
+
+// Grams returns the sorted, distinct trigrams of every word in texts.
+func Grams(texts ...string) []string {
+	seen := map[string]bool{}
+	var grams []string
+	for _, text := range texts {
+		for _, word := range Words(text) {
+			for _, gram := range Trigrams(word) {
+				if !seen[gram] {
+					seen[gram] = true
+					grams = append(grams, gram)
+				}
+			}
+		}
+	}
+	sort.Strings(grams)
+	return grams
+}
+
+// Similarity is the share of trigrams two words have in common, from 0 for
+// unrelated words to 1 for identical ones.
+func Similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ga, gb := Trigrams(a), Trigrams(b)
+	inA := make(map[string]bool, len(ga))
+	for _, gram := range ga {
+		inA[gram] = true
+	}
+	shared := 0
+	for _, gram := range gb {
+		if inA[gram] {
+			shared++
+		}
+	}
+	union := len(ga) + len(gb) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// Match reports how well a query word matches word: exact matches and
+// prefixes score 1, anything else its trigram similarity.
+func Match(query, word string) float64 {
+	if strings.HasPrefix(word, query) {
+		return 1
+	}
+	return Similarity(query, word)
+}
+
+// Score is the average, over every query word, of its best Match against
+// the words of texts.
+func Score(query []string, texts ...string) float64 {
+	if len(query) == 0 {
+		return 0
+	}
+	var words []string
+	for _, text := range texts {
+		words = append(words, Words(text)...)
+	}
+	total := 0.0
+	for _, q := range query {
+		best := 0.0
+		for _, word := range words {
+			if s := Match(q, word); s > best {
+				best = s
+			}
+		}
+		total += best
+	}
+	return total / float64(len(query))
+}
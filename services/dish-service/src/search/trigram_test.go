@@ -0,0 +1,114 @@
+package search
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"Paneer Tikka", []string{"paneer", "tikka"}},
+		{"  Chicken-65, extra spicy! ", []string{"chicken", "65", "extra", "spicy"}},
+		{"Crème brûlée", []string{"crème", "brûlée"}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		if got := Words(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Words(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTrigrams(t *testing.T) {
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"dal", []string{"  d", " da", "dal", "al "}},
+		{"a", []string{"  a", " a "}},
+		{"aaaa", []string{"  a", " aa", "aaa", "aa "}},
+	}
+	for _, tt := range tests {
+		if got := Trigrams(tt.word); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Trigrams(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestGramsAreSortedAndDistinct(t *testing.T) {
+	got := Grams("Dal dal", "DAL")
+	want := []string{"  d", " da", "al ", "dal"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Grams() = %q, want %q", got, want)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"biryani", "biryani", 1},
+		{"dal", "dal", 1},
+		// "dal" and "daal" share "  d", " da" and "al " out of 6 grams
+		{"dal", "daal", 0.5},
+		{"dal", "xyz", 0},
+	}
+	for _, tt := range tests {
+		if got := Similarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := Similarity(tt.b, tt.a); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Similarity(%q, %q) = %v, want %v", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		query, word string
+		want        float64
+	}{
+		{"pan", "paneer", 1},
+		{"paneer", "paneer", 1},
+		{"paneer", "pan", Similarity("paneer", "pan")},
+		{"panner", "paneer", Similarity("panner", "paneer")},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.query, tt.word); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.query, tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		query []string
+		texts []string
+		want  float64
+	}{
+		{"no query", nil, []string{"Paneer Tikka"}, 0},
+		{"every word matches", []string{"paneer", "tik"}, []string{"Paneer Tikka"}, 1},
+		{"words may come from any text", []string{"paneer", "starter"}, []string{"Paneer Tikka", "Starters"}, 1},
+		{"half the words match", []string{"paneer", "xyz"}, []string{"Paneer Tikka"}, 0.5},
+		{"nothing matches", []string{"xyz"}, []string{"Paneer Tikka"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Score(tt.query, tt.texts...); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Score(%q, %q) = %v, want %v", tt.query, tt.texts, got, tt.want)
+			}
+		})
+	}
+
+	typo := Score([]string{"panner"}, "Paneer Tikka")
+	unrelated := Score([]string{"panner"}, "Gulab Jamun")
+	if typo <= unrelated {
+		t.Errorf("a typo scores %v, no better than an unrelated dish at %v", typo, unrelated)
+	}
+}
@@ -1,14 +1,15 @@
 package utils
 
 import (
+	"bytes"
 	"fmt"
-	"mime/multipart"
+	"log"
 	"os"
-	"path/filepath"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/joho/godotenv"
 )
@@ -18,9 +19,9 @@ var bucketName string
 
 func init() {
     // Load environment variables from .env file
-    err := godotenv.Load()
-    if err != nil {
-        panic("Error loading .env file")
+    // Settings may come from the environment alone, as in tests
+    if err := godotenv.Load(); err != nil {
+        log.Println("No .env file found!")
     }
 
     region := os.Getenv("AWS_REGION")
@@ -45,25 +46,65 @@ func init() {
 	uploader = s3manager.NewUploader(awsSession)
 }
 
-// SaveFile uploads a file to S3 and returns its URL.
-func SaveFile(file multipart.File, fileHeader *multipart.FileHeader, dishId string, restaurantId string, pathType string) (string, error) {
-	// Generate a unique filename using user ID and a new UUID
-	originalFileName := fileHeader.Filename
-	ext := filepath.Ext(originalFileName)
-	fileName := fmt.Sprintf("%s/%s/%s%s", pathType, restaurantId, dishId, ext)
-
-	// Upload the file to S3
+// SaveFile uploads body to S3 under key and returns its URL. Keys are
+// expected to change with the content, so the object is cached for good.
+func SaveFile(key string, body []byte, contentType string) (string, error) {
 	_, err := uploader.Upload(&s3manager.UploadInput{
-		Bucket: aws.String(bucketName),
-		Key:    aws.String(fileName),
-		Body:   file,
+		Bucket:       aws.String(bucketName),
+		Key:          aws.String(key),
+		Body:         bytes.NewReader(body),
+		ContentType:  aws.String(contentType),
+		CacheControl: aws.String("public, max-age=31536000, immutable"),
 	})
 	if err != nil {
 		return "", err
 	}
 
 	// Get the URL of the uploaded file
-	url := fmt.Sprintf("https://%s.s3.amazonaws.com/%s", bucketName, fileName)
+	url := fmt.Sprintf("https://%s.s3.amazonaws.com/%s", bucketName, key)
 
 	return url, nil
 }
+
+// DeleteFiles removes the objects under keys from S3.
+func DeleteFiles(keys []string) error {
+	for len(keys) > 0 {
+		// S3 deletes at most 1000 objects per request
+		batch := keys[:min(len(keys), 1000)]
+		keys = keys[len(batch):]
+
+		objects := make([]*s3.ObjectIdentifier, 0, len(batch))
+		for _, key := range batch {
+			objects = append(objects, &s3.ObjectIdentifier{Key: aws.String(key)})
+		}
+		output, err := uploader.S3.DeleteObjects(&s3.DeleteObjectsInput{
+			Bucket: aws.String(bucketName),
+			Delete: &s3.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return err
+		}
+		if len(output.Errors) > 0 {
+			return fmt.Errorf("failed to delete %s: %s", aws.StringValue(output.Errors[0].Key), aws.StringValue(output.Errors[0].Message))
+		}
+	}
+	return nil
+}
+
+// DeleteFilesWithPrefix removes every object whose key starts with prefix.
+func DeleteFilesWithPrefix(prefix string) error {
+	var keys []string
+	err := uploader.S3.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(bucketName),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, last bool) bool {
+		for _, object := range page.Contents {
+			keys = append(keys, aws.StringValue(object.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return DeleteFiles(keys)
+}
@@ -0,0 +1,103 @@
+// Command fake-payment stands in for the payment service during local
+// testing. It consumes payment intents published by order-services and,
+// after FAKE_PAYMENT_DELAY (default 2s), answers each one with a payment
+// event. FAKE_PAYMENT_OUTCOME=failed fails every payment; otherwise they
+// succeed.
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"order-service/src/queue"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func main() {
+	log.SetOutput(os.Stdout)
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found!")
+	}
+
+	rabbitMqUrl := os.Getenv("RABBITMQ_URL")
+	if rabbitMqUrl == "" {
+		rabbitMqUrl = "amqp://localhost"
+	}
+	delay, err := time.ParseDuration(os.Getenv("FAKE_PAYMENT_DELAY"))
+	if err != nil {
+		delay = 2 * time.Second
+	}
+	outcome := os.Getenv("FAKE_PAYMENT_OUTCOME")
+	if outcome != "failed" {
+		outcome = "succeeded"
+	}
+
+	conn, err := amqp.Dial(rabbitMqUrl)
+	if err != nil {
+		log.Fatalf("Failed to connect to RabbitMQ: %v", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		log.Fatalf("Failed to open a RabbitMQ channel: %v", err)
+	}
+	defer ch.Close()
+
+	for _, queueName := range []string{queue.PaymentIntentQueue, queue.PaymentEventQueue} {
+		if _, err := ch.QueueDeclare(queueName, true, false, false, false, nil); err != nil {
+			log.Fatalf("Failed to declare a queue: %v", err)
+		}
+	}
+
+	msgs, err := ch.Consume(queue.PaymentIntentQueue, "", false, false, false, false, nil)
+	if err != nil {
+		log.Fatalf("Failed to consume messages: %v", err)
+	}
+
+	log.Printf(" [*] Fake payment service answering every intent with %q after %s\n", outcome, delay)
+
+	for msg := range msgs {
+		var intent queue.PaymentIntent
+		if err := json.Unmarshal(msg.Body, &intent); err != nil {
+			log.Println("Error decoding payment intent:", err)
+			msg.Nack(false, false)
+			continue
+		}
+		time.Sleep(delay)
+
+		event := queue.PaymentEvent{
+			PaymentId: intent.PaymentId,
+			OrderId:   intent.OrderId,
+			Status:    outcome,
+		}
+		if outcome == "succeeded" {
+			event.ProviderRef = "fake_" + uuid.New().String()
+		} else {
+			event.Reason = "declined by fake payment service"
+		}
+		body, err := json.Marshal(event)
+		if err != nil {
+			log.Println("Error encoding payment event:", err)
+			msg.Nack(false, false)
+			continue
+		}
+		err = ch.Publish("", queue.PaymentEventQueue, false, false, amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			Body:         body,
+		})
+		if err != nil {
+			log.Println("Error publishing payment event:", err)
+			msg.Nack(false, true)
+			continue
+		}
+		log.Printf("Payment %s for order %d %s\n", intent.PaymentId, intent.OrderId, outcome)
+		msg.Ack(false)
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"log"
 	"order-service/src/config"
+	"order-service/src/model"
+	"order-service/src/queue"
 	"order-service/src/routes"
+	"order-service/src/service"
 	"os"
 	"time"
 
@@ -36,6 +39,25 @@ func main() {
 		log.Println("Disconnected from MongoDB.")
 	}()
 
+	queue.Connect()
+	defer queue.Close()
+
+	go queue.ConsumePaymentEvents(service.HandlePaymentEvent)
+	go service.RunPaymentExpirySweeper(time.Minute)
+	go queue.ConsumeRefundEvents(service.HandleRefundEvent)
+	go service.RunRefundRetrier(time.Minute)
+	go queue.ConsumeRestaurantResponses(service.HandleRestaurantResponse)
+	go service.RunRestaurantResponseSweeper(30 * time.Second)
+	go queue.ConsumeDeliveryOfferResponses(service.HandleOfferResponse)
+	go service.RunDispatcher(5 * time.Second)
+	go queue.ConsumeOrderEvents("order_eta_updates", []string{
+		model.EventOrderCreated,
+		model.EventOrderStatusChanged,
+		model.EventOrderAgentAssigned,
+	}, service.HandleOrderEventForETA)
+	go queue.ConsumeAgentLocations("order_eta_locations", service.HandleAgentLocationForETA)
+	go service.RunOutboxRelay(time.Second)
+
 	r := gin.Default()
 	r.Use(gin.Logger())
 
@@ -11,6 +11,11 @@ import (
 )
 
 var OrderCollection *mongo.Collection
+var CounterCollection *mongo.Collection
+var IdempotencyCollection *mongo.Collection
+var PaymentCollection *mongo.Collection
+var RefundCollection *mongo.Collection
+var OutboxCollection *mongo.Collection
 
 func ConnectDB() (*mongo.Client, error) {
 	mongo_uri := os.Getenv("DATABASE_URL")
@@ -29,6 +34,15 @@ func ConnectDB() (*mongo.Client, error) {
 	}
 
 	OrderCollection = client.Database("customDish").Collection("orders")
+	CounterCollection = client.Database("customDish").Collection("counters")
+	IdempotencyCollection = client.Database("customDish").Collection("idempotencyKeys")
+	PaymentCollection = client.Database("customDish").Collection("payments")
+	RefundCollection = client.Database("customDish").Collection("refunds")
+	OutboxCollection = client.Database("customDish").Collection("outbox")
+
+	if err := EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
 
 	log.Println("Connected to MongoDB!")
 	return client, nil
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// EnsureIndexes creates the indexes order-services relies on for correctness.
+func EnsureIndexes(ctx context.Context) error {
+	_, err := OrderCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "orderId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "restaurantResponseBy", Value: 1}}},
+		{Keys: bson.D{{Key: "restaurantId", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "deliveryAgentId", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "dispatch.status", Value: 1}, {Key: "dispatch.nextSearchAt", Value: 1}}},
+		{Keys: bson.D{{Key: "dispatch.status", Value: 1}, {Key: "dispatch.offerExpiresAt", Value: 1}}},
+		// Keyset pagination for GetAllOrders: each party's orders by every
+		// listing sort, with orderId as the tiebreaker.
+		{Keys: bson.D{{Key: "customerId", Value: 1}, {Key: "orderTime", Value: 1}, {Key: "orderId", Value: 1}}},
+		{Keys: bson.D{{Key: "restaurantId", Value: 1}, {Key: "orderTime", Value: 1}, {Key: "orderId", Value: 1}}},
+		{Keys: bson.D{{Key: "deliveryAgentId", Value: 1}, {Key: "orderTime", Value: 1}, {Key: "orderId", Value: 1}}},
+		{Keys: bson.D{{Key: "customerId", Value: 1}, {Key: "price", Value: 1}, {Key: "orderId", Value: 1}}},
+		{Keys: bson.D{{Key: "restaurantId", Value: 1}, {Key: "price", Value: 1}, {Key: "orderId", Value: 1}}},
+		{Keys: bson.D{{Key: "deliveryAgentId", Value: 1}, {Key: "price", Value: 1}, {Key: "orderId", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = IdempotencyCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "scope", Value: 1}, {Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "createdAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(IdempotencyKeyTTL().Seconds())),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = PaymentCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "paymentId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "orderId", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "expiresAt", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = RefundCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "refundId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "orderId", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = OutboxCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "eventId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "sentAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(OutboxRetention().Seconds())),
+		},
+	})
+	return err
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Settings are read from the environment on use so values from .env are
+// picked up.
+
+func TaxRate() float32 {
+	return envFloat("ORDER_TAX_RATE", 0.05)
+}
+
+func DeliveryFee() float32 {
+	return envFloat("ORDER_DELIVERY_FEE", 30)
+}
+
+func PlatformFee() float32 {
+	return envFloat("ORDER_PLATFORM_FEE", 5)
+}
+
+// IdempotencyKeyTTL is how long a stored Idempotency-Key response is replayed.
+func IdempotencyKeyTTL() time.Duration {
+	return time.Duration(envFloat("IDEMPOTENCY_KEY_TTL_HOURS", 24) * float32(time.Hour))
+}
+
+// OrderEnrichTimeout bounds the lookups that add dish, restaurant and agent
+// details to orders. Orders are returned without the details that did not
+// arrive in time.
+func OrderEnrichTimeout() time.Duration {
+	return time.Duration(envFloat("ORDER_ENRICH_TIMEOUT_SECONDS", 3) * float32(time.Second))
+}
+
+// PaymentExpiry is how long a prepaid order waits for payment before it is cancelled.
+func PaymentExpiry() time.Duration {
+	return time.Duration(envFloat("PAYMENT_EXPIRY_MINUTES", 15) * float32(time.Minute))
+}
+
+// RefundMaxAttempts is how many times a failed refund is retried before it
+// is left for manual follow-up.
+func RefundMaxAttempts() int {
+	return int(envFloat("REFUND_MAX_ATTEMPTS", 5))
+}
+
+// RefundProcessingTimeout is how long a refund waits for the payment
+// service's answer before it is sent again.
+func RefundProcessingTimeout() time.Duration {
+	return time.Duration(envFloat("REFUND_PROCESSING_TIMEOUT_MINUTES", 30) * float32(time.Minute))
+}
+
+// RestaurantResponseTimeout is how long a restaurant has to accept a new
+// order before it is rejected automatically.
+func RestaurantResponseTimeout() time.Duration {
+	return time.Duration(envFloat("RESTAURANT_RESPONSE_MINUTES", 5) * float32(time.Minute))
+}
+
+// DispatchRadiusKm is how far from the restaurant delivery agents are
+// considered for an order.
+func DispatchRadiusKm() float64 {
+	return float64(envFloat("DISPATCH_RADIUS_KM", 5))
+}
+
+// DispatchLoadPenaltyKm is how much farther away an idle agent may be and
+// still rank ahead of an agent carrying one more active order.
+func DispatchLoadPenaltyKm() float64 {
+	return float64(envFloat("DISPATCH_LOAD_PENALTY_KM", 1.5))
+}
+
+// DispatchOfferTimeout is how long an agent has to accept an offered order.
+func DispatchOfferTimeout() time.Duration {
+	return time.Duration(envFloat("DISPATCH_OFFER_SECONDS", 30) * float32(time.Second))
+}
+
+// DispatchRetryDelay is how long to wait before searching again when no
+// agent could be offered the order.
+func DispatchRetryDelay() time.Duration {
+	return time.Duration(envFloat("DISPATCH_RETRY_SECONDS", 30) * float32(time.Second))
+}
+
+// DispatchMaxRounds is how many empty searches are made before dispatch
+// gives up on an order.
+func DispatchMaxRounds() int {
+	return int(envFloat("DISPATCH_MAX_ROUNDS", 20))
+}
+
+// AgentSpeedKmh is the average speed used to estimate travel times.
+func AgentSpeedKmh() float64 {
+	return float64(envFloat("ETA_AGENT_SPEED_KMH", 20))
+}
+
+// QueueMinutesPerOrder is how much each order already being prepared at a
+// restaurant delays the next one.
+func QueueMinutesPerOrder() float64 {
+	return float64(envFloat("ETA_QUEUE_MINUTES_PER_ORDER", 3))
+}
+
+// PickupAllowance covers finding an agent and getting them to the
+// restaurant while no agent is assigned yet.
+func PickupAllowance() time.Duration {
+	return time.Duration(envFloat("ETA_PICKUP_ALLOWANCE_MINUTES", 8) * float32(time.Minute))
+}
+
+// DefaultDeliveryLeg is the restaurant to customer travel time assumed when
+// the order has no delivery location.
+func DefaultDeliveryLeg() time.Duration {
+	return time.Duration(envFloat("ETA_DEFAULT_DELIVERY_MINUTES", 20) * float32(time.Minute))
+}
+
+// ETALocationInterval is the least time between two location driven ETA
+// updates of the same order.
+func ETALocationInterval() time.Duration {
+	return time.Duration(envFloat("ETA_LOCATION_INTERVAL_SECONDS", 30) * float32(time.Second))
+}
+
+// OutboxRetention is how long published outbox events are kept before they
+// are removed.
+func OutboxRetention() time.Duration {
+	return time.Duration(envFloat("OUTBOX_RETENTION_HOURS", 72) * float32(time.Hour))
+}
+
+// OutboxMaxAttempts is how many times the relay tries to publish an outbox
+// event before it parks it for manual follow-up.
+func OutboxMaxAttempts() int {
+	return int(envFloat("OUTBOX_MAX_ATTEMPTS", 10))
+}
+
+func envFloat(key string, fallback float32) float32 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(value, 32)
+	if err != nil {
+		log.Printf("Invalid %s %q, using %v\n", key, value, fallback)
+		return fallback
+	}
+	return float32(parsed)
+}
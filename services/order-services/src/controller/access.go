@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"order-service/src/model"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// canViewOrder reports whether the logged in customer, restaurant or delivery
+// agent is a party to order.
+func canViewOrder(c *gin.Context, order *model.Order) bool {
+	if id, ok := c.Get("customerId"); ok {
+		return matchesID(id, order.CustomerID)
+	}
+	if id, ok := c.Get("restaurantId"); ok {
+		return matchesID(id, order.RestaurantID)
+	}
+	if id, ok := c.Get("deliveryAgentId"); ok {
+		return order.DeliveryAgentID != nil && matchesID(id, *order.DeliveryAgentID)
+	}
+	return false
+}
+
+func matchesID(value any, id primitive.ObjectID) bool {
+	str, ok := value.(string)
+	return ok && str == id.Hex()
+}
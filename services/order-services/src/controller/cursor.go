@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"order-service/src/model"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var (
+	errInvalidSort   = errors.New("invalid sort")
+	errInvalidCursor = errors.New("invalid cursor")
+)
+
+// pageCursor is what an opaque cursor token carries: the sort it was issued
+// for and the sort value and order id of the last order on the previous page.
+type pageCursor struct {
+	Sort    string          `json:"s"`
+	Value   json.RawMessage `json:"v"`
+	OrderId int             `json:"id"`
+}
+
+func encodeCursor(cursor pageCursor) string {
+	body, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(body)
+}
+
+func decodeCursor(token string) (pageCursor, error) {
+	var cursor pageCursor
+	body, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, errInvalidCursor
+	}
+	if err := json.Unmarshal(body, &cursor); err != nil {
+		return cursor, errInvalidCursor
+	}
+	return cursor, nil
+}
+
+// orderSortFields maps each sort option to its field and default direction.
+// Ties are broken on orderId so the order is total and stable.
+var orderSortFields = map[string]struct {
+	field     string
+	direction int
+}{
+	"orderTime": {"orderTime", -1},
+	"price":     {"price", 1},
+}
+
+type orderSort struct {
+	name      string
+	field     string
+	direction int
+}
+
+// parseOrderSort reads the sort and order query parameters. Without sort,
+// orders are listed newest first.
+func parseOrderSort(c *gin.Context) (orderSort, error) {
+	name := c.DefaultQuery("sort", "orderTime")
+	spec, ok := orderSortFields[name]
+	if !ok {
+		return orderSort{}, errInvalidSort
+	}
+	sort := orderSort{name: name, field: spec.field, direction: spec.direction}
+	switch strings.ToLower(c.Query("order")) {
+	case "":
+	case "asc":
+		sort.direction = 1
+	case "desc":
+		sort.direction = -1
+	default:
+		return orderSort{}, errInvalidSort
+	}
+	return sort, nil
+}
+
+// key identifies the sort inside a cursor so a token cannot be replayed
+// against a different ordering.
+func (s orderSort) key() string {
+	if s.direction < 0 {
+		return s.name + ":desc"
+	}
+	return s.name + ":asc"
+}
+
+func (s orderSort) options() bson.D {
+	return bson.D{{Key: s.field, Value: s.direction}, {Key: "orderId", Value: s.direction}}
+}
+
+// after returns the filter selecting the orders that follow cursor.
+func (s orderSort) after(cursor pageCursor) (bson.M, error) {
+	if cursor.Sort != s.key() {
+		return nil, errInvalidCursor
+	}
+	var value any
+	switch s.field {
+	case "orderTime":
+		var orderTime time.Time
+		if err := json.Unmarshal(cursor.Value, &orderTime); err != nil {
+			return nil, errInvalidCursor
+		}
+		value = orderTime
+	default:
+		var price float64
+		if err := json.Unmarshal(cursor.Value, &price); err != nil {
+			return nil, errInvalidCursor
+		}
+		value = price
+	}
+	op := "$gt"
+	if s.direction < 0 {
+		op = "$lt"
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{s.field: bson.M{op: value}},
+		bson.M{s.field: value, "orderId": bson.M{op: cursor.OrderId}},
+	}}, nil
+}
+
+// cursorAfter returns the token for the page following order.
+func (s orderSort) cursorAfter(order model.Order) string {
+	var value any
+	switch s.field {
+	case "orderTime":
+		value = order.OrderTime
+	default:
+		// Widened exactly so the value compares equal to the stored double.
+		value = float64(order.TotalPrice)
+	}
+	encoded, _ := json.Marshal(value)
+	return encodeCursor(pageCursor{Sort: s.key(), Value: encoded, OrderId: order.OrderId})
+}
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"encoding/base64"
+	"net/http/httptest"
+	"order-service/src/model"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestParseOrderSort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		query   string
+		want    orderSort
+		wantErr bool
+	}{
+		{"", orderSort{name: "orderTime", field: "orderTime", direction: -1}, false},
+		{"sort=price", orderSort{name: "price", field: "price", direction: 1}, false},
+		{"sort=price&order=DESC", orderSort{name: "price", field: "price", direction: -1}, false},
+		{"sort=orderTime&order=asc", orderSort{name: "orderTime", field: "orderTime", direction: 1}, false},
+		{"sort=status", orderSort{}, true},
+		{"sort=price&order=up", orderSort{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/?"+tt.query, nil)
+			got, err := parseOrderSort(c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseOrderSort() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseOrderSort() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderCursorRoundTrip(t *testing.T) {
+	orderTime := time.Date(2024, 3, 10, 18, 30, 15, 123000000, time.UTC)
+	order := model.Order{OrderId: 100042, OrderTime: orderTime, TotalPrice: 245.5}
+
+	tests := []struct {
+		name string
+		sort orderSort
+		want bson.M
+	}{
+		{
+			name: "newest first",
+			sort: orderSort{name: "orderTime", field: "orderTime", direction: -1},
+			want: bson.M{"$or": bson.A{
+				bson.M{"orderTime": bson.M{"$lt": orderTime}},
+				bson.M{"orderTime": orderTime, "orderId": bson.M{"$lt": 100042}},
+			}},
+		},
+		{
+			name: "cheapest first",
+			sort: orderSort{name: "price", field: "price", direction: 1},
+			want: bson.M{"$or": bson.A{
+				bson.M{"price": bson.M{"$gt": 245.5}},
+				bson.M{"price": 245.5, "orderId": bson.M{"$gt": 100042}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := decodeCursor(tt.sort.cursorAfter(order))
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+			got, err := tt.sort.after(cursor)
+			if err != nil {
+				t.Fatalf("after() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("after() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderCursorRejected(t *testing.T) {
+	newest := orderSort{name: "orderTime", field: "orderTime", direction: -1}
+	oldest := orderSort{name: "orderTime", field: "orderTime", direction: 1}
+	token := newest.cursorAfter(model.Order{OrderId: 100042, OrderTime: time.Now()})
+
+	cursor, err := decodeCursor(token)
+	if err != nil {
+		t.Fatalf("decodeCursor() error = %v", err)
+	}
+	if _, err := oldest.after(cursor); err != errInvalidCursor {
+		t.Errorf("after() with another sort's cursor error = %v, want errInvalidCursor", err)
+	}
+
+	for _, token := range []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("not json")),
+	} {
+		if _, err := decodeCursor(token); err != errInvalidCursor {
+			t.Errorf("decodeCursor(%q) error = %v, want errInvalidCursor", token, err)
+		}
+	}
+
+	badValue := pageCursor{Sort: newest.key(), Value: []byte(`"yesterday"`), OrderId: 1}
+	if _, err := newest.after(badValue); err != errInvalidCursor {
+		t.Errorf("after() with an unreadable value error = %v, want errInvalidCursor", err)
+	}
+}
@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"order-service/src/config"
+	"order-service/src/model"
+	"order-service/src/queue"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// orderRefs holds the distinct entity ids referenced by a page of orders.
+type orderRefs struct {
+	dishes      []primitive.ObjectID
+	restaurants []primitive.ObjectID
+	agents      []primitive.ObjectID
+}
+
+func collectOrderRefs(orders []model.Order) orderRefs {
+	var refs orderRefs
+	seen := map[primitive.ObjectID]bool{}
+	add := func(ids *[]primitive.ObjectID, id primitive.ObjectID) {
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		*ids = append(*ids, id)
+	}
+	for _, order := range orders {
+		add(&refs.restaurants, order.RestaurantID)
+		if order.DeliveryAgentID != nil {
+			add(&refs.agents, *order.DeliveryAgentID)
+		}
+		for _, o := range order.Orders {
+			if o.ComboID == nil {
+				add(&refs.dishes, o.DishID)
+			}
+			for _, component := range o.Components {
+				add(&refs.dishes, component.DishID)
+			}
+		}
+	}
+	return refs
+}
+
+// enrichOrders fetches the dishes, restaurants and delivery agents referenced
+// by orders and assembles OrderDetails. Details are best effort: lookups are
+// cut off after config.OrderEnrichTimeout and whatever could not be fetched
+// is left empty rather than failing the request.
+func enrichOrders(ctx context.Context, orders []model.Order) []OrderDetails {
+	refs := collectOrderRefs(orders)
+	ctx, cancel := context.WithTimeout(ctx, config.OrderEnrichTimeout())
+	defer cancel()
+
+	var (
+		wg          sync.WaitGroup
+		dishes      = &queue.DishDetailsBatch{}
+		restaurants = &queue.EachDetails[queue.RestaurantDetails]{}
+		agents      = &queue.EachDetails[queue.DeliveryAgentDetails]{}
+	)
+	if len(refs.dishes) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			batch, err := queue.GetDishDetailsBatch(ctx, refs.dishes)
+			if err != nil {
+				log.Println("Error fetching dish details:", err)
+				return
+			}
+			dishes = batch
+		}()
+	}
+	if len(refs.restaurants) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			restaurants = queue.GetEachRestaurantDetails(ctx, refs.restaurants)
+		}()
+	}
+	if len(refs.agents) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			agents = queue.GetEachAgentDetails(ctx, refs.agents)
+		}()
+	}
+	wg.Wait()
+
+	logMissing("dishes", dishes.Missing)
+	logMissing("restaurants", restaurants.Missing)
+	logMissing("delivery agents", agents.Missing)
+
+	enriched := make([]OrderDetails, 0, len(orders))
+	for _, order := range orders {
+		var detailedOrders []SingleOrderDetails
+		for _, o := range order.Orders {
+			detail := SingleOrderDetails{
+				Price:          o.Price,
+				UnitPrice:      o.UnitPrice,
+				Surcharge:      o.Surcharge,
+				VariantID:      o.VariantID,
+				VariantName:    o.VariantName,
+				ComboID:        o.ComboID,
+				ComboName:      o.ComboName,
+				Quantity:       o.Quantity,
+				Customizations: o.Customizations,
+			}
+			if o.ComboID == nil {
+				detail.Dish = dishes.Items[o.DishID.Hex()]
+			}
+			for _, component := range o.Components {
+				detail.Components = append(detail.Components, ComboComponentDetails{
+					Dish:        dishes.Items[component.DishID.Hex()],
+					VariantName: component.VariantName,
+					Quantity:    component.Quantity,
+				})
+			}
+			detailedOrders = append(detailedOrders, detail)
+		}
+
+		var notes []OrderNoteDetails
+		for _, note := range order.Notes {
+			notes = append(notes, OrderNoteDetails{Text: note.Text, CreatedAt: note.CreatedAt})
+		}
+
+		var deliveryAgent queue.DeliveryAgentDetails
+		if order.DeliveryAgentID != nil {
+			deliveryAgent = agents.Items[order.DeliveryAgentID.Hex()]
+		}
+
+		enriched = append(enriched, OrderDetails{
+			OrderId:         order.OrderId,
+			CustomerID:      order.CustomerID,
+			Orders:          detailedOrders,
+			TotalPrice:      order.TotalPrice,
+			PaymentMode:     order.PaymentMode,
+			Status:          order.Status,
+			OrderTime:       order.OrderTime,
+			FulfillmentTime: order.FulfillmentTime,
+			DeliveryTime:    order.DeliveryTime,
+			Discount:        order.Discount,
+			CouponCode:      order.CouponCode,
+			DeliveryAgent:   deliveryAgent,
+			Restaurant:      restaurants.Items[order.RestaurantID.Hex()],
+			Pricing: PriceBreakdown{
+				Subtotal: order.Pricing.Subtotal,
+				Discount: order.Pricing.Discount,
+				Taxes:    order.Pricing.Taxes,
+				Fees:     order.Pricing.Fees,
+				Total:    order.Pricing.Total,
+			},
+			Notes:                 notes,
+			EstimatedDeliveryTime: order.EstimatedDeliveryTime,
+		})
+	}
+	return enriched
+}
+
+func logMissing(entity string, ids []string) {
+	if len(ids) > 0 {
+		log.Printf("Could not resolve %s: %v\n", entity, ids)
+	}
+}
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"order-service/src/queue"
+	"order-service/src/repository"
+)
+
+// rpcErrorStatus maps an error from the queue package to an HTTP status.
+func rpcErrorStatus(err error) int {
+	var remoteErr *queue.RemoteError
+	switch {
+	case errors.Is(err, queue.ErrTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, queue.ErrNotConnected), errors.Is(err, queue.ErrClosed):
+		return http.StatusServiceUnavailable
+	case errors.As(err, &remoteErr):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// transitionErrorStatus maps an error from repository.TransitionStatus to an HTTP status.
+func transitionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrIllegalTransition):
+		return http.StatusBadRequest
+	case errors.Is(err, repository.ErrStatusConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func transitionErrorMessage(err error, from string, to string) string {
+	switch {
+	case errors.Is(err, repository.ErrIllegalTransition):
+		return "Order cannot move from " + from + " to " + to
+	case errors.Is(err, repository.ErrStatusConflict):
+		return "Order status was updated by someone else, please retry"
+	default:
+		return "Failed to update status"
+	}
+}
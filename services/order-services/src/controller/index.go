@@ -2,13 +2,17 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"log"
-	"math/rand"
 	"net/http"
 	"order-service/src/config"
 	"order-service/src/model"
 	"order-service/src/queue"
+	"order-service/src/repository"
+	"order-service/src/service"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -18,21 +22,18 @@ import (
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
+// SingleOrder is a line item as submitted. VariantID is required for dishes
+// sold in variants. Customizations are keyed by the dish's customization
+// option keys and checked against the dish by dish-service. A combo line
+// item sets ComboID and Selections, picked option ids keyed by group key,
+// instead of a dish.
 type SingleOrder struct {
-	
-	Price          float32            `json:"price"`
-	DishID         primitive.ObjectID `json:"dishId"`
-	Quantity       int                `json:"quantity"`
-	Customizations Customizations     `json:"customizations"`
-}
-
-type Customizations struct {
-	Salty       int  `json:"salty,omitempty"`
-	Spicy       int  `json:"spicy,omitempty"`
-	ExtraCheese int  `json:"extraCheese,omitempty"`
-	Sweetness   int  `json:"sweetness,omitempty"`
-	Onion       bool `json:"onion,omitempty"`
-	Garlic      bool `json:"garlic,omitempty"`
+	DishID         primitive.ObjectID         `json:"dishId"`
+	VariantID      *primitive.ObjectID        `json:"variantId,omitempty"`
+	ComboID        *primitive.ObjectID        `json:"comboId,omitempty"`
+	Selections     map[string][]primitive.ObjectID `json:"selections,omitempty"`
+	Quantity       int                        `json:"quantity"`
+	Customizations map[string]json.RawMessage `json:"customizations"`
 }
 
 type CreateOrderInput struct {
@@ -40,6 +41,13 @@ type CreateOrderInput struct {
 	Orders      []SingleOrder `json:"singleOrder"`
 	PaymentMode string        `json:"paymentMode"`
 	CouponCode  *string       `json:"couponCode,omitempty"`
+	Note        *string       `json:"note,omitempty"`
+	DeliveryLocation *Location `json:"deliveryLocation,omitempty"`
+}
+
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
 }
 
 type DeliveryAgentLocation struct {
@@ -51,11 +59,24 @@ type DeliveryAgentLocation struct {
 
 type SingleOrderDetails struct {
 	Price          float32            `json:"price"`
+	UnitPrice      float32            `json:"unitPrice"`
+	Surcharge      float32            `json:"surcharge"`
 	Dish           queue.DishDetails `json:"dish"`
+	VariantID      *primitive.ObjectID `json:"variantId,omitempty"`
+	VariantName    string             `json:"variantName,omitempty"`
+	ComboID        *primitive.ObjectID `json:"comboId,omitempty"`
+	ComboName      string             `json:"comboName,omitempty"`
+	Components     []ComboComponentDetails `json:"components,omitempty"`
 	Quantity       int                `json:"quantity"`
 	Customizations model.Customizations     `json:"customizations"`
 }
 
+type ComboComponentDetails struct {
+	Dish        queue.DishDetails `json:"dish"`
+	VariantName string            `json:"variantName,omitempty"`
+	Quantity    int               `json:"quantity"`
+}
+
 type OrderDetails struct {
 	OrderId         int                `json:"orderId"`
 	CustomerID      primitive.ObjectID `json:"customerId"`
@@ -70,11 +91,41 @@ type OrderDetails struct {
 	CouponCode      *string            `json:"couponCode,omitempty"`
 	DeliveryAgent 	queue.DeliveryAgentDetails	`json:"deliveryAgent"`
 	Restaurant   queue.RestaurantDetails `json:"restaurant"`
+	Pricing      PriceBreakdown          `json:"pricing"`
+	Notes        []OrderNoteDetails      `json:"notes,omitempty"`
+	EstimatedDeliveryTime *time.Time     `json:"estimatedDeliveryTime,omitempty"`
+}
+
+type OrderNoteDetails struct {
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type PriceBreakdown struct {
+	Subtotal float32 `json:"subtotal"`
+	Discount float32 `json:"discount"`
+	Taxes    float32 `json:"taxes"`
+	Fees     float32 `json:"fees"`
+	Total    float32 `json:"total"`
 }
 
 
-func GenerateRandomOrderID() int {
-	return rand.Intn(900000) + 100000
+type StatusChangeDetails struct {
+	From      string              `json:"from"`
+	To        string              `json:"to"`
+	Actor     model.Actor         `json:"actor"`
+	ActorID   *primitive.ObjectID `json:"actorId,omitempty"`
+	ChangedAt time.Time           `json:"changedAt"`
+}
+
+// parseOrderID reads the public order number from the :orderId route param.
+func parseOrderID(c *gin.Context) (int, bool) {
+	orderIdInt, err := strconv.Atoi(c.Param("orderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return 0, false
+	}
+	return orderIdInt, true
 }
 
 func CreateOrder(client *mongo.Client, c *gin.Context) {
@@ -100,69 +151,104 @@ func CreateOrder(client *mongo.Client, c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	// create orders array and calculate total price
-	var orders []model.SingleOrder
-	var totalPrice float32
-	for _, o := range input.Orders {
-		orders = append(orders, model.SingleOrder{
-			
-			Price:        o.Price,
-			DishID:       o.DishID,
-			Quantity:     o.Quantity,
-			Customizations: model.Customizations{
-				Salty:       o.Customizations.Salty,
-				Spicy:       o.Customizations.Spicy,
-				ExtraCheese: o.Customizations.ExtraCheese,
-				Sweetness:   o.Customizations.Sweetness,
-				Onion:       o.Customizations.Onion,
-				Garlic:      o.Customizations.Garlic,
-			},
-		})
-		totalPrice += o.Price
+	if loc := input.DeliveryLocation; loc != nil && (loc.Latitude < -90 || loc.Latitude > 90 || loc.Longitude < -180 || loc.Longitude > 180) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery location"})
+		return
+	}
+	paymentMode, ok := model.NormalizePaymentMode(input.PaymentMode)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment mode, expected one of COD, card, wallet or UPI"})
+		return
+	}
+	// price the line items from the dish catalog
+	orders, subtotal, err := priceLineItems(c.Request.Context(), input.RestaurantID, input.Orders)
+	if err != nil {
+		var pricingErr *PricingError
+		if errors.As(err, &pricingErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": pricingErr.Message})
+			return
+		}
+		c.JSON(rpcErrorStatus(err), gin.H{"error": "Failed to price order: " + err.Error()})
+		return
 	}
 	// check for discount if coupon code exists
 	var discount float32 = 0.0
 	if input.CouponCode != nil {
-		discount, err = queue.GetDiscountPrice(*input.CouponCode)
+		discount, err = queue.GetDiscountPrice(c.Request.Context(), *input.CouponCode)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(rpcErrorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
-		totalPrice -= discount
 	}
-	// generate random id for order
-	orderId := GenerateRandomOrderID()
+	pricing := buildPriceBreakdown(subtotal, discount)
+	// hold the stock for the order, given back if it is cancelled
+	reservationId := service.NewStockReservationId()
+	if err := service.ReserveStock(c.Request.Context(), reservationId, orders); err != nil {
+		var stockErr *service.StockError
+		if errors.As(err, &stockErr) {
+			c.JSON(http.StatusConflict, gin.H{"error": stockErr.Message})
+			return
+		}
+		// dish-service may still take the stock after the call gave up, so
+		// release the reservation in case it does
+		if err := service.ReleaseStock(context.TODO(), reservationId, "stock reservation did not complete"); err != nil {
+			log.Println("Error releasing stock:", err)
+		}
+		c.JSON(rpcErrorStatus(err), gin.H{"error": "Failed to reserve stock: " + err.Error()})
+		return
+	}
 	// create entry in database
 	newOrder := model.Order{
-		OrderId:     orderId,
 		RestaurantID: input.RestaurantID,
 		CustomerID:  customerObjectID,
-		TotalPrice:  totalPrice,
+		TotalPrice:  pricing.Total,
 		Orders:      orders,
-		PaymentMode: input.PaymentMode,
+		PaymentMode: paymentMode,
+		PaymentId:   service.NewPaymentId(),
+		PaymentStatus: model.PaymentStatusPending,
 		CouponCode:  input.CouponCode,
 		Status:      model.StatusPending,
-		Discount:    discount,
+		Discount:    pricing.Discount,
 		OrderTime:   time.Now(),
+		Pricing:     pricing,
+		StockReservationId: reservationId,
+	}
+	if input.DeliveryLocation != nil {
+		newOrder.DeliveryLocation = &model.GeoPoint{
+			Latitude:  input.DeliveryLocation.Latitude,
+			Longitude: input.DeliveryLocation.Longitude,
+		}
 	}
-	result, err := config.OrderCollection.InsertOne(context.TODO(), newOrder)
+	if input.Note != nil && strings.TrimSpace(*input.Note) != "" {
+		newOrder.Notes = []model.OrderNote{{Text: strings.TrimSpace(*input.Note), CreatedAt: newOrder.OrderTime}}
+	}
+	newOrder.StatusHistory = []model.StatusChange{{
+		To:        model.StatusPending,
+		Actor:     model.ActorCustomer,
+		ActorID:   &customerObjectID,
+		ChangedAt: newOrder.OrderTime,
+	}}
+	payment := service.NewPayment(&newOrder)
+	err = repository.InsertOrder(context.TODO(), &newOrder, payment)
 	if err != nil {
 		log.Println("Error creating Order:", err)
+		if err := service.ReleaseStock(context.TODO(), reservationId, "order was not created"); err != nil {
+			log.Println("Error releasing stock:", err)
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create Order"})
 		return
 	}
-	// send message to the payment service
-	data, err := queue.InitiatePayment()
-	if err != nil {
-		log.Println("Error initiating payment:", err)
-	}
-	log.Println(data)
-	c.JSON(http.StatusCreated, gin.H{"message": "Order created successfully!", "orderId": result.InsertedID})
+	c.JSON(http.StatusCreated, gin.H{
+		"message":          "Order created successfully!",
+		"orderId":          newOrder.OrderId,
+		"price":            pricing.Total,
+		"paymentId":        payment.PaymentId,
+		"paymentStatus":    payment.Status,
+		"paymentExpiresAt": payment.ExpiresAt,
+	})
 }
 
 func CancelOrder(client *mongo.Client, c *gin.Context) {
-	// get order id from params
-	orderId := c.Param("id")
 	customerId, exists := c.Get("customerId")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
@@ -178,8 +264,12 @@ func CancelOrder(client *mongo.Client, c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	orderIdInt, ok := parseOrderID(c)
+	if !ok {
+		return
+	}
 
-	filter := bson.M{"orderId": orderId}
+	filter := bson.M{"orderId": orderIdInt}
 	var order model.Order
 	err = config.OrderCollection.FindOne(context.TODO(), filter).Decode(&order)
 	if err != nil {
@@ -190,34 +280,20 @@ func CancelOrder(client *mongo.Client, c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
-	if order.Status == model.StatusOutForDelivery || order.Status == model.StatusBeingPrepared {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Order cannot be cancelled at this stage"})
-		return
-	}
 	// cancel the order
-	//TODO: send message to the restaurant service.
-	message, err := queue.SendMessageToRestaurant(order.RestaurantID)
+	err = repository.TransitionStatus(context.TODO(), &order, model.StatusCancelled, model.ActorCustomer, &customerObjectID)
 	if err != nil {
-		log.Println("Error sending message to restaurant:", err)
+		c.JSON(transitionErrorStatus(err), gin.H{"error": transitionErrorMessage(err, order.Status, model.StatusCancelled)})
+		return
+	}
+	// refund the customer if the order was paid online
+	if err := service.RefundCancelledOrder(context.TODO(), &order, "cancelled by customer", model.ActorCustomer); err != nil {
+		log.Println("Error refunding cancelled order:", err)
 	}
-	log.Println(message)
-	//TODO: send message to the payment service.
-	message, err = queue.SendMessageToPaymentService()
-	if err != nil {
-		log.Println("Error sending message to payment service:", err)
-	}
-	log.Println(message)
-	update := bson.M{"status": model.StatusCancelled}
-	updatedResult := config.OrderCollection.FindOneAndUpdate(context.TODO(), filter, bson.M{"$set": update})
-	if updatedResult.Err() != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel order"})
-        return
-    }
 	c.JSON(http.StatusOK, gin.H{"message": "Order cancelled successfully!"})
 }
 
 func UpdateOrderStatus(client *mongo.Client, c *gin.Context) {
-	orderId := c.Param("orderId")
 	status := c.Param("status")
 	restaurantId, restaurantExists := c.Get("restaurantId")
 	deliveryAgentId, deliveryAgentExists := c.Get("deliveryAgentId")
@@ -225,17 +301,23 @@ func UpdateOrderStatus(client *mongo.Client, c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
-	orderIdInt, err := strconv.Atoi(orderId)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+	if !model.IsValidStatus(status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+	orderIdInt, ok := parseOrderID(c)
+	if !ok {
 		return
 	}
 	var order model.Order
-	err = config.OrderCollection.FindOne(context.TODO(), bson.M{"orderId": orderIdInt}).Decode(&order)
+	err := config.OrderCollection.FindOne(context.TODO(), bson.M{"orderId": orderIdInt}).Decode(&order)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 		return
 	}
+
+	var actor model.Actor
+	var actorID primitive.ObjectID
 	if restaurantExists {
 		restaurantIDStr, ok := restaurantId.(string)
 		if !ok {
@@ -247,23 +329,12 @@ func UpdateOrderStatus(client *mongo.Client, c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		if status != model.StatusBeingPrepared && status != model.StatusOutForDelivery {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
-			return
-		}
 		if restaurantObjectID != order.RestaurantID {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			return 
 		}
-		updatedStatus := config.OrderCollection.FindOneAndUpdate(context.TODO(),bson.M{"orderId": orderId}, bson.M{"$set": bson.M{"status": status}})
-		if updatedStatus.Err() != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update status"})
-			return
-		}
-		c.JSON(http.StatusOK, gin.H{"message": "Status updated successfully!"})
-		return
-	}
-	if deliveryAgentExists {
+		actor, actorID = model.ActorRestaurant, restaurantObjectID
+	} else {
 		deliveryAgentIdStr, ok := deliveryAgentId.(string)
 		if !ok {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid delivery agent ID"})
@@ -274,94 +345,104 @@ func UpdateOrderStatus(client *mongo.Client, c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		if status != model.StatusOutForDelivery && status != model.StatusDelivered {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
-			return
-		}
-		if deliveryAgentObjectId != *order.DeliveryAgentID {
+		if order.DeliveryAgentID == nil || deliveryAgentObjectId != *order.DeliveryAgentID {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			return 
 		}
-		updatedStatus := config.OrderCollection.FindOneAndUpdate(context.TODO(),bson.M{"orderId": orderId}, bson.M{"$set": status})
-		if updatedStatus.Err() != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update status"})
-			return
+		actor, actorID = model.ActorDeliveryAgent, deliveryAgentObjectId
+	}
+
+	from := order.Status
+	if actor == model.ActorRestaurant && status == model.StatusConfirmed && order.RestaurantNotifiedAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Order is awaiting payment"})
+		return
+	}
+	if err := repository.TransitionStatus(context.TODO(), &order, status, actor, &actorID); err != nil {
+		if !errors.Is(err, repository.ErrIllegalTransition) && !errors.Is(err, repository.ErrStatusConflict) {
+			log.Println("Error updating order status:", err)
 		}
-		c.JSON(http.StatusOK, gin.H{"message": "Status updated successfully!"})
+		c.JSON(transitionErrorStatus(err), gin.H{"error": transitionErrorMessage(err, from, status)})
 		return
 	}
+	if status == model.StatusCancelled {
+		if err := service.RefundCancelledOrder(context.TODO(), &order, "cancelled by restaurant", actor); err != nil {
+			log.Println("Error refunding cancelled order:", err)
+		}
+	}
+	if status == model.StatusReadyForPickup {
+		// look for an agent now rather than on the dispatcher's next run
+		go func(order model.Order) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := service.DispatchOrder(ctx, &order); err != nil {
+				log.Println("Error dispatching order:", err)
+			}
+		}(order)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Status updated successfully!"})
 }
 
-func GetOrder(client *mongo.Client, c *gin.Context) {
-	orderId := c.Param("orderId")
-	orderIdInt, err := strconv.Atoi(orderId)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+func GetOrderHistory(client *mongo.Client, c *gin.Context) {
+	orderIdInt, ok := parseOrderID(c)
+	if !ok {
 		return
 	}
 
 	var order model.Order
-	err = config.OrderCollection.FindOne(context.TODO(), bson.M{"orderId": orderIdInt}).Decode(&order)
+	err := config.OrderCollection.FindOne(context.TODO(), bson.M{"orderId": orderIdInt}).Decode(&order)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 		return
 	}
+	if !canViewOrder(c, &order) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
 
-	// Fetch dish details for each order item
-	var enrichedOrders []SingleOrderDetails
-	for _, o := range order.Orders {
-		dishDetails, err := queue.GetDishDetails(o.DishID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dish details: " + err.Error()})
-			return
-		}
-
-		enrichedOrders = append(enrichedOrders, SingleOrderDetails{
-			Price:          o.Price,
-			Dish:           *dishDetails,
-			Quantity:       o.Quantity,
-			Customizations: o.Customizations,
+	history := make([]StatusChangeDetails, 0, len(order.StatusHistory))
+	for _, change := range order.StatusHistory {
+		history = append(history, StatusChangeDetails{
+			From:      change.From,
+			To:        change.To,
+			Actor:     change.Actor,
+			ActorID:   change.ActorID,
+			ChangedAt: change.ChangedAt,
 		})
 	}
 
-	// Fetch restaurant details
-	restaurant, err := queue.GetRestaurantDetails(order.RestaurantID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch restaurant details: " + err.Error()})
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Order history fetched successfully!",
+		"status":  order.Status,
+		"history": history,
+	})
+}
+
+func GetOrder(client *mongo.Client, c *gin.Context) {
+	orderIdInt, ok := parseOrderID(c)
+	if !ok {
 		return
 	}
 
-	// Fetch delivery agent details (if assigned)
-	var deliveryAgent *queue.DeliveryAgentDetails
-	if order.DeliveryAgentID != nil {
-		deliveryAgent, err = queue.GetAgentDetails(*order.DeliveryAgentID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch delivery agent details: " + err.Error()})
-			return
-		}
+	var order model.Order
+	err := config.OrderCollection.FindOne(context.TODO(), bson.M{"orderId": orderIdInt}).Decode(&order)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
 	}
-
-	// Create the final response object
-	orderResponse := OrderDetails{
-		OrderId:         order.OrderId,
-		CustomerID:      order.CustomerID,
-		Orders:          enrichedOrders,
-		TotalPrice:      order.TotalPrice,
-		PaymentMode:     order.PaymentMode,
-		Status:          order.Status,
-		OrderTime:       order.OrderTime,
-		FulfillmentTime: order.FulfillmentTime,
-		DeliveryTime:    order.DeliveryTime,
-		Discount:        order.Discount,
-		CouponCode:      order.CouponCode,
-		DeliveryAgent:   *deliveryAgent,
-		Restaurant:      *restaurant,
+	// Order numbers are sequential, so they must not be enough to read an
+	// order.
+	if !canViewOrder(c, &order) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
 	}
 
+	// Fetch dish, restaurant and delivery agent details
+	enrichedOrders := enrichOrders(c.Request.Context(), []model.Order{order})
+
 	// Return the enriched order details
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Order fetched successfully!",
-		"order":   orderResponse,
+		"order":   enrichedOrders[0],
 	})
 }
 
@@ -403,13 +484,34 @@ func GetAllOrders(client *mongo.Client, c *gin.Context) {
 	if err != nil || skip < 0 {
 		skip = 0
 	}
+	sort, err := parseOrderSort(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort"})
+		return
+	}
+	// A cursor continues from the last order of the previous page and
+	// replaces skip.
+	if token := c.Query("cursor"); token != "" {
+		pageCursor, err := decodeCursor(token)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		after, err := sort.after(pageCursor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		filter = bson.M{"$and": bson.A{filter, after}}
+		skip = 0
+	}
 
 	// Apply Projection to optimize MongoDB query
 	projection := bson.M{
 		"orderId":        1,
 		"customerId":     1,
-		"orders":         1,
-		"totalPrice":     1,
+		"singleOrder":    1,
+		"price":          1,
 		"paymentMode":    1,
 		"status":         1,
 		"orderTime":      1,
@@ -417,10 +519,15 @@ func GetAllOrders(client *mongo.Client, c *gin.Context) {
 		"couponCode":     1,
 		"deliveryAgentId": 1,
 		"restaurantId":   1,
+		"pricing":        1,
+		"notes":          1,
+		"estimatedDeliveryTime": 1,
 	}
+	// Fetch one extra order to learn whether there is a next page
 	findOptions := options.Find().
 		SetProjection(projection).
-		SetLimit(int64(limit)).
+		SetSort(sort.options()).
+		SetLimit(int64(limit + 1)).
 		SetSkip(int64(skip))
 	// Fetch orders from MongoDB
 	var orders []model.Order
@@ -437,66 +544,24 @@ func GetAllOrders(client *mongo.Client, c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode orders"})
 		return
 	}
-
-	// Fetch dish, restaurant, and delivery agent details from RabbitMQ
-	var enrichedOrders []OrderDetails
-	for _, order := range orders {
-		// Fetch restaurant details
-		restaurant, err := queue.GetRestaurantDetails(order.RestaurantID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch restaurant details"})
-			return
-		}
-
-		// Fetch delivery agent details (if assigned)
-		var deliveryAgent queue.DeliveryAgentDetails
-		if order.DeliveryAgentID != nil {
-			agentDetails, err := queue.GetAgentDetails(*order.DeliveryAgentID)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch delivery agent details"})
-				return
-			}
-			deliveryAgent = *agentDetails
-		}
-
-		// Fetch dish details for each order item
-		var detailedOrders []SingleOrderDetails
-		for _, singleOrder := range order.Orders {
-			dishDetails, err := queue.GetDishDetails(singleOrder.DishID)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dish details"})
-				return
-			}
-			detailedOrders = append(detailedOrders, SingleOrderDetails{
-				Price:          singleOrder.Price,
-				Dish:           *dishDetails,
-				Quantity:       singleOrder.Quantity,
-				Customizations: singleOrder.Customizations,
-			})
-		}
-
-		// Append enriched order details
-		enrichedOrders = append(enrichedOrders, OrderDetails{
-			OrderId:        order.OrderId,
-			CustomerID:     order.CustomerID,
-			Orders:         detailedOrders,
-			TotalPrice:     order.TotalPrice,
-			Status:         order.Status,
-			OrderTime:      order.OrderTime,
-			DeliveryAgent:  deliveryAgent,
-			Restaurant:     *restaurant,
-		})
+	var nextCursor string
+	if len(orders) > limit {
+		orders = orders[:limit]
+		nextCursor = sort.cursorAfter(orders[limit-1])
 	}
 
+	// Fetch dish, restaurant, and delivery agent details from RabbitMQ in batches
+	enrichedOrders := enrichOrders(c.Request.Context(), orders)
+
 	// Return JSON response
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Orders fetched successfully",
-		"orders":  enrichedOrders,
+		"message":    "Orders fetched successfully",
+		"orders":     enrichedOrders,
+		"nextCursor": nextCursor,
 	})
 }
 
 func TrackOrder(client *mongo.Client, c *gin.Context) {
-	orderId := c.Param("orderId")
 	customerId, exists := c.Get("customerId")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
@@ -512,9 +577,8 @@ func TrackOrder(client *mongo.Client, c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
 		return
 	}
-	orderIdInt, err := strconv.Atoi(orderId)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+	orderIdInt, ok := parseOrderID(c)
+	if !ok {
 		return
 	}
 	var order model.Order
@@ -536,15 +600,15 @@ func TrackOrder(client *mongo.Client, c *gin.Context) {
 		return
 	}
 	var locationDetails *queue.DeliveryAgentLocation
-	locationDetails, err = queue.GetOrderLocation(*order.DeliveryAgentID)
+	locationDetails, err = queue.GetOrderLocation(c.Request.Context(), *order.DeliveryAgentID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order location"})
+		c.JSON(rpcErrorStatus(err), gin.H{"error": "Failed to get order location"})
 		return
 	}
 
 	agentLocation := DeliveryAgentLocation{
 		AgentId : *order.DeliveryAgentID,
-		Latitude :	locationDetails.Longitude,
+		Latitude :	locationDetails.Latitude,
 		Longitude : locationDetails.Longitude,
 		UpdatedAt : locationDetails.UpdatedAt,
 	}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"order-service/src/config"
+	"order-service/src/model"
+	"order-service/src/repository"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// maxNoteLength keeps customer notes short enough for a kitchen ticket.
+const maxNoteLength = 500
+
+type AddNoteInput struct {
+	Note string `json:"note"`
+}
+
+// AddOrderNote lets the customer send a note to the restaurant while the
+// order is still open.
+func AddOrderNote(client *mongo.Client, c *gin.Context) {
+	var input AddNoteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	note := strings.TrimSpace(input.Note)
+	if note == "" || len(note) > maxNoteLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Note must be between 1 and 500 characters"})
+		return
+	}
+	customerId, exists := c.Get("customerId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	orderIdInt, ok := parseOrderID(c)
+	if !ok {
+		return
+	}
+	var order model.Order
+	err := config.OrderCollection.FindOne(context.TODO(), bson.M{"orderId": orderIdInt}).Decode(&order)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+	if !matchesID(customerId, order.CustomerID) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	if model.IsTerminal(order.Status) || order.Status == model.StatusOutForDelivery {
+		c.JSON(http.StatusConflict, gin.H{"error": "Notes can no longer be added to this order"})
+		return
+	}
+
+	if err := repository.AddCustomerNote(context.TODO(), &order, note); err != nil {
+		if errors.Is(err, repository.ErrStatusConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Order was updated, please try again"})
+			return
+		}
+		log.Println("Error adding order note:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add note"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Note sent to the restaurant!"})
+}
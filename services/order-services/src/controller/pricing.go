@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"context"
+	"math"
+	"order-service/src/config"
+	"order-service/src/model"
+	"order-service/src/queue"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PricingError reports a line item that cannot be ordered as submitted.
+type PricingError struct {
+	Message string
+}
+
+func (e *PricingError) Error() string {
+	return e.Message
+}
+
+// priceLineItems prices items from dish-service's catalog. It rejects dishes
+// and combos that are unknown, unavailable or sold by a restaurant other than
+// restaurantID, customizations the dish does not offer and combo picks that
+// do not fit the combo.
+func priceLineItems(ctx context.Context, restaurantID primitive.ObjectID, items []SingleOrder) ([]model.SingleOrder, float32, error) {
+	if len(items) == 0 {
+		return nil, 0, &PricingError{Message: "Order must contain at least one dish"}
+	}
+	var dishIds []primitive.ObjectID
+	var customizationItems []queue.CustomizationItem
+	var comboItems []queue.ComboSelection
+	for _, item := range items {
+		if item.ComboID != nil {
+			if item.Quantity < 1 {
+				return nil, 0, &PricingError{Message: "Quantity must be at least 1 for combo " + item.ComboID.Hex()}
+			}
+			if !item.DishID.IsZero() || item.VariantID != nil || len(item.Customizations) > 0 {
+				return nil, 0, &PricingError{Message: "Combo " + item.ComboID.Hex() + " cannot have a dish, variant or customizations"}
+			}
+			comboItems = append(comboItems, queue.ComboSelection{ComboID: *item.ComboID, Selections: item.Selections})
+			continue
+		}
+		if item.Quantity < 1 {
+			return nil, 0, &PricingError{Message: "Quantity must be at least 1 for dish " + item.DishID.Hex()}
+		}
+		dishIds = append(dishIds, item.DishID)
+		customizationItems = append(customizationItems, queue.CustomizationItem{
+			DishID:         item.DishID,
+			Customizations: item.Customizations,
+		})
+	}
+
+	pricing := &queue.DishPricingBatch{}
+	customizations := &queue.CustomizationBatch{}
+	combos := &queue.ComboPricingBatch{}
+	var err error
+	if len(dishIds) > 0 {
+		if pricing, err = queue.GetDishPricing(ctx, dishIds); err != nil {
+			return nil, 0, err
+		}
+		if customizations, err = queue.PriceCustomizations(ctx, customizationItems); err != nil {
+			return nil, 0, err
+		}
+	}
+	if len(comboItems) > 0 {
+		if combos, err = queue.GetComboPricing(ctx, comboItems); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var lines []model.SingleOrder
+	var subtotal float32
+	dishIndex, comboIndex := 0, 0
+	for _, item := range items {
+		var line model.SingleOrder
+		if item.ComboID != nil {
+			line, err = comboLine(restaurantID, item, combos.Items[comboIndex])
+			comboIndex++
+		} else {
+			line, err = dishLine(restaurantID, item, pricing, customizations.Items[dishIndex])
+			dishIndex++
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		lines = append(lines, line)
+		subtotal += line.Price
+	}
+	return lines, roundMoney(subtotal), nil
+}
+
+func dishLine(restaurantID primitive.ObjectID, item SingleOrder, pricing *queue.DishPricingBatch, customization queue.PricedCustomization) (model.SingleOrder, error) {
+	dish, ok := pricing.Items[item.DishID.Hex()]
+	if !ok {
+		return model.SingleOrder{}, &PricingError{Message: "Dish " + item.DishID.Hex() + " does not exist"}
+	}
+	if dish.RestaurantID != restaurantID.Hex() {
+		return model.SingleOrder{}, &PricingError{Message: "Dish " + item.DishID.Hex() + " does not belong to this restaurant"}
+	}
+	if !dish.Available {
+		if dish.AvailableFrom != nil {
+			return model.SingleOrder{}, &PricingError{Message: "Dish " + item.DishID.Hex() + " is not available until " + dish.AvailableFrom.Format(time.RFC3339)}
+		}
+		return model.SingleOrder{}, &PricingError{Message: "Dish " + item.DishID.Hex() + " is currently unavailable"}
+	}
+	unitPrice, preparationTime := dish.Price, dish.PreparationTime
+	var variantName string
+	if len(dish.Variants) > 0 {
+		if item.VariantID == nil {
+			return model.SingleOrder{}, &PricingError{Message: "Dish " + item.DishID.Hex() + " requires a variant"}
+		}
+		variant, ok := dish.Variants[item.VariantID.Hex()]
+		if !ok {
+			return model.SingleOrder{}, &PricingError{Message: "Variant " + item.VariantID.Hex() + " does not exist for dish " + item.DishID.Hex()}
+		}
+		if !variant.Available {
+			return model.SingleOrder{}, &PricingError{Message: "Variant " + variant.Name + " of dish " + item.DishID.Hex() + " is currently unavailable"}
+		}
+		unitPrice, preparationTime, variantName = variant.Price, variant.PreparationTime, variant.Name
+	} else if item.VariantID != nil {
+		return model.SingleOrder{}, &PricingError{Message: "Dish " + item.DishID.Hex() + " has no variants"}
+	}
+
+	if customization.Error != "" {
+		return model.SingleOrder{}, &PricingError{Message: "Invalid customizations for dish " + item.DishID.Hex() + ": " + customization.Error}
+	}
+	surcharge := customization.Surcharge
+	return model.SingleOrder{
+		Price:           roundMoney((unitPrice + surcharge) * float32(item.Quantity)),
+		UnitPrice:       unitPrice,
+		Surcharge:       surcharge,
+		PreparationTime: preparationTime,
+		DishID:          item.DishID,
+		VariantID:       item.VariantID,
+		VariantName:     variantName,
+		Quantity:        item.Quantity,
+		Customizations:  model.Customizations(customization.Customizations),
+	}, nil
+}
+
+// comboLine prices a combo line item and records the components the kitchen
+// prepares for each combo.
+func comboLine(restaurantID primitive.ObjectID, item SingleOrder, combo queue.ComboPrice) (model.SingleOrder, error) {
+	if combo.Error != "" {
+		return model.SingleOrder{}, &PricingError{Message: "Combo " + item.ComboID.Hex() + ": " + combo.Error}
+	}
+	if combo.RestaurantID != restaurantID.Hex() {
+		return model.SingleOrder{}, &PricingError{Message: "Combo " + item.ComboID.Hex() + " does not belong to this restaurant"}
+	}
+	var components []model.ComboComponent
+	for _, component := range combo.Components {
+		components = append(components, model.ComboComponent{
+			DishID:      component.DishID,
+			VariantID:   component.VariantID,
+			VariantName: component.VariantName,
+			Quantity:    component.Quantity,
+		})
+	}
+	return model.SingleOrder{
+		Price:           roundMoney(combo.Price * float32(item.Quantity)),
+		UnitPrice:       combo.Price,
+		PreparationTime: combo.PreparationTime,
+		ComboID:         item.ComboID,
+		ComboName:       combo.Name,
+		Components:      components,
+		Quantity:        item.Quantity,
+	}, nil
+}
+
+// buildPriceBreakdown applies discount, taxes and fees to subtotal. The
+// discount never exceeds the subtotal and taxes apply after the discount.
+func buildPriceBreakdown(subtotal float32, discount float32) model.PriceBreakdown {
+	if discount > subtotal {
+		discount = subtotal
+	}
+	if discount < 0 {
+		discount = 0
+	}
+	taxes := roundMoney((subtotal - discount) * config.TaxRate())
+	fees := roundMoney(config.DeliveryFee() + config.PlatformFee())
+	return model.PriceBreakdown{
+		Subtotal: subtotal,
+		Discount: roundMoney(discount),
+		Taxes:    taxes,
+		Fees:     fees,
+		Total:    roundMoney(subtotal - discount + taxes + fees),
+	}
+}
+
+func roundMoney(amount float32) float32 {
+	return float32(math.Round(float64(amount)*100) / 100)
+}
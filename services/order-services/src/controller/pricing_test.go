@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"errors"
+	"order-service/src/model"
+	"order-service/src/queue"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestBuildPriceBreakdown(t *testing.T) {
+	t.Setenv("ORDER_TAX_RATE", "0.05")
+	t.Setenv("ORDER_DELIVERY_FEE", "30")
+	t.Setenv("ORDER_PLATFORM_FEE", "5")
+
+	tests := []struct {
+		name     string
+		subtotal float32
+		discount float32
+		want     model.PriceBreakdown
+	}{
+		{
+			name:     "no discount",
+			subtotal: 200,
+			want:     model.PriceBreakdown{Subtotal: 200, Taxes: 10, Fees: 35, Total: 245},
+		},
+		{
+			name:     "taxes apply after the discount",
+			subtotal: 200,
+			discount: 50,
+			want:     model.PriceBreakdown{Subtotal: 200, Discount: 50, Taxes: 7.5, Fees: 35, Total: 192.5},
+		},
+		{
+			name:     "discount is capped at the subtotal",
+			subtotal: 100,
+			discount: 150,
+			want:     model.PriceBreakdown{Subtotal: 100, Discount: 100, Fees: 35, Total: 35},
+		},
+		{
+			name:     "negative discount is ignored",
+			subtotal: 100,
+			discount: -20,
+			want:     model.PriceBreakdown{Subtotal: 100, Taxes: 5, Fees: 35, Total: 140},
+		},
+		{
+			name:     "taxes are rounded to cents",
+			subtotal: 33.33,
+			want:     model.PriceBreakdown{Subtotal: 33.33, Taxes: 1.67, Fees: 35, Total: 70},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildPriceBreakdown(tt.subtotal, tt.discount); got != tt.want {
+				t.Errorf("buildPriceBreakdown(%v, %v) = %+v, want %+v", tt.subtotal, tt.discount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDishLine(t *testing.T) {
+	restaurantID := primitive.NewObjectID()
+	otherRestaurantID := primitive.NewObjectID()
+	dishID := primitive.NewObjectID()
+	variantID := primitive.NewObjectID()
+	missingVariantID := primitive.NewObjectID()
+	availableFrom := time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)
+
+	plain := queue.DishPrice{Price: 120, RestaurantID: restaurantID.Hex(), Available: true, PreparationTime: 15}
+	withVariants := queue.DishPrice{
+		Price:        150,
+		RestaurantID: restaurantID.Hex(),
+		Available:    true,
+		Variants: map[string]queue.VariantPrice{
+			variantID.Hex(): {Name: "Full", Price: 250, Available: true, PreparationTime: 20},
+		},
+	}
+	soldOutVariant := withVariants
+	soldOutVariant.Variants = map[string]queue.VariantPrice{
+		variantID.Hex(): {Name: "Full", Price: 250, Available: false},
+	}
+
+	tests := []struct {
+		name          string
+		dish          *queue.DishPrice
+		item          SingleOrder
+		customization queue.PricedCustomization
+		want          model.SingleOrder
+		wantErr       bool
+	}{
+		{
+			name: "plain dish",
+			dish: &plain,
+			item: SingleOrder{DishID: dishID, Quantity: 2},
+			want: model.SingleOrder{Price: 240, UnitPrice: 120, PreparationTime: 15, DishID: dishID, Quantity: 2},
+		},
+		{
+			name:          "surcharge is added per unit",
+			dish:          &plain,
+			item:          SingleOrder{DishID: dishID, Quantity: 3},
+			customization: queue.PricedCustomization{Surcharge: 10.5},
+			want:          model.SingleOrder{Price: 391.5, UnitPrice: 120, Surcharge: 10.5, PreparationTime: 15, DishID: dishID, Quantity: 3},
+		},
+		{
+			name: "variant price replaces the dish price",
+			dish: &withVariants,
+			item: SingleOrder{DishID: dishID, VariantID: &variantID, Quantity: 1},
+			want: model.SingleOrder{Price: 250, UnitPrice: 250, PreparationTime: 20, DishID: dishID, VariantID: &variantID, VariantName: "Full", Quantity: 1},
+		},
+		{name: "unknown dish", item: SingleOrder{DishID: dishID, Quantity: 1}, wantErr: true},
+		{
+			name:    "dish of another restaurant",
+			dish:    &queue.DishPrice{Price: 120, RestaurantID: otherRestaurantID.Hex(), Available: true},
+			item:    SingleOrder{DishID: dishID, Quantity: 1},
+			wantErr: true,
+		},
+		{
+			name:    "unavailable dish",
+			dish:    &queue.DishPrice{Price: 120, RestaurantID: restaurantID.Hex()},
+			item:    SingleOrder{DishID: dishID, Quantity: 1},
+			wantErr: true,
+		},
+		{
+			name:    "dish outside its schedule",
+			dish:    &queue.DishPrice{Price: 120, RestaurantID: restaurantID.Hex(), AvailableFrom: &availableFrom},
+			item:    SingleOrder{DishID: dishID, Quantity: 1},
+			wantErr: true,
+		},
+		{name: "variant required", dish: &withVariants, item: SingleOrder{DishID: dishID, Quantity: 1}, wantErr: true},
+		{name: "unknown variant", dish: &withVariants, item: SingleOrder{DishID: dishID, VariantID: &missingVariantID, Quantity: 1}, wantErr: true},
+		{name: "unavailable variant", dish: &soldOutVariant, item: SingleOrder{DishID: dishID, VariantID: &variantID, Quantity: 1}, wantErr: true},
+		{name: "variant of a dish without variants", dish: &plain, item: SingleOrder{DishID: dishID, VariantID: &variantID, Quantity: 1}, wantErr: true},
+		{
+			name:          "invalid customizations",
+			dish:          &plain,
+			item:          SingleOrder{DishID: dishID, Quantity: 1},
+			customization: queue.PricedCustomization{Error: "spice is required"},
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pricing := &queue.DishPricingBatch{Items: map[string]queue.DishPrice{}}
+			if tt.dish != nil {
+				pricing.Items[dishID.Hex()] = *tt.dish
+			}
+			got, err := dishLine(restaurantID, tt.item, pricing, tt.customization)
+			if tt.wantErr {
+				var pricingErr *PricingError
+				if !errors.As(err, &pricingErr) {
+					t.Fatalf("dishLine() error = %v, want a PricingError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("dishLine() error = %v", err)
+			}
+			if got.Price != tt.want.Price || got.UnitPrice != tt.want.UnitPrice || got.Surcharge != tt.want.Surcharge ||
+				got.PreparationTime != tt.want.PreparationTime || got.Quantity != tt.want.Quantity ||
+				got.DishID != tt.want.DishID || got.VariantName != tt.want.VariantName {
+				t.Errorf("dishLine() = %+v, want %+v", got, tt.want)
+			}
+			if (got.VariantID == nil) != (tt.want.VariantID == nil) || (got.VariantID != nil && *got.VariantID != *tt.want.VariantID) {
+				t.Errorf("dishLine() variant = %v, want %v", got.VariantID, tt.want.VariantID)
+			}
+		})
+	}
+}
+
+func TestComboLine(t *testing.T) {
+	restaurantID := primitive.NewObjectID()
+	comboID := primitive.NewObjectID()
+	dishID := primitive.NewObjectID()
+
+	combo := queue.ComboPrice{
+		Name:            "Lunch combo",
+		RestaurantID:    restaurantID.Hex(),
+		Price:           299.99,
+		PreparationTime: 25,
+		Components:      []queue.ComboComponent{{DishID: dishID, Quantity: 2}},
+	}
+	got, err := comboLine(restaurantID, SingleOrder{ComboID: &comboID, Quantity: 3}, combo)
+	if err != nil {
+		t.Fatalf("comboLine() error = %v", err)
+	}
+	if got.Price != 899.97 || got.UnitPrice != 299.99 || got.ComboName != "Lunch combo" || got.PreparationTime != 25 {
+		t.Errorf("comboLine() = %+v", got)
+	}
+	if len(got.Components) != 1 || got.Components[0].DishID != dishID || got.Components[0].Quantity != 2 {
+		t.Errorf("comboLine() components = %+v", got.Components)
+	}
+
+	for name, combo := range map[string]queue.ComboPrice{
+		"combo error":          {RestaurantID: restaurantID.Hex(), Error: "choose a drink"},
+		"another restaurant's": {RestaurantID: primitive.NewObjectID().Hex(), Price: 100},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var pricingErr *PricingError
+			if _, err := comboLine(restaurantID, SingleOrder{ComboID: &comboID, Quantity: 1}, combo); !errors.As(err, &pricingErr) {
+				t.Errorf("comboLine() error = %v, want a PricingError", err)
+			}
+		})
+	}
+}
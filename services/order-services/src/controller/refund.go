@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"order-service/src/config"
+	"order-service/src/model"
+	"order-service/src/repository"
+	"order-service/src/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type RefundLineInput struct {
+	LineIndex int `json:"lineIndex"`
+	Quantity  int `json:"quantity"`
+}
+
+type CreateRefundInput struct {
+	Items  []RefundLineInput `json:"items"`
+	Reason string            `json:"reason"`
+}
+
+type RefundItemDetails struct {
+	LineIndex int     `json:"lineIndex"`
+	Quantity  int     `json:"quantity"`
+	Amount    float32 `json:"amount"`
+}
+
+type RefundDetails struct {
+	RefundId    string              `json:"refundId"`
+	Amount      float32             `json:"amount"`
+	Reason      string              `json:"reason"`
+	Status      string              `json:"status"`
+	Items       []RefundItemDetails `json:"items,omitempty"`
+	RequestedBy model.Actor         `json:"requestedBy"`
+	Attempts    int                 `json:"attempts"`
+	LastError   *string             `json:"lastError,omitempty"`
+	CreatedAt   time.Time           `json:"createdAt"`
+	UpdatedAt   time.Time           `json:"updatedAt"`
+}
+
+func toRefundDetails(refund model.Refund) RefundDetails {
+	var items []RefundItemDetails
+	for _, item := range refund.Items {
+		items = append(items, RefundItemDetails{
+			LineIndex: item.LineIndex,
+			Quantity:  item.Quantity,
+			Amount:    item.Amount,
+		})
+	}
+	return RefundDetails{
+		RefundId:    refund.RefundId,
+		Amount:      refund.Amount,
+		Reason:      refund.Reason,
+		Status:      refund.Status,
+		Items:       items,
+		RequestedBy: refund.RequestedBy,
+		Attempts:    refund.Attempts,
+		LastError:   refund.LastError,
+		CreatedAt:   refund.CreatedAt,
+		UpdatedAt:   refund.UpdatedAt,
+	}
+}
+
+func GetOrderRefunds(client *mongo.Client, c *gin.Context) {
+	orderIdInt, ok := parseOrderID(c)
+	if !ok {
+		return
+	}
+	var order model.Order
+	err := config.OrderCollection.FindOne(context.TODO(), bson.M{"orderId": orderIdInt}).Decode(&order)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+	if !canViewOrder(c, &order) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	refunds, err := repository.FindRefundsByOrder(context.TODO(), order.OrderId)
+	if err != nil {
+		log.Println("Error fetching refunds:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch refunds"})
+		return
+	}
+	details := make([]RefundDetails, 0, len(refunds))
+	for _, refund := range refunds {
+		details = append(details, toRefundDetails(refund))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Refunds fetched successfully!",
+		"refundedAmount": order.RefundedAmount,
+		"refunds":        details,
+	})
+}
+
+// CreateRefund lets a restaurant refund individual line items, for example
+// when a dish turns out to be unavailable after the order was paid.
+func CreateRefund(client *mongo.Client, c *gin.Context) {
+	var input CreateRefundInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(input.Items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one line item is required"})
+		return
+	}
+	restaurantId, exists := c.Get("restaurantId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	orderIdInt, ok := parseOrderID(c)
+	if !ok {
+		return
+	}
+	var order model.Order
+	err := config.OrderCollection.FindOne(context.TODO(), bson.M{"orderId": orderIdInt}).Decode(&order)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+	if !matchesID(restaurantId, order.RestaurantID) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var lines []service.RefundLine
+	for _, item := range input.Items {
+		lines = append(lines, service.RefundLine{LineIndex: item.LineIndex, Quantity: item.Quantity})
+	}
+	refund, err := service.RequestRefund(context.TODO(), &order, lines, input.Reason, model.ActorRestaurant)
+	if err != nil {
+		var refundErr *service.RefundError
+		if errors.As(err, &refundErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": refundErr.Message})
+			return
+		}
+		log.Println("Error creating refund:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create refund"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Refund requested successfully!",
+		"refund":  toRefundDetails(*refund),
+	})
+}
@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"order-service/src/config"
+	"order-service/src/model"
+	"order-service/src/queue"
+	"order-service/src/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	// streamKeepAlive keeps idle streams from being closed by proxies.
+	streamKeepAlive = 15 * time.Second
+	wsWriteTimeout  = 10 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// TrackOrderStream pushes status changes, agent assignment, ETA revisions
+// and agent location updates for an order as they happen. Clients that ask for a
+// WebSocket upgrade get one; everyone else gets Server-Sent Events. The
+// stream ends once the order is delivered or cancelled.
+func TrackOrderStream(client *mongo.Client, c *gin.Context) {
+	orderIdInt, ok := parseOrderID(c)
+	if !ok {
+		return
+	}
+	var order model.Order
+	err := config.OrderCollection.FindOne(context.TODO(), bson.M{"orderId": orderIdInt}).Decode(&order)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+	if !canViewOrder(c, &order) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		return
+	}
+	if model.IsTerminal(order.Status) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Order is already " + order.Status})
+		return
+	}
+
+	updates, stop := service.OrderTracker().Watch(&order)
+	defer stop()
+	snapshot := trackingSnapshot(c.Request.Context(), &order)
+
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		streamWebSocket(c, snapshot, updates)
+		return
+	}
+	streamSSE(c, snapshot, updates)
+}
+
+// trackingSnapshot is what a new watcher is sent before live updates: the
+// current status and ETA and, if an agent is on the way, their last known
+// location.
+func trackingSnapshot(ctx context.Context, order *model.Order) []service.TrackingUpdate {
+	now := time.Now()
+	snapshot := []service.TrackingUpdate{{
+		Type:    service.TrackingStatus,
+		OrderId: order.OrderId,
+		Data:    service.TrackingStatusData{To: order.Status},
+		At:      now,
+	}}
+	if order.EstimatedDeliveryTime != nil {
+		snapshot = append(snapshot, service.TrackingUpdate{
+			Type:    service.TrackingETA,
+			OrderId: order.OrderId,
+			Data:    gin.H{"estimatedDeliveryTime": order.EstimatedDeliveryTime, "status": order.Status},
+			At:      now,
+		})
+	}
+	if order.DeliveryAgentID == nil {
+		return snapshot
+	}
+	location, err := queue.GetOrderLocation(ctx, *order.DeliveryAgentID)
+	if err != nil {
+		log.Println("Error fetching agent location:", err)
+		return snapshot
+	}
+	return append(snapshot, service.TrackingUpdate{
+		Type:    service.TrackingLocation,
+		OrderId: order.OrderId,
+		Data: service.TrackingLocationData{
+			AgentID:   *order.DeliveryAgentID,
+			Latitude:  location.Latitude,
+			Longitude: location.Longitude,
+			UpdatedAt: location.UpdatedAt,
+		},
+		At: location.UpdatedAt,
+	})
+}
+
+func streamSSE(c *gin.Context, snapshot []service.TrackingUpdate, updates <-chan service.TrackingUpdate) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	for _, update := range snapshot {
+		c.SSEvent(update.Type, update)
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return false
+			}
+			c.SSEvent(update.Type, update)
+			return true
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+func streamWebSocket(c *gin.Context, snapshot []service.TrackingUpdate, updates <-chan service.TrackingUpdate) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade has already replied to the client
+		log.Println("Error upgrading tracking stream:", err)
+		return
+	}
+	defer conn.Close()
+
+	// the client never sends anything, but reading is how close frames and
+	// dropped connections are noticed
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	write := func(update service.TrackingUpdate) error {
+		conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		return conn.WriteJSON(update)
+	}
+	for _, update := range snapshot {
+		if err := write(update); err != nil {
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, "order completed"),
+					time.Now().Add(wsWriteTimeout))
+				return
+			}
+			if err := write(update); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"order-service/src/model"
+	"order-service/src/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// responseRecorder keeps a copy of everything the handler writes.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency makes a handler safe to retry. Requests carrying an
+// Idempotency-Key header run at most once per key and caller; replays with
+// the same body get the original response, replays with a different body
+// are rejected with 422 and replays of a request still running get 409.
+// Keys are scoped to the caller AuthMiddleware identified, so it must run
+// first; a key sent without an identity is rejected.
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			c.Abort()
+			return
+		}
+
+		scope := idempotencyScope(c)
+		if scope == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Idempotency-Key can only be used by an authenticated caller"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		record := &model.IdempotencyRecord{
+			Key:         key,
+			Scope:       scope,
+			RequestHash: hashRequest(c, body),
+		}
+		existing, reserved, err := repository.ReserveIdempotencyKey(context.TODO(), record)
+		if err != nil {
+			log.Println("Error reserving idempotency key:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process Idempotency-Key"})
+			c.Abort()
+			return
+		}
+		if !reserved {
+			replay(c, record, existing)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// server errors are not remembered so the client can retry them
+		if recorder.Status() >= http.StatusInternalServerError {
+			if err := repository.ReleaseIdempotencyKey(context.TODO(), record); err != nil {
+				log.Println("Error releasing idempotency key:", err)
+			}
+			return
+		}
+		if err := repository.CompleteIdempotencyKey(context.TODO(), record, recorder.Status(), recorder.body.Bytes()); err != nil {
+			log.Println("Error storing idempotent response:", err)
+		}
+	}
+}
+
+func replay(c *gin.Context, record *model.IdempotencyRecord, existing *model.IdempotencyRecord) {
+	switch {
+	case existing.RequestHash != record.RequestHash:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+	case existing.Status != model.IdempotencyCompleted:
+		c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+	default:
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(existing.ResponseCode, "application/json; charset=utf-8", existing.ResponseBody)
+	}
+	c.Abort()
+}
+
+// idempotencyScope keeps keys from different callers apart.
+func idempotencyScope(c *gin.Context) string {
+	for _, claim := range []string{"customerId", "restaurantId", "deliveryAgentId"} {
+		if id, ok := c.Get(claim); ok {
+			if idStr, ok := id.(string); ok {
+				return claim + ":" + idStr
+			}
+		}
+	}
+	return ""
+}
+
+func hashRequest(c *gin.Context, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.FullPath() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DispatchSearching = "searching"
+	DispatchOffered   = "offered"
+	DispatchAssigned  = "assigned"
+	DispatchFailed    = "failed"
+)
+
+const (
+	OfferPending  = "pending"
+	OfferAccepted = "accepted"
+	OfferDeclined = "declined"
+	OfferExpired  = "expired"
+)
+
+type GeoPoint struct {
+	Latitude  float64 `bson:"latitude"`
+	Longitude float64 `bson:"longitude"`
+}
+
+// AssignmentAttempt is one offer of an order to a delivery agent.
+type AssignmentAttempt struct {
+	OfferId      string             `bson:"offerId"`
+	AgentID      primitive.ObjectID `bson:"agentId"`
+	DistanceKm   float64            `bson:"distanceKm"`
+	ActiveOrders int                `bson:"activeOrders"`
+	Status       string             `bson:"status"`
+	OfferedAt    time.Time          `bson:"offeredAt"`
+	ExpiresAt    time.Time          `bson:"expiresAt"`
+	RespondedAt  *time.Time         `bson:"respondedAt,omitempty"`
+}
+
+// Dispatch tracks the search for a delivery agent once an order is ready.
+// Rounds counts searches that found nobody to offer the order to.
+type Dispatch struct {
+	Status         string              `bson:"status"`
+	Pickup         *GeoPoint           `bson:"pickup,omitempty"`
+	Attempts       []AssignmentAttempt `bson:"attempts"`
+	Rounds         int                 `bson:"rounds"`
+	OfferExpiresAt *time.Time          `bson:"offerExpiresAt,omitempty"`
+	NextSearchAt   *time.Time          `bson:"nextSearchAt,omitempty"`
+	UpdatedAt      time.Time           `bson:"updatedAt"`
+}
+
+// OfferedTo reports whether the order was already offered to agentID.
+func (d *Dispatch) OfferedTo(agentID primitive.ObjectID) bool {
+	for _, attempt := range d.Attempts {
+		if attempt.AgentID == agentID {
+			return true
+		}
+	}
+	return false
+}
+
+const earthRadiusKm = 6371
+
+// DistanceKm returns the great-circle distance between a and b.
+func DistanceKm(a GeoPoint, b GeoPoint) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// ETA triggers record what caused an estimate to be recomputed.
+const (
+	ETATriggerStatus   = "status"
+	ETATriggerAgent    = "agent_assigned"
+	ETATriggerLocation = "location"
+)
+
+// ETAEstimate is one estimate of an order's delivery time together with the
+// inputs it was computed from, kept so estimates can be compared with the
+// actual delivery time later.
+type ETAEstimate struct {
+	EstimatedDeliveryTime time.Time `bson:"estimatedDeliveryTime"`
+	ComputedAt            time.Time `bson:"computedAt"`
+	Trigger               string    `bson:"trigger"`
+	Status                string    `bson:"status"`
+	PreparationMinutes    float64   `bson:"preparationMinutes"`
+	QueueDepth            int       `bson:"queueDepth"`
+	TravelKm              float64   `bson:"travelKm"`
+	TravelMinutes         float64   `bson:"travelMinutes"`
+}
+
+// PreparationMinutes is how long the kitchen needs for order. Dishes are
+// prepared in parallel, so it is the slowest dish's preparation time.
+func (o *Order) PreparationMinutes() int {
+	longest := 0
+	for _, line := range o.Orders {
+		if line.PreparationTime > longest {
+			longest = line.PreparationTime
+		}
+	}
+	return longest
+}
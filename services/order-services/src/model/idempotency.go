@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+const (
+	IdempotencyInProgress = "in_progress"
+	IdempotencyCompleted  = "completed"
+)
+
+// IdempotencyRecord remembers the outcome of a request made with an
+// Idempotency-Key so a retried request can be answered without re-running it.
+type IdempotencyRecord struct {
+	Key          string    `bson:"key"`
+	Scope        string    `bson:"scope"`
+	RequestHash  string    `bson:"requestHash"`
+	Status       string    `bson:"status"`
+	ResponseCode int       `bson:"responseCode,omitempty"`
+	ResponseBody []byte    `bson:"responseBody,omitempty"`
+	CreatedAt    time.Time `bson:"createdAt"`
+	LockedAt     time.Time `bson:"lockedAt"`
+}
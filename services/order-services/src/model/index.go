@@ -10,6 +10,7 @@ const (
 	StatusPending        = "Pending"
 	StatusConfirmed      = "Confirmed"
 	StatusBeingPrepared  = "Being Prepared"
+	StatusReadyForPickup = "Ready for Pickup"
 	StatusOutForDelivery = "Out for Delivery"
 	StatusDelivered      = "Delivered"
 	StatusCancelled      = "Cancelled"
@@ -22,6 +23,8 @@ type Order struct {
 	Orders          []SingleOrder      `bson:"singleOrder"`
 	TotalPrice      float32            `bson:"price"`
 	PaymentMode     string             `bson:"paymentMode"`
+	PaymentId       string             `bson:"paymentId,omitempty"`
+	PaymentStatus   string             `bson:"paymentStatus"`
 	Status          string             `bson:"status"`
 	OrderTime       time.Time          `bson:"orderTime"`
 	FulfillmentTime *time.Time         `bson:"fulfillmentTime,omitempty"`
@@ -30,20 +33,69 @@ type Order struct {
 	CouponCode      *string            `bson:"couponCode,omitempty"`
 	DeliveryAgentID 	*primitive.ObjectID	`bson:"deliveryAgentId, omitempty"`
 	RestaurantID   primitive.ObjectID `bson:"restaurantId"`
+	StatusHistory  []StatusChange     `bson:"statusHistory"`
+	Pricing        PriceBreakdown     `bson:"pricing"`
+	RefundedAmount float32            `bson:"refundedAmount"`
+	Notes          []OrderNote        `bson:"notes,omitempty"`
+	// RestaurantNotifiedAt is set once the restaurant has been sent the
+	// order, which for prepaid orders happens after payment succeeds.
+	RestaurantNotifiedAt *time.Time `bson:"restaurantNotifiedAt,omitempty"`
+	// RestaurantResponseBy is when a pending order is rejected automatically
+	// if the restaurant has not accepted it.
+	RestaurantResponseBy *time.Time `bson:"restaurantResponseBy,omitempty"`
+	Dispatch             *Dispatch  `bson:"dispatch,omitempty"`
+	// DeliveryLocation is where the customer wants the order delivered.
+	DeliveryLocation      *GeoPoint     `bson:"deliveryLocation,omitempty"`
+	EstimatedDeliveryTime *time.Time    `bson:"estimatedDeliveryTime,omitempty"`
+	ETAHistory            []ETAEstimate `bson:"etaHistory,omitempty"`
+	// StockReservationId names the stock dish-service holds for the order,
+	// released if the order is cancelled.
+	StockReservationId string `bson:"stockReservationId,omitempty"`
+}
+
+// OrderNote is a note from the customer to the restaurant.
+type OrderNote struct {
+	Text      string    `bson:"text"`
+	CreatedAt time.Time `bson:"createdAt"`
+}
+
+// PriceBreakdown is how an order's total was computed at creation time.
+type PriceBreakdown struct {
+	Subtotal float32 `bson:"subtotal"`
+	Discount float32 `bson:"discount"`
+	Taxes    float32 `bson:"taxes"`
+	Fees     float32 `bson:"fees"`
+	Total    float32 `bson:"total"`
 }
 
 type SingleOrder struct {
-	Price          float32            `bson:"price"`
-	DishID         primitive.ObjectID `bson:"dishId"`
-	Quantity       int                `bson:"quantity"`
-	Customizations Customizations     `bson:"customizations"`
+	Price           float32            `bson:"price"` // line total
+	UnitPrice       float32            `bson:"unitPrice"`
+	Surcharge       float32            `bson:"surcharge"`       // customization surcharge per unit
+	PreparationTime int                `bson:"preparationTime"` // minutes
+	DishID          primitive.ObjectID `bson:"dishId"`
+	// VariantID and VariantName identify the portion ordered for dishes
+	// sold in variants; the name is kept as it was at order time.
+	VariantID       *primitive.ObjectID `bson:"variantId,omitempty"`
+	VariantName     string              `bson:"variantName,omitempty"`
+	// ComboID is set instead of DishID for a combo line item. Components are
+	// what the kitchen prepares for each combo ordered.
+	ComboID         *primitive.ObjectID `bson:"comboId,omitempty"`
+	ComboName       string              `bson:"comboName,omitempty"`
+	Components      []ComboComponent    `bson:"components,omitempty"`
+	Quantity        int                `bson:"quantity"`
+	Customizations  Customizations     `bson:"customizations"`
 }
 
-type Customizations struct {
-	Salty       int  `bson:"salty, omitempty"`
-	Spicy       int  `bson:"spicy, omitempty"`
-	ExtraCheese int  `bson:"extraCheese, omitempty"`
-	Sweetness   int  `bson:"sweetness, omitempty"`
-	Onion       bool `bson:"onion, omitempty"`
-	Garlic      bool `bson:"garlic, omitempty"`
+// ComboComponent is one dish in a combo line item, per combo.
+type ComboComponent struct {
+	DishID      primitive.ObjectID  `bson:"dishId"`
+	VariantID   *primitive.ObjectID `bson:"variantId,omitempty"`
+	VariantName string              `bson:"variantName,omitempty"`
+	Quantity    int                 `bson:"quantity"`
 }
+
+// Customizations are the dish's customization values as validated by
+// dish-service, keyed by option key. Values are numbers, bools, choice keys
+// or lists of choice keys depending on the option type.
+type Customizations map[string]any
@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// Order domain events, also used as routing keys on the order events exchange.
+const (
+	EventOrderCreated         = "order.created"
+	EventOrderStatusChanged   = "order.status_changed"
+	EventOrderCancelled       = "order.cancelled"
+	EventOrderPaymentUpdated  = "order.payment_updated"
+	EventOrderRefundRequested = "order.refund_requested"
+	EventOrderRefundFailed    = "order.refund_failed"
+	EventOrderAgentAssigned   = "order.agent_assigned"
+	EventOrderETAUpdated      = "order.eta_updated"
+)
+
+// Outbox event statuses. A parked event failed to publish too many times
+// and is left for manual follow-up.
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxParked  = "parked"
+)
+
+// OutboxEvent is a domain event stored in the same transaction as the order
+// change it describes and published later by the outbox relay. Payload is
+// the JSON message body, so the relay publishes exactly what was committed.
+// Exchange and RoutingKey default to the order events exchange and Type.
+// Messages with a Queue are sent straight to that queue instead. An event
+// that failed to publish is retried at NextAttemptAt.
+type OutboxEvent struct {
+	EventId       string     `bson:"eventId"`
+	Type          string     `bson:"type"`
+	OrderId       int        `bson:"orderId"`
+	Exchange      string     `bson:"exchange,omitempty"`
+	RoutingKey    string     `bson:"routingKey,omitempty"`
+	Queue         string     `bson:"queue,omitempty"`
+	Payload       []byte     `bson:"payload"`
+	Status        string     `bson:"status"`
+	Attempts      int        `bson:"attempts"`
+	LastError     *string    `bson:"lastError,omitempty"`
+	NextAttemptAt *time.Time `bson:"nextAttemptAt,omitempty"`
+	CreatedAt     time.Time  `bson:"createdAt"`
+	SentAt        *time.Time `bson:"sentAt,omitempty"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PaymentModeCOD    = "COD"
+	PaymentModeCard   = "card"
+	PaymentModeWallet = "wallet"
+	PaymentModeUPI    = "UPI"
+)
+
+const (
+	PaymentStatusPending   = "pending"
+	PaymentStatusSucceeded = "succeeded"
+	PaymentStatusFailed    = "failed"
+	PaymentStatusExpired   = "expired"
+)
+
+type Payment struct {
+	PaymentId     string             `bson:"paymentId"`
+	OrderId       int                `bson:"orderId"`
+	CustomerID    primitive.ObjectID `bson:"customerId"`
+	Amount        float32            `bson:"amount"`
+	Mode          string             `bson:"mode"`
+	Status        string             `bson:"status"`
+	ProviderRef   *string            `bson:"providerRef,omitempty"`
+	FailureReason *string            `bson:"failureReason,omitempty"`
+	CreatedAt     time.Time          `bson:"createdAt"`
+	UpdatedAt     time.Time          `bson:"updatedAt"`
+	ExpiresAt     *time.Time         `bson:"expiresAt,omitempty"`
+}
+
+// NormalizePaymentMode returns the canonical spelling of mode, or false if
+// mode is not a supported payment mode.
+func NormalizePaymentMode(mode string) (string, bool) {
+	for _, known := range []string{PaymentModeCOD, PaymentModeCard, PaymentModeWallet, PaymentModeUPI} {
+		if strings.EqualFold(mode, known) {
+			return known, true
+		}
+	}
+	return "", false
+}
+
+// IsPrepaid reports whether mode is collected online before the order is confirmed.
+func IsPrepaid(mode string) bool {
+	return mode != PaymentModeCOD
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+const (
+	RefundRequested  = "requested"
+	RefundProcessing = "processing"
+	RefundCompleted  = "completed"
+	RefundFailed     = "failed"
+)
+
+// RefundItem is the part of a single order line covered by a partial refund.
+type RefundItem struct {
+	LineIndex int     `bson:"lineIndex"`
+	Quantity  int     `bson:"quantity"`
+	Amount    float32 `bson:"amount"`
+}
+
+type Refund struct {
+	RefundId      string       `bson:"refundId"`
+	OrderId       int          `bson:"orderId"`
+	PaymentId     string       `bson:"paymentId"`
+	Amount        float32      `bson:"amount"`
+	Reason        string       `bson:"reason"`
+	Status        string       `bson:"status"`
+	Items         []RefundItem `bson:"items,omitempty"`
+	RequestedBy   Actor        `bson:"requestedBy"`
+	Attempts      int          `bson:"attempts"`
+	LastError     *string      `bson:"lastError,omitempty"`
+	ProviderRef   *string      `bson:"providerRef,omitempty"`
+	NextAttemptAt *time.Time   `bson:"nextAttemptAt,omitempty"`
+	CreatedAt     time.Time    `bson:"createdAt"`
+	UpdatedAt     time.Time    `bson:"updatedAt"`
+}
+
+// GivenUp reports whether the refund failed with no attempts left. Its
+// amount no longer counts as refunded.
+func (r Refund) GivenUp() bool {
+	return r.Status == RefundFailed && r.NextAttemptAt == nil
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Actor identifies who moved an order from one status to another.
+type Actor string
+
+const (
+	ActorCustomer      Actor = "customer"
+	ActorRestaurant    Actor = "restaurant"
+	ActorDeliveryAgent Actor = "delivery_agent"
+	ActorSystem        Actor = "system"
+)
+
+// StatusChange is one entry of Order.StatusHistory.
+type StatusChange struct {
+	From      string              `bson:"from"`
+	To        string              `bson:"to"`
+	Actor     Actor               `bson:"actor"`
+	ActorID   *primitive.ObjectID `bson:"actorId,omitempty"`
+	ChangedAt time.Time           `bson:"changedAt"`
+}
+
+// transitions lists, per actor, the statuses an order may move to from each status.
+var transitions = map[Actor]map[string][]string{
+	ActorCustomer: {
+		StatusPending:   {StatusCancelled},
+		StatusConfirmed: {StatusCancelled},
+	},
+	ActorRestaurant: {
+		StatusPending:   {StatusConfirmed, StatusCancelled},
+		StatusConfirmed: {StatusBeingPrepared, StatusCancelled},
+		// Dispatch starts at ReadyForPickup, so the restaurant cannot skip it.
+		StatusBeingPrepared: {StatusReadyForPickup},
+	},
+	ActorDeliveryAgent: {
+		StatusBeingPrepared:  {StatusOutForDelivery},
+		StatusReadyForPickup: {StatusOutForDelivery},
+		StatusOutForDelivery: {StatusDelivered},
+	},
+	ActorSystem: {
+		StatusPending:   {StatusConfirmed, StatusCancelled},
+		StatusConfirmed: {StatusCancelled},
+	},
+}
+
+// IsValidStatus reports whether status is one of the known order statuses.
+func IsValidStatus(status string) bool {
+	switch status {
+	case StatusPending, StatusConfirmed, StatusBeingPrepared, StatusReadyForPickup,
+		StatusOutForDelivery, StatusDelivered, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// IsTerminal reports whether no actor can move an order out of status.
+func IsTerminal(status string) bool {
+	return status == StatusDelivered || status == StatusCancelled
+}
+
+// CanTransition reports whether actor may move an order from one status to another.
+func CanTransition(actor Actor, from string, to string) bool {
+	for _, next := range transitions[actor][from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package model
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		name  string
+		actor Actor
+		from  string
+		to    string
+		want  bool
+	}{
+		{"customer cancels pending order", ActorCustomer, StatusPending, StatusCancelled, true},
+		{"customer cancels confirmed order", ActorCustomer, StatusConfirmed, StatusCancelled, true},
+		{"customer cannot cancel once prepared", ActorCustomer, StatusBeingPrepared, StatusCancelled, false},
+		{"customer cannot confirm", ActorCustomer, StatusPending, StatusConfirmed, false},
+		{"restaurant accepts", ActorRestaurant, StatusPending, StatusConfirmed, true},
+		{"restaurant rejects", ActorRestaurant, StatusPending, StatusCancelled, true},
+		{"restaurant starts preparing", ActorRestaurant, StatusConfirmed, StatusBeingPrepared, true},
+		{"restaurant marks ready", ActorRestaurant, StatusBeingPrepared, StatusReadyForPickup, true},
+		{"restaurant cannot skip preparing", ActorRestaurant, StatusConfirmed, StatusReadyForPickup, false},
+		{"restaurant cannot skip ready for pickup", ActorRestaurant, StatusBeingPrepared, StatusOutForDelivery, false},
+		{"restaurant cannot deliver", ActorRestaurant, StatusOutForDelivery, StatusDelivered, false},
+		{"agent picks up ready order", ActorDeliveryAgent, StatusReadyForPickup, StatusOutForDelivery, true},
+		{"agent picks up order being prepared", ActorDeliveryAgent, StatusBeingPrepared, StatusOutForDelivery, true},
+		{"agent delivers", ActorDeliveryAgent, StatusOutForDelivery, StatusDelivered, true},
+		{"agent cannot cancel", ActorDeliveryAgent, StatusOutForDelivery, StatusCancelled, false},
+		{"system confirms paid order", ActorSystem, StatusPending, StatusConfirmed, true},
+		{"system cancels unpaid order", ActorSystem, StatusPending, StatusCancelled, true},
+		{"system cannot deliver", ActorSystem, StatusOutForDelivery, StatusDelivered, false},
+		{"nobody leaves delivered", ActorRestaurant, StatusDelivered, StatusCancelled, false},
+		{"nobody leaves cancelled", ActorSystem, StatusCancelled, StatusPending, false},
+		{"same status is not a transition", ActorRestaurant, StatusPending, StatusPending, false},
+		{"unknown actor", Actor("admin"), StatusPending, StatusCancelled, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanTransition(tt.actor, tt.from, tt.to); got != tt.want {
+				t.Errorf("CanTransition(%q, %q, %q) = %v, want %v", tt.actor, tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerminalStatusesHaveNoTransitions(t *testing.T) {
+	for actor, from := range transitions {
+		for status := range from {
+			if IsTerminal(status) {
+				t.Errorf("%s can move an order out of terminal status %q", actor, status)
+			}
+			if !IsValidStatus(status) {
+				t.Errorf("%s has transitions from unknown status %q", actor, status)
+			}
+		}
+		for _, targets := range from {
+			for _, to := range targets {
+				if !IsValidStatus(to) {
+					t.Errorf("%s has a transition to unknown status %q", actor, to)
+				}
+			}
+		}
+	}
+}
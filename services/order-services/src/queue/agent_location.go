@@ -1,15 +1,11 @@
 package queue
 
 import (
-	"errors"
-	"log"
+	"context"
 	"os"
 	"time"
 
-	"encoding/json"
-	amqp "github.com/rabbitmq/amqp091-go"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"github.com/google/uuid"
 )
 
 type DeliveryAgentLocation struct {
@@ -18,83 +14,35 @@ type DeliveryAgentLocation struct {
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
-func GetOrderLocation(deliveryAgentId primitive.ObjectID) (*DeliveryAgentLocation, error){
-	rabbitMqUrl := os.Getenv("RABBITMQ_URL")
-	if rabbitMqUrl == "" {
-		rabbitMqUrl = "amqp://localhost"
+func GetOrderLocation(ctx context.Context, deliveryAgentId primitive.ObjectID) (*DeliveryAgentLocation, error){
+	var response DeliveryAgentLocation
+	if err := Connect().Call(ctx, "delivery_agent_location", deliveryAgentId, &response); err != nil {
+		return nil, err
 	}
-	conn, err := amqp.Dial(rabbitMqUrl)
-	failOnError(err, "Failed to connect to RabbitMQ")
-	defer conn.Close()
-
-	ch, err := conn.Channel()
-	failOnError(err, "Failed to open a channel")
-	defer ch.Close()
-
-	requestQueue := "delivery_agent_location"
-	responseQueue := "delivery_agent_location_response"
-
-	_, err = ch.QueueDeclare(
-		requestQueue,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	failOnError(err, "Failed to declare a request queue")
-
-	_, err = ch.QueueDeclare(
-		responseQueue,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	failOnError(err, "Failed to declare a response queue")
-
-	correlationID := uuid.New().String()
-
-	request := deliveryAgentId
-
-	requestBody, err := json.Marshal(request)
-	failOnError(err, "Failed to marshal request body")
-
-	responseChan := make(chan *DeliveryAgentLocation)
+	return &response, nil
+}
 
-	go func ()  {
-		msgs, err := ch.Consume(responseQueue, "", false, false, false, false, nil)
-		failOnError(err, "Failed to consume message from queue")
-		for msg := range msgs {
-			if msg.CorrelationId == correlationID {
-				var response DeliveryAgentLocation
-				if err := json.Unmarshal(msg.Body, &response); err != nil {
-					log.Println("Failed to unmarshal response:", err)
-					continue
-				}
-				ch.Ack(msg.DeliveryTag, false)
-				responseChan <- &response
-				return
-			}
-		}
-	}()
+// AgentLocationUpdate is published by the delivery agent service to the
+// agent locations exchange under AgentRoutingKey whenever an agent moves.
+type AgentLocationUpdate struct {
+	AgentID   primitive.ObjectID `json:"agentId"`
+	Latitude  float64            `json:"latitude"`
+	Longitude float64            `json:"longitude"`
+	UpdatedAt time.Time          `json:"updatedAt"`
+}
 
-	err = ch.Publish(
-		"", requestQueue, false, false,
-		amqp.Publishing{
-			CorrelationId: correlationID,
-			ReplyTo:       responseQueue,
-			ContentType:   "application/json",
-			Body:          requestBody,
-		},
-	)
-	failOnError(err, "Failed to push the message to queue")
+// ConsumeAgentLocations delivers every agent's location updates from a
+// durable queue shared by all instances.
+func ConsumeAgentLocations(queueName string, handle func(update AgentLocationUpdate) error) {
+	ConsumeBound(queueName, AgentLocationExchange(), []string{"agent.*"}, handle)
+}
 
-	select {
-	case response := <-responseChan:
-		return response, nil
-	case <-time.After(9 * time.Second):
-		return nil, errors.New("token generation timed out")
+// AgentLocationExchange is the topic exchange agent location updates are
+// published to.
+func AgentLocationExchange() string {
+	exchange := os.Getenv("DELIVERY_AGENT_LOCATION_EXCHANGE")
+	if exchange == "" {
+		exchange = "delivery_agent_locations"
 	}
-}
\ No newline at end of file
+	return exchange
+}
@@ -0,0 +1,33 @@
+package queue
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BatchDetailsRequest is the body sent on every *_batch queue. Only dishes
+// have one; restaurants and delivery agents are looked up one at a time by
+// GetEachRestaurantDetails and GetEachAgentDetails.
+type BatchDetailsRequest struct {
+	IDs []primitive.ObjectID `json:"ids"`
+}
+
+// DishDetailsBatch is keyed by the hex id of each requested dish. Ids that
+// could not be resolved are listed in Missing rather than failing the batch.
+type DishDetailsBatch struct {
+	Items   map[string]DishDetails `json:"items"`
+	Missing []string               `json:"missing,omitempty"`
+	Error   string                 `json:"error,omitempty"`
+}
+
+func GetDishDetailsBatch(ctx context.Context, dishIds []primitive.ObjectID) (*DishDetailsBatch, error) {
+	var response DishDetailsBatch
+	if err := Connect().Call(ctx, "dish_details_batch", BatchDetailsRequest{IDs: dishIds}, &response); err != nil {
+		return nil, err
+	}
+	if response.Error != "" {
+		return nil, &RemoteError{Queue: "dish_details_batch", Message: response.Error}
+	}
+	return &response, nil
+}
@@ -0,0 +1,57 @@
+package queue
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ComboSelection is a combo line item with the customer's picks, option ids
+// keyed by group key.
+type ComboSelection struct {
+	ComboID    primitive.ObjectID              `json:"comboId"`
+	Selections map[string][]primitive.ObjectID `json:"selections"`
+}
+
+type ComboPricingRequest struct {
+	Items []ComboSelection `json:"items"`
+}
+
+// ComboPrice is dish-service's price for one combo with the components the
+// kitchen prepares for it, in request order. PreparationTime is in minutes.
+type ComboPrice struct {
+	ComboID         string           `json:"comboId"`
+	Name            string           `json:"name"`
+	RestaurantID    string           `json:"restaurantId"`
+	Price           float32          `json:"price"`
+	PreparationTime int              `json:"preparationTime"`
+	Components      []ComboComponent `json:"components"`
+	Error           string           `json:"error,omitempty"`
+}
+
+type ComboComponent struct {
+	DishID      primitive.ObjectID  `json:"dishId"`
+	DishName    string              `json:"dishName"`
+	VariantID   *primitive.ObjectID `json:"variantId,omitempty"`
+	VariantName string              `json:"variantName,omitempty"`
+	Quantity    int                 `json:"quantity"`
+}
+
+type ComboPricingBatch struct {
+	Items []ComboPrice `json:"items"`
+	Error string       `json:"error,omitempty"`
+}
+
+func GetComboPricing(ctx context.Context, items []ComboSelection) (*ComboPricingBatch, error) {
+	var response ComboPricingBatch
+	if err := Connect().Call(ctx, "combo_pricing", ComboPricingRequest{Items: items}, &response); err != nil {
+		return nil, err
+	}
+	if response.Error != "" {
+		return nil, &RemoteError{Queue: "combo_pricing", Message: response.Error}
+	}
+	if len(response.Items) != len(items) {
+		return nil, &RemoteError{Queue: "combo_pricing", Message: "reply does not match request"}
+	}
+	return &response, nil
+}
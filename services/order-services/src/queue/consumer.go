@@ -0,0 +1,170 @@
+package queue
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// attemptsHeader counts how many times a message has been handled without
+// success.
+const attemptsHeader = "x-attempts"
+
+// Consume delivers every message on queueName to handle, decoded from JSON
+// into a fresh T. Messages that fail to decode are dropped; messages whose
+// handler fails are retried at the back of the queue, and moved to the
+// queue's dead letter queue once they have failed maxDeliveryAttempts
+// times. It reconnects whenever the broker goes away and never returns, so
+// run it in its own goroutine.
+func Consume[T any](queueName string, handle func(message T) error) {
+	consume(queueName, nil, handle)
+}
+
+// ConsumeBound is Consume for a durable queue that is fed by binding it to
+// routingKeys on the topic exchange.
+func ConsumeBound[T any](queueName string, exchange string, routingKeys []string, handle func(message T) error) {
+	var bindings []binding
+	for _, key := range routingKeys {
+		bindings = append(bindings, binding{exchange, key})
+	}
+	consume(queueName, bindings, handle)
+}
+
+func consume[T any](queueName string, bindings []binding, handle func(message T) error) {
+	backoff := time.Second
+	for {
+		err := consumeOnce(queueName, bindings, func(ch *amqp.Channel, msg amqp.Delivery) {
+			var message T
+			if err := json.Unmarshal(msg.Body, &message); err != nil {
+				log.Printf("Dropping malformed %s message: %v\n", queueName, err)
+				msg.Nack(false, false)
+				return
+			}
+			if err := handle(message); err != nil {
+				log.Printf("Error handling %s message: %v\n", queueName, err)
+				time.Sleep(time.Second)
+				retryOrDeadLetter(ch, queueName, msg, err)
+				return
+			}
+			msg.Ack(false)
+		})
+		log.Printf("Consumer for %s stopped: %v\n", queueName, err)
+		time.Sleep(backoff)
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+func consumeOnce(queueName string, bindings []binding, handle func(ch *amqp.Channel, msg amqp.Delivery)) error {
+	conn, err := amqp.Dial(rabbitMqUrl())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	if _, err := ch.QueueDeclare(queueName, true, false, false, false, nil); err != nil {
+		return err
+	}
+	for _, b := range bindings {
+		if err := ch.ExchangeDeclare(b.exchange, "topic", true, false, false, false, nil); err != nil {
+			return err
+		}
+		if err := ch.QueueBind(queueName, b.routingKey, b.exchange, false, nil); err != nil {
+			return err
+		}
+	}
+	if err := ch.Qos(10, 0, false); err != nil {
+		return err
+	}
+	msgs, err := ch.Consume(queueName, "", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	log.Printf(" [*] Waiting for %s messages...\n", queueName)
+	for msg := range msgs {
+		handle(ch, msg)
+	}
+	return amqp.ErrClosed
+}
+
+// retryOrDeadLetter publishes a copy of a message whose handler failed back
+// to queueName with its attempt count raised, or to DeadLetterQueue once it
+// has used all its attempts, and then acks the original. If the copy cannot
+// be published the original is requeued instead.
+func retryOrDeadLetter(ch *amqp.Channel, queueName string, msg amqp.Delivery, handleErr error) {
+	attempts := headerInt(msg.Headers[attemptsHeader]) + 1
+	headers := amqp.Table{}
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+	headers[attemptsHeader] = int32(attempts)
+
+	target := queueName
+	if attempts >= maxDeliveryAttempts() {
+		target = DeadLetterQueue(queueName)
+		headers["x-last-error"] = handleErr.Error()
+		if _, err := ch.QueueDeclare(target, true, false, false, false, nil); err != nil {
+			log.Printf("Error declaring %s: %v\n", target, err)
+			msg.Nack(false, true)
+			return
+		}
+	}
+	err := ch.Publish("", target, false, false, amqp.Publishing{
+		Headers:       headers,
+		ContentType:   msg.ContentType,
+		DeliveryMode:  amqp.Persistent,
+		CorrelationId: msg.CorrelationId,
+		MessageId:     msg.MessageId,
+		Type:          msg.Type,
+		Timestamp:     msg.Timestamp,
+		Body:          msg.Body,
+	})
+	if err != nil {
+		log.Printf("Error republishing %s message: %v\n", queueName, err)
+		msg.Nack(false, true)
+		return
+	}
+	if target != queueName {
+		log.Printf("Moved %s message to %s after %d attempts\n", queueName, target, attempts)
+	}
+	msg.Ack(false)
+}
+
+// DeadLetterQueue is where messages from queueName that kept failing are
+// kept for inspection.
+func DeadLetterQueue(queueName string) string {
+	return queueName + ".dead"
+}
+
+// maxDeliveryAttempts is how many times a message is handled before it is
+// dead-lettered.
+func maxDeliveryAttempts() int {
+	if attempts, err := strconv.Atoi(os.Getenv("QUEUE_MAX_DELIVERY_ATTEMPTS")); err == nil && attempts > 0 {
+		return attempts
+	}
+	return 5
+}
+
+func headerInt(value any) int {
+	switch v := value.(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
@@ -0,0 +1,48 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CustomizationRequest asks dish-service to validate and price the
+// customizations submitted for each line item of an order.
+type CustomizationRequest struct {
+	Items []CustomizationItem `json:"items"`
+}
+
+type CustomizationItem struct {
+	DishID         primitive.ObjectID         `json:"dishId"`
+	Customizations map[string]json.RawMessage `json:"customizations"`
+}
+
+// PricedCustomization is dish-service's verdict on one line item, in request
+// order. Customizations has the dish's defaults filled in and Surcharge is
+// per unit. Error explains why the customizations were rejected.
+type PricedCustomization struct {
+	DishID         string         `json:"dishId"`
+	Customizations map[string]any `json:"customizations"`
+	Surcharge      float32        `json:"surcharge"`
+	Error          string         `json:"error,omitempty"`
+}
+
+type CustomizationBatch struct {
+	Items []PricedCustomization `json:"items"`
+	Error string                `json:"error,omitempty"`
+}
+
+func PriceCustomizations(ctx context.Context, items []CustomizationItem) (*CustomizationBatch, error) {
+	var response CustomizationBatch
+	if err := Connect().Call(ctx, "dish_customizations", CustomizationRequest{Items: items}, &response); err != nil {
+		return nil, err
+	}
+	if response.Error != "" {
+		return nil, &RemoteError{Queue: "dish_customizations", Message: response.Error}
+	}
+	if len(response.Items) != len(items) {
+		return nil, &RemoteError{Queue: "dish_customizations", Message: "reply does not match request"}
+	}
+	return &response, nil
+}
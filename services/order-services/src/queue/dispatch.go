@@ -0,0 +1,126 @@
+package queue
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Delivery agent message types.
+const (
+	AgentDeliveryOffer  = "delivery_offer"
+	AgentOfferWithdrawn = "offer_withdrawn"
+)
+
+const DeliveryOfferResponseQueue = "delivery_offer_responses"
+
+type RestaurantLocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// CandidateRequest asks the delivery agent service for agents near a
+// restaurant. ExcludeIDs lists agents the order was already offered to.
+type CandidateRequest struct {
+	OrderId      int                  `json:"orderId"`
+	RestaurantID primitive.ObjectID   `json:"restaurantId"`
+	Latitude     float64              `json:"latitude"`
+	Longitude    float64              `json:"longitude"`
+	RadiusKm     float64              `json:"radiusKm"`
+	Limit        int                  `json:"limit"`
+	ExcludeIDs   []primitive.ObjectID `json:"excludeIds,omitempty"`
+}
+
+// AgentCandidate is an agent's last known position and workload.
+type AgentCandidate struct {
+	AgentID      primitive.ObjectID `json:"agentId"`
+	Latitude     float64            `json:"latitude"`
+	Longitude    float64            `json:"longitude"`
+	Available    bool               `json:"available"`
+	ActiveOrders int                `json:"activeOrders"`
+}
+
+type CandidateResponse struct {
+	Agents []AgentCandidate `json:"agents"`
+	Error  string           `json:"error,omitempty"`
+}
+
+// AgentMessage is the body of every message sent to a delivery agent,
+// routed under the key returned by AgentRoutingKey.
+type AgentMessage struct {
+	MessageId string             `json:"messageId"`
+	Type      string             `json:"type"`
+	OrderId   int                `json:"orderId"`
+	AgentID   primitive.ObjectID `json:"agentId"`
+	SentAt    time.Time          `json:"sentAt"`
+	Data      any                `json:"data"`
+}
+
+// DeliveryOfferMessage offers an order to an agent until ExpiresAt.
+// AmountToCollect is non-zero for cash on delivery orders.
+type DeliveryOfferMessage struct {
+	OfferId         string             `json:"offerId"`
+	RestaurantID    primitive.ObjectID `json:"restaurantId"`
+	PickupLatitude  float64            `json:"pickupLatitude"`
+	PickupLongitude float64            `json:"pickupLongitude"`
+	DistanceKm      float64            `json:"distanceKm"`
+	Items           int                `json:"items"`
+	AmountToCollect float32            `json:"amountToCollect"`
+	ExpiresAt       time.Time          `json:"expiresAt"`
+}
+
+type OfferWithdrawnMessage struct {
+	OfferId string `json:"offerId"`
+	Reason  string `json:"reason"`
+}
+
+// DeliveryOfferResponse is an agent accepting or declining an offer.
+type DeliveryOfferResponse struct {
+	OrderId  int                `json:"orderId"`
+	OfferId  string             `json:"offerId"`
+	AgentID  primitive.ObjectID `json:"agentId"`
+	Accepted bool               `json:"accepted"`
+}
+
+func GetRestaurantLocation(ctx context.Context, restaurantId primitive.ObjectID) (*RestaurantLocation, error) {
+	var response RestaurantLocation
+	if err := Connect().Call(ctx, "restaurant_location", restaurantId, &response); err != nil {
+		return nil, err
+	}
+	if response.Error != "" {
+		return nil, &RemoteError{Queue: "restaurant_location", Message: response.Error}
+	}
+	return &response, nil
+}
+
+func GetDispatchCandidates(ctx context.Context, request CandidateRequest) ([]AgentCandidate, error) {
+	var response CandidateResponse
+	if err := Connect().Call(ctx, "delivery_agent_candidates", request, &response); err != nil {
+		return nil, err
+	}
+	if response.Error != "" {
+		return nil, &RemoteError{Queue: "delivery_agent_candidates", Message: response.Error}
+	}
+	return response.Agents, nil
+}
+
+// ConsumeDeliveryOfferResponses hands every accept/decline response to handle.
+func ConsumeDeliveryOfferResponses(handle func(response DeliveryOfferResponse) error) {
+	Consume(DeliveryOfferResponseQueue, handle)
+}
+
+func AgentRoutingKey(agentId primitive.ObjectID) string {
+	return "agent." + agentId.Hex()
+}
+
+// AgentExchange is the topic exchange delivery agent messages are published to.
+func AgentExchange() string {
+	exchange := os.Getenv("DELIVERY_AGENT_EXCHANGE")
+	if exchange == "" {
+		exchange = "delivery_agent_notifications"
+	}
+	return exchange
+}
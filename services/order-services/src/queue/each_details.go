@@ -0,0 +1,59 @@
+package queue
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EachDetails holds the details fetched one id at a time, keyed by hex id.
+// Ids whose lookup failed are listed in Missing.
+type EachDetails[T any] struct {
+	Items   map[string]T
+	Missing []string
+}
+
+// GetEachRestaurantDetails and GetEachAgentDetails make one call per id on
+// the single-item queue, as restaurants and delivery agents have no batch
+// queue. Calls run concurrently and any that fail are listed in Missing, so
+// one slow service leaves details empty instead of failing the whole page.
+func GetEachRestaurantDetails(ctx context.Context, restaurantIds []primitive.ObjectID) *EachDetails[RestaurantDetails] {
+	return fetchEach(ctx, restaurantIds, GetRestaurantDetails)
+}
+
+func GetEachAgentDetails(ctx context.Context, deliveryAgentIds []primitive.ObjectID) *EachDetails[DeliveryAgentDetails] {
+	return fetchEach(ctx, deliveryAgentIds, GetAgentDetails)
+}
+
+// maxConcurrentLookups caps the single-item calls fetchEach has in flight.
+const maxConcurrentLookups = 8
+
+func fetchEach[T any](ctx context.Context, ids []primitive.ObjectID, fetch func(context.Context, primitive.ObjectID) (*T, error)) *EachDetails[T] {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		details = &EachDetails[T]{Items: map[string]T{}}
+		slots   = make(chan struct{}, maxConcurrentLookups)
+	)
+	for _, id := range ids {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(id primitive.ObjectID) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			item, err := fetch(ctx, id)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.Printf("Error fetching details of %s: %v\n", id.Hex(), err)
+				details.Missing = append(details.Missing, id.Hex())
+				return
+			}
+			details.Items[id.Hex()] = *item
+		}(id)
+	}
+	wg.Wait()
+	return details
+}
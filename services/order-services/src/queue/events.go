@@ -0,0 +1,170 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ErrNotConfirmed is returned when the broker rejects a published event.
+var ErrNotConfirmed = errors.New("broker did not confirm the event")
+
+// EventPublisher publishes events to topic exchanges on its own channel in
+// confirm mode, so a nil error means the broker has taken responsibility
+// for the message. The connection is opened on first use and reopened
+// after any failure.
+type EventPublisher struct {
+	url string
+
+	mu             sync.Mutex // guards conn, ch and the declared sets, and serialises publishes
+	conn           *amqp.Connection
+	ch             *amqp.Channel
+	declared       map[string]bool
+	declaredQueues map[string]bool
+}
+
+func NewEventPublisher(url string) *EventPublisher {
+	return &EventPublisher{url: url}
+}
+
+// Publish sends body to exchange under routingKey and waits for the broker
+// to confirm it. messageId is passed through so consumers can drop
+// redelivered events.
+func (p *EventPublisher) Publish(ctx context.Context, exchange string, routingKey string, messageId string, body []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.ch == nil || p.ch.IsClosed() {
+		if err := p.connect(); err != nil {
+			return fmt.Errorf("%s: %w", exchange, err)
+		}
+	}
+	if !p.declared[exchange] {
+		if err := p.ch.ExchangeDeclare(exchange, "topic", true, false, false, false, nil); err != nil {
+			p.disconnect()
+			return fmt.Errorf("%s: declare exchange: %w", exchange, err)
+		}
+		p.declared[exchange] = true
+	}
+	if err := p.publish(ctx, exchange, routingKey, messageId, body); err != nil {
+		return fmt.Errorf("%s: %w", exchange, err)
+	}
+	return nil
+}
+
+// PublishToQueue sends body straight to queueName through the default
+// exchange and waits for the broker to confirm it, for consumers that read
+// a plain queue rather than binding to an exchange.
+func (p *EventPublisher) PublishToQueue(ctx context.Context, queueName string, messageId string, body []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.ch == nil || p.ch.IsClosed() {
+		if err := p.connect(); err != nil {
+			return fmt.Errorf("%s: %w", queueName, err)
+		}
+	}
+	if !p.declaredQueues[queueName] {
+		if _, err := p.ch.QueueDeclare(queueName, true, false, false, false, nil); err != nil {
+			p.disconnect()
+			return fmt.Errorf("%s: declare queue: %w", queueName, err)
+		}
+		p.declaredQueues[queueName] = true
+	}
+	if err := p.publish(ctx, "", queueName, messageId, body); err != nil {
+		return fmt.Errorf("%s: %w", queueName, err)
+	}
+	return nil
+}
+
+// publish sends one message on the open channel and waits for its confirm.
+// p.mu must be held.
+func (p *EventPublisher) publish(ctx context.Context, exchange string, routingKey string, messageId string, body []byte) error {
+	confirmation, err := p.ch.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    messageId,
+		Type:         routingKey,
+		Timestamp:    time.Now(),
+		Body:         body,
+	})
+	if err != nil {
+		p.disconnect()
+		return fmt.Errorf("publish: %w", err)
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		// the confirm may still arrive later; start over on a fresh channel
+		// so it cannot be mistaken for the next publish's
+		p.disconnect()
+		return err
+	}
+	if !acked {
+		return ErrNotConfirmed
+	}
+	return nil
+}
+
+func (p *EventPublisher) connect() error {
+	p.disconnect()
+	conn, err := amqp.Dial(p.url)
+	if err != nil {
+		return err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if err := ch.Confirm(false); err != nil {
+		conn.Close()
+		return err
+	}
+	p.conn = conn
+	p.ch = ch
+	p.declared = map[string]bool{}
+	p.declaredQueues = map[string]bool{}
+	return nil
+}
+
+func (p *EventPublisher) disconnect() {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.conn = nil
+	p.ch = nil
+}
+
+// Close releases the publisher's connection.
+func (p *EventPublisher) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.disconnect()
+}
+
+var (
+	eventPublisher     *EventPublisher
+	eventPublisherOnce sync.Once
+)
+
+// PublishEvent publishes an already encoded event to exchange and waits for
+// the broker to confirm it.
+func PublishEvent(ctx context.Context, exchange string, routingKey string, eventId string, body []byte) error {
+	eventPublisherOnce.Do(func() {
+		eventPublisher = NewEventPublisher(rabbitMqUrl())
+	})
+	return eventPublisher.Publish(ctx, exchange, routingKey, eventId, body)
+}
+
+// PublishToQueue publishes an already encoded message straight to queueName
+// and waits for the broker to confirm it.
+func PublishToQueue(ctx context.Context, queueName string, messageId string, body []byte) error {
+	eventPublisherOnce.Do(func() {
+		eventPublisher = NewEventPublisher(rabbitMqUrl())
+	})
+	return eventPublisher.PublishToQueue(ctx, queueName, messageId, body)
+}
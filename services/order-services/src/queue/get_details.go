@@ -1,15 +1,9 @@
 package queue
 
 import (
-	"errors"
-	"log"
-	"os"
-	"time"
+	"context"
 
-	"encoding/json"
-	amqp "github.com/rabbitmq/amqp091-go"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"github.com/google/uuid"
 )
 
 type DishDetails struct {
@@ -17,6 +11,7 @@ type DishDetails struct {
 	Image 	string `json:"dish_image"`
 	Category string `json:"dish_category"`
 	IsVeg	bool `json:"dish_is_veg"`
+	Error	string `json:"error,omitempty"`
 }
 
 type RestaurantDetails struct {
@@ -31,252 +26,29 @@ type DeliveryAgentDetails struct {
 	Image 	string `json:"delivery_agent_image"`
 }
 
-func failOnError(err error, msg string) {
-	if err != nil {
-	  log.Panicf("%s: %s", msg, err)
+func GetDishDetails(ctx context.Context, dishId primitive.ObjectID) (*DishDetails, error) {
+	var response DishDetails
+	if err := Connect().Call(ctx, "dish_details", dishId, &response); err != nil {
+		return nil, err
 	}
-  }
-
-func GetDishDetails(dishId primitive.ObjectID) (*DishDetails, error) {
-	rabbitMqUrl := os.Getenv("RABBITMQ_URL")
-	if rabbitMqUrl == "" {
-		rabbitMqUrl = "amqp://localhost"
-	}
-	conn, err := amqp.Dial(rabbitMqUrl)
-	failOnError(err, "Failed to connect to RabbitMQ")
-	defer conn.Close()
-
-	ch, err := conn.Channel()
-	failOnError(err, "Failed to open a channel")
-	defer ch.Close()
-
-	requestQueue := "dish_details"
-	responseQueue := "dish_details_response"
-
-	_, err = ch.QueueDeclare(
-		requestQueue,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	failOnError(err, "Failed to declare a request queue")
-
-	_, err = ch.QueueDeclare(
-		responseQueue,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	failOnError(err, "Failed to declare a response queue")
-
-	correlationID := uuid.New().String()
-
-	request := dishId
-
-	requestBody, err := json.Marshal(request)
-	failOnError(err, "Failed to marshal request body")
-
-	responseChan := make(chan *DishDetails)
-
-	go func ()  {
-		msgs, err := ch.Consume(responseQueue, "", false, false, false, false, nil)
-		failOnError(err, "Failed to consume message from queue")
-		for msg := range msgs {
-			if msg.CorrelationId == correlationID {
-				var response DishDetails
-				if err := json.Unmarshal(msg.Body, &response); err != nil {
-					log.Println("Failed to unmarshal response:", err)
-					continue
-				}
-				ch.Ack(msg.DeliveryTag, false)
-				responseChan <- &response
-				return
-			}
-		}
-	}()
-
-	err = ch.Publish(
-		"", requestQueue, false, false,
-		amqp.Publishing{
-			CorrelationId: correlationID,
-			ReplyTo:       responseQueue,
-			ContentType:   "application/json",
-			Body:          requestBody,
-		},
-	)
-	failOnError(err, "Failed to push the message to queue")
-
-	select {
-	case response := <-responseChan:
-		return response, nil
-	case <-time.After(9 * time.Second):
-		return nil, errors.New("token generation timed out")
+	if response.Error != "" {
+		return nil, &RemoteError{Queue: "dish_details", Message: response.Error}
 	}
-
+	return &response, nil
 }
 
-func GetRestaurantDetails(restaurantId primitive.ObjectID) (*RestaurantDetails, error) {
-	rabbitMqUrl := os.Getenv("RABBITMQ_URL")
-	if rabbitMqUrl == "" {
-		rabbitMqUrl = "amqp://localhost"
-	}
-	conn, err := amqp.Dial(rabbitMqUrl)
-	failOnError(err, "Failed to connect to RabbitMQ")
-	defer conn.Close()
-
-	ch, err := conn.Channel()
-	failOnError(err, "Failed to open a channel")
-	defer ch.Close()
-
-	requestQueue := "restaurant_details"
-	responseQueue := "restaurant_details_response"
-
-	_, err = ch.QueueDeclare(
-		requestQueue,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	failOnError(err, "Failed to declare a request queue")
-
-	_, err = ch.QueueDeclare(
-		responseQueue,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	failOnError(err, "Failed to declare a response queue")
-
-	correlationID := uuid.New().String()
-
-	request := restaurantId
-
-	requestBody, err := json.Marshal(request)
-	failOnError(err, "Failed to marshal request body")
-
-	responseChan := make(chan *RestaurantDetails)
-
-	go func ()  {
-		msgs, err := ch.Consume(responseQueue, "", false, false, false, false, nil)
-		failOnError(err, "Failed to consume message from queue")
-		for msg := range msgs {
-			if msg.CorrelationId == correlationID {
-				var response RestaurantDetails
-				if err := json.Unmarshal(msg.Body, &response); err != nil {
-					log.Println("Failed to unmarshal response:", err)
-					continue
-				}
-				ch.Ack(msg.DeliveryTag, false)
-				responseChan <- &response
-				return
-			}
-		}
-	}()
-
-	err = ch.Publish(
-		"", requestQueue, false, false,
-		amqp.Publishing{
-			CorrelationId: correlationID,
-			ReplyTo:       responseQueue,
-			ContentType:   "application/json",
-			Body:          requestBody,
-		},
-	)
-	failOnError(err, "Failed to push the message to queue")
-
-	select {
-	case response := <-responseChan:
-		return response, nil
-	case <-time.After(9 * time.Second):
-		return nil, errors.New("token generation timed out")
+func GetRestaurantDetails(ctx context.Context, restaurantId primitive.ObjectID) (*RestaurantDetails, error) {
+	var response RestaurantDetails
+	if err := Connect().Call(ctx, "restaurant_details", restaurantId, &response); err != nil {
+		return nil, err
 	}
+	return &response, nil
 }
 
-func GetAgentDetails(deliveryAgentId primitive.ObjectID) (*DeliveryAgentDetails, error) {
-	rabbitMqUrl := os.Getenv("RABBITMQ_URL")
-	if rabbitMqUrl == "" {
-		rabbitMqUrl = "amqp://localhost"
+func GetAgentDetails(ctx context.Context, deliveryAgentId primitive.ObjectID) (*DeliveryAgentDetails, error) {
+	var response DeliveryAgentDetails
+	if err := Connect().Call(ctx, "delivery_agent_details", deliveryAgentId, &response); err != nil {
+		return nil, err
 	}
-	conn, err := amqp.Dial(rabbitMqUrl)
-	failOnError(err, "Failed to connect to RabbitMQ")
-	defer conn.Close()
-
-	ch, err := conn.Channel()
-	failOnError(err, "Failed to open a channel")
-	defer ch.Close()
-
-	requestQueue := "delivery_agent_details"
-	responseQueue := "delivery_agent_details_response"
-
-	_, err = ch.QueueDeclare(
-		requestQueue,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	failOnError(err, "Failed to declare a request queue")
-
-	_, err = ch.QueueDeclare(
-		responseQueue,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	failOnError(err, "Failed to declare a response queue")
-
-	correlationID := uuid.New().String()
-
-	request := deliveryAgentId
-
-	requestBody, err := json.Marshal(request)
-	failOnError(err, "Failed to marshal request body")
-
-	responseChan := make(chan *DeliveryAgentDetails)
-
-	go func ()  {
-		msgs, err := ch.Consume(responseQueue, "", false, false, false, false, nil)
-		failOnError(err, "Failed to consume message from queue")
-		for msg := range msgs {
-			if msg.CorrelationId == correlationID {
-				var response DeliveryAgentDetails
-				if err := json.Unmarshal(msg.Body, &response); err != nil {
-					log.Println("Failed to unmarshal response:", err)
-					continue
-				}
-				ch.Ack(msg.DeliveryTag, false)
-				responseChan <- &response
-				return
-			}
-		}
-	}()
-
-	err = ch.Publish(
-		"", requestQueue, false, false,
-		amqp.Publishing{
-			CorrelationId: correlationID,
-			ReplyTo:       responseQueue,
-			ContentType:   "application/json",
-			Body:          requestBody,
-		},
-	)
-	failOnError(err, "Failed to push the message to queue")
-
-	select {
-	case response := <-responseChan:
-		return response, nil
-	case <-time.After(9 * time.Second):
-		return nil, errors.New("token generation timed out")
-	}
-}
\ No newline at end of file
+	return &response, nil
+}
@@ -0,0 +1,98 @@
+package queue
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OrderEvent is the body of every message on the order events exchange.
+// EventId is also sent as the AMQP message id; the relay delivers at least
+// once, so consumers should use it to drop duplicates.
+type OrderEvent struct {
+	EventId    string    `json:"eventId"`
+	Type       string    `json:"type"`
+	OrderId    int       `json:"orderId"`
+	OccurredAt time.Time `json:"occurredAt"`
+	Data       any       `json:"data"`
+}
+
+// ReceivedOrderEvent is an OrderEvent as decoded by a consumer, with Data
+// left encoded until its type is known.
+type ReceivedOrderEvent struct {
+	EventId    string          `json:"eventId"`
+	Type       string          `json:"type"`
+	OrderId    int             `json:"orderId"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// ConsumeOrderEvents delivers order events of the given types from a durable
+// queue, so each event is handled once across all instances.
+func ConsumeOrderEvents(queueName string, eventTypes []string, handle func(event ReceivedOrderEvent) error) {
+	ConsumeBound(queueName, OrderEventsExchange(), eventTypes, handle)
+}
+
+type OrderCreatedData struct {
+	CustomerID   primitive.ObjectID `json:"customerId"`
+	RestaurantID primitive.ObjectID `json:"restaurantId"`
+	TotalPrice   float32            `json:"totalPrice"`
+	PaymentMode  string             `json:"paymentMode"`
+	PaymentId    string             `json:"paymentId,omitempty"`
+	Status       string             `json:"status"`
+}
+
+type OrderStatusChangedData struct {
+	CustomerID      primitive.ObjectID  `json:"customerId"`
+	RestaurantID    primitive.ObjectID  `json:"restaurantId"`
+	DeliveryAgentID *primitive.ObjectID `json:"deliveryAgentId,omitempty"`
+	From            string              `json:"from"`
+	To              string              `json:"to"`
+	Actor           string              `json:"actor"`
+	ActorID         *primitive.ObjectID `json:"actorId,omitempty"`
+}
+
+type OrderPaymentUpdatedData struct {
+	PaymentId     string  `json:"paymentId"`
+	PaymentStatus string  `json:"paymentStatus"`
+	Amount        float32 `json:"amount"`
+}
+
+type OrderAgentAssignedData struct {
+	DeliveryAgentID primitive.ObjectID `json:"deliveryAgentId"`
+	OfferId         string             `json:"offerId"`
+	Attempts        int                `json:"attempts"`
+}
+
+type OrderETAUpdatedData struct {
+	EstimatedDeliveryTime time.Time `json:"estimatedDeliveryTime"`
+	Trigger               string    `json:"trigger"`
+	Status                string    `json:"status"`
+}
+
+type OrderRefundRequestedData struct {
+	RefundId       string  `json:"refundId"`
+	Amount         float32 `json:"amount"`
+	RefundedAmount float32 `json:"refundedAmount"`
+	Reason         string  `json:"reason"`
+}
+
+// OrderRefundFailedData is sent when a refund is given up on. Its amount
+// no longer counts towards RefundedAmount.
+type OrderRefundFailedData struct {
+	RefundId       string  `json:"refundId"`
+	Amount         float32 `json:"amount"`
+	RefundedAmount float32 `json:"refundedAmount"`
+	Reason         string  `json:"reason,omitempty"`
+}
+
+// OrderEventsExchange is the topic exchange order events are published to.
+func OrderEventsExchange() string {
+	exchange := os.Getenv("ORDER_EVENTS_EXCHANGE")
+	if exchange == "" {
+		exchange = "order_events"
+	}
+	return exchange
+}
@@ -1,5 +1,38 @@
 package queue
 
-func InitiatePayment() (any, error) {
-	return "hello", nil
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PaymentIntentQueue = "payment_intents"
+	PaymentEventQueue  = "payment_events"
+)
+
+// PaymentIntent asks the payment service to collect Amount for an order. It
+// is sent to PaymentIntentQueue through the outbox.
+type PaymentIntent struct {
+	PaymentId  string             `json:"paymentId"`
+	OrderId    int                `json:"orderId"`
+	CustomerID primitive.ObjectID `json:"customerId"`
+	Amount     float32            `json:"amount"`
+	Mode       string             `json:"mode"`
+	ExpiresAt  *time.Time         `json:"expiresAt,omitempty"`
+}
+
+// PaymentEvent is published by the payment service once a payment settles.
+// Status is "succeeded" or "failed".
+type PaymentEvent struct {
+	PaymentId   string `json:"paymentId"`
+	OrderId     int    `json:"orderId"`
+	Status      string `json:"status"`
+	ProviderRef string `json:"providerRef,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// ConsumePaymentEvents hands every payment event to handle.
+func ConsumePaymentEvents(handle func(event PaymentEvent) error) {
+	Consume(PaymentEventQueue, handle)
 }
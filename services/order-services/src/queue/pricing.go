@@ -0,0 +1,45 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DishPrice is dish-service's authoritative price and availability for a
+// dish. PreparationTime is in minutes. Dishes with variants must be ordered
+// by variant and are priced from Variants, keyed by variant id.
+// AvailableFrom is set when the dish is outside its menu schedule.
+type DishPrice struct {
+	Price           float32                 `json:"price"`
+	RestaurantID    string                  `json:"restaurantId"`
+	Available       bool                    `json:"available"`
+	AvailableFrom   *time.Time              `json:"availableFrom,omitempty"`
+	PreparationTime int                     `json:"preparationTime"`
+	Variants        map[string]VariantPrice `json:"variants,omitempty"`
+}
+
+type VariantPrice struct {
+	Name            string  `json:"name"`
+	Price           float32 `json:"price"`
+	Available       bool    `json:"available"`
+	PreparationTime int     `json:"preparationTime"`
+}
+
+type DishPricingBatch struct {
+	Items   map[string]DishPrice `json:"items"`
+	Missing []string             `json:"missing,omitempty"`
+	Error   string               `json:"error,omitempty"`
+}
+
+func GetDishPricing(ctx context.Context, dishIds []primitive.ObjectID) (*DishPricingBatch, error) {
+	var response DishPricingBatch
+	if err := Connect().Call(ctx, "dish_pricing", BatchDetailsRequest{IDs: dishIds}, &response); err != nil {
+		return nil, err
+	}
+	if response.Error != "" {
+		return nil, &RemoteError{Queue: "dish_pricing", Message: response.Error}
+	}
+	return &response, nil
+}
@@ -0,0 +1,32 @@
+package queue
+
+const (
+	RefundRequestQueue = "payment_refunds"
+	RefundEventQueue   = "refund_events"
+)
+
+// RefundRequest asks the payment service to return Amount of a payment. It
+// is sent to RefundRequestQueue through the outbox, and sent again if no
+// answer arrives in time, so the payment service should use RefundId to
+// drop repeats.
+type RefundRequest struct {
+	RefundId  string  `json:"refundId"`
+	PaymentId string  `json:"paymentId"`
+	OrderId   int     `json:"orderId"`
+	Amount    float32 `json:"amount"`
+	Reason    string  `json:"reason"`
+}
+
+// RefundEvent is published by the payment service once a refund settles.
+// Status is "completed" or "failed".
+type RefundEvent struct {
+	RefundId    string `json:"refundId"`
+	Status      string `json:"status"`
+	ProviderRef string `json:"providerRef,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// ConsumeRefundEvents hands every refund event to handle.
+func ConsumeRefundEvents(handle func(event RefundEvent) error) {
+	Consume(RefundEventQueue, handle)
+}
@@ -0,0 +1,100 @@
+package queue
+
+import (
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Restaurant message types.
+const (
+	RestaurantNewOrder       = "new_order"
+	RestaurantOrderCancelled = "order_cancelled"
+	RestaurantCustomerNote   = "customer_note"
+)
+
+const RestaurantResponseQueue = "restaurant_responses"
+
+// RestaurantMessage is the body of every message sent to a restaurant. It is
+// published to the restaurant notifications exchange under the routing key
+// returned by RestaurantRoutingKey, so each restaurant can bind only its own.
+type RestaurantMessage struct {
+	MessageId    string             `json:"messageId"`
+	Type         string             `json:"type"`
+	OrderId      int                `json:"orderId"`
+	RestaurantID primitive.ObjectID `json:"restaurantId"`
+	SentAt       time.Time          `json:"sentAt"`
+	Data         any                `json:"data"`
+}
+
+type RestaurantOrderItem struct {
+	DishID         primitive.ObjectID         `json:"dishId"`
+	VariantName    string                     `json:"variantName,omitempty"`
+	ComboName      string                     `json:"comboName,omitempty"`
+	Components     []RestaurantComboComponent `json:"components,omitempty"`
+	Quantity       int                        `json:"quantity"`
+	UnitPrice      float32                    `json:"unitPrice"`
+	Surcharge      float32                    `json:"surcharge"`
+	Price          float32                    `json:"price"`
+	Customizations map[string]any             `json:"customizations"`
+}
+
+// RestaurantComboComponent is a dish to prepare for a combo line item.
+// Quantity covers every combo on the line.
+type RestaurantComboComponent struct {
+	DishID      primitive.ObjectID `json:"dishId"`
+	VariantName string             `json:"variantName,omitempty"`
+	Quantity    int                `json:"quantity"`
+}
+
+type RestaurantNote struct {
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NewOrderMessage asks the restaurant to accept or reject an order before
+// RespondBy, after which it is rejected automatically.
+type NewOrderMessage struct {
+	Items       []RestaurantOrderItem `json:"items"`
+	Notes       []RestaurantNote      `json:"notes,omitempty"`
+	TotalPrice  float32               `json:"totalPrice"`
+	PaymentMode string                `json:"paymentMode"`
+	OrderTime   time.Time             `json:"orderTime"`
+	RespondBy   time.Time             `json:"respondBy"`
+}
+
+type OrderCancelledMessage struct {
+	CancelledBy string `json:"cancelledBy"`
+	Reason      string `json:"reason"`
+}
+
+type CustomerNoteMessage struct {
+	Note RestaurantNote `json:"note"`
+}
+
+// RestaurantResponse is a restaurant accepting or rejecting a new order.
+type RestaurantResponse struct {
+	OrderId      int                `json:"orderId"`
+	RestaurantID primitive.ObjectID `json:"restaurantId"`
+	Accepted     bool               `json:"accepted"`
+	Reason       string             `json:"reason,omitempty"`
+}
+
+// ConsumeRestaurantResponses hands every accept/reject response to handle.
+func ConsumeRestaurantResponses(handle func(response RestaurantResponse) error) {
+	Consume(RestaurantResponseQueue, handle)
+}
+
+func RestaurantRoutingKey(restaurantId primitive.ObjectID) string {
+	return "restaurant." + restaurantId.Hex()
+}
+
+// RestaurantExchange is the topic exchange restaurant messages are published to.
+func RestaurantExchange() string {
+	exchange := os.Getenv("RESTAURANT_EXCHANGE")
+	if exchange == "" {
+		exchange = "restaurant_notifications"
+	}
+	return exchange
+}
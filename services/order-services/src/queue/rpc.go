@@ -0,0 +1,301 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// directReplyTo is RabbitMQ's pseudo-queue for RPC replies. Replies are routed
+// straight back to the consuming channel, so no reply queue is ever declared.
+const directReplyTo = "amq.rabbitmq.reply-to"
+
+// DefaultTimeout bounds a Call whose context carries no deadline.
+const DefaultTimeout = 9 * time.Second
+
+var (
+	ErrNotConnected = errors.New("rabbitmq connection is not available")
+	ErrTimeout      = errors.New("rpc request timed out")
+	ErrClosed       = errors.New("rpc client closed")
+)
+
+// RemoteError is returned when a responder answers with an error payload.
+type RemoteError struct {
+	Queue   string
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Queue, e.Message)
+}
+
+type rpcReply struct {
+	body []byte
+	err  error
+}
+
+// RPCClient multiplexes request/reply calls over a single long-lived
+// connection, matching replies to callers by correlation id.
+type RPCClient struct {
+	url string
+
+	mu       sync.Mutex // guards conn, ch and declared
+	conn     *amqp.Connection
+	ch       *amqp.Channel
+	declared map[string]bool
+
+	pendingMu sync.Mutex
+	pending   map[string]chan rpcReply
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewRPCClient returns a client that connects to url in the background and
+// reconnects with backoff whenever the connection drops.
+func NewRPCClient(url string) *RPCClient {
+	c := &RPCClient{
+		url:     url,
+		pending: map[string]chan rpcReply{},
+		done:    make(chan struct{}),
+	}
+	go c.run()
+	return c
+}
+
+func (c *RPCClient) run() {
+	backoff := time.Second
+	for {
+		connClosed, chClosed, err := c.connect()
+		if err != nil {
+			log.Println("RPC client failed to connect to RabbitMQ:", err)
+			select {
+			case <-c.done:
+				return
+			case <-time.After(backoff):
+			}
+			if backoff < 30*time.Second {
+				backoff *= 2
+			}
+			continue
+		}
+		backoff = time.Second
+		log.Println("RPC client connected to RabbitMQ")
+
+		select {
+		case <-c.done:
+			c.disconnect(ErrClosed)
+			return
+		case amqpErr := <-connClosed:
+			log.Println("RPC client lost RabbitMQ connection:", amqpErr)
+			c.disconnect(ErrNotConnected)
+		case amqpErr := <-chClosed:
+			log.Println("RPC client lost RabbitMQ channel:", amqpErr)
+			c.disconnect(ErrNotConnected)
+		}
+	}
+}
+
+// connect dials the broker and starts consuming direct replies. The returned
+// channels fire when the connection or the channel closes.
+func (c *RPCClient) connect() (<-chan *amqp.Error, <-chan *amqp.Error, error) {
+	conn, err := amqp.Dial(c.url)
+	if err != nil {
+		return nil, nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	replies, err := ch.Consume(directReplyTo, "", true, false, false, false, nil)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	chClosed := ch.NotifyClose(make(chan *amqp.Error, 1))
+
+	c.mu.Lock()
+	c.conn = conn
+	c.ch = ch
+	c.declared = map[string]bool{}
+	c.mu.Unlock()
+
+	go c.dispatch(replies)
+	return connClosed, chClosed, nil
+}
+
+// disconnect drops the current connection and fails every in-flight call.
+func (c *RPCClient) disconnect(reason error) {
+	c.mu.Lock()
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	c.conn = nil
+	c.ch = nil
+	c.mu.Unlock()
+
+	c.pendingMu.Lock()
+	for id, waiter := range c.pending {
+		waiter <- rpcReply{err: reason}
+		delete(c.pending, id)
+	}
+	c.pendingMu.Unlock()
+}
+
+func (c *RPCClient) dispatch(replies <-chan amqp.Delivery) {
+	for msg := range replies {
+		c.pendingMu.Lock()
+		waiter, ok := c.pending[msg.CorrelationId]
+		delete(c.pending, msg.CorrelationId)
+		c.pendingMu.Unlock()
+		if !ok {
+			log.Println("Dropping RPC reply with unknown correlation id:", msg.CorrelationId)
+			continue
+		}
+		waiter <- rpcReply{body: msg.Body}
+	}
+}
+
+// Call publishes request as JSON to requestQueue and decodes the reply into
+// response. It fails with ErrTimeout once ctx (or DefaultTimeout) expires.
+func (c *RPCClient) Call(ctx context.Context, requestQueue string, request any, response any) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("%s: encode request: %w", requestQueue, err)
+	}
+
+	correlationID := uuid.New().String()
+	waiter := make(chan rpcReply, 1)
+	c.pendingMu.Lock()
+	c.pending[correlationID] = waiter
+	c.pendingMu.Unlock()
+	defer func() {
+		c.pendingMu.Lock()
+		delete(c.pending, correlationID)
+		c.pendingMu.Unlock()
+	}()
+
+	if err := c.publish(ctx, requestQueue, correlationID, body); err != nil {
+		return err
+	}
+
+	select {
+	case reply := <-waiter:
+		if reply.err != nil {
+			return fmt.Errorf("%s: %w", requestQueue, reply.err)
+		}
+		if err := json.Unmarshal(reply.body, response); err != nil {
+			return fmt.Errorf("%s: decode response: %w", requestQueue, err)
+		}
+		return nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%s: %w", requestQueue, ErrTimeout)
+		}
+		return fmt.Errorf("%s: %w", requestQueue, ctx.Err())
+	}
+}
+
+func (c *RPCClient) publish(ctx context.Context, requestQueue string, correlationID string, body []byte) error {
+	return c.send(ctx, requestQueue, amqp.Publishing{
+		CorrelationId: correlationID,
+		ReplyTo:       directReplyTo,
+		ContentType:   "application/json",
+		Body:          body,
+	})
+}
+
+// Publish sends message as JSON to queueName without waiting for a reply.
+func (c *RPCClient) Publish(ctx context.Context, queueName string, message any) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("%s: encode message: %w", queueName, err)
+	}
+	return c.send(ctx, queueName, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+		Body:         body,
+	})
+}
+
+// send declares queueName on first use and publishes msg to it.
+func (c *RPCClient) send(ctx context.Context, queueName string, msg amqp.Publishing) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ch == nil {
+		return fmt.Errorf("%s: %w", queueName, ErrNotConnected)
+	}
+	if !c.declared[queueName] {
+		if _, err := c.ch.QueueDeclare(queueName, true, false, false, false, nil); err != nil {
+			return fmt.Errorf("%s: declare queue: %w", queueName, err)
+		}
+		c.declared[queueName] = true
+	}
+	if err := c.ch.PublishWithContext(ctx, "", queueName, false, false, msg); err != nil {
+		return fmt.Errorf("%s: publish: %w", queueName, err)
+	}
+	return nil
+}
+
+// Close stops reconnecting and fails any in-flight calls with ErrClosed.
+func (c *RPCClient) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.disconnect(ErrClosed)
+	})
+}
+
+var (
+	defaultClient     *RPCClient
+	defaultClientOnce sync.Once
+)
+
+// Connect starts the shared RPC client used by this package. Calls made
+// before Connect start it lazily.
+func Connect() *RPCClient {
+	defaultClientOnce.Do(func() {
+		defaultClient = NewRPCClient(rabbitMqUrl())
+	})
+	return defaultClient
+}
+
+// Close shuts down the shared RPC client and event publisher.
+func Close() {
+	if defaultClient != nil {
+		defaultClient.Close()
+	}
+	if eventPublisher != nil {
+		eventPublisher.Close()
+	}
+}
+
+func rabbitMqUrl() string {
+	rabbitMqUrl := os.Getenv("RABBITMQ_URL")
+	if rabbitMqUrl == "" {
+		rabbitMqUrl = "amqp://localhost"
+	}
+	return rabbitMqUrl
+}
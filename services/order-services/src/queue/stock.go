@@ -0,0 +1,68 @@
+package queue
+
+import (
+	"context"
+	"os"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StockItem is a quantity of a dish, or of one of its variants, to reserve.
+type StockItem struct {
+	DishID    primitive.ObjectID  `json:"dishId"`
+	VariantID *primitive.ObjectID `json:"variantId,omitempty"`
+	Quantity  int                 `json:"quantity"`
+}
+
+// StockReservationRequest asks dish-service to take stock for a new order.
+// Sending the same ReservationId again returns the first answer instead of
+// taking the stock twice.
+type StockReservationRequest struct {
+	ReservationId string      `json:"reservationId"`
+	Items         []StockItem `json:"items"`
+}
+
+// StockReservationReply says whether every item was reserved. Nothing is
+// reserved when Reserved is false and Message says why.
+type StockReservationReply struct {
+	Reserved  bool            `json:"reserved"`
+	Message   string          `json:"message,omitempty"`
+	Shortages []StockShortage `json:"shortages,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+type StockShortage struct {
+	DishID    primitive.ObjectID  `json:"dishId"`
+	VariantID *primitive.ObjectID `json:"variantId,omitempty"`
+	Name      string              `json:"name"`
+	Remaining int                 `json:"remaining"`
+}
+
+// StockReleaseMessage asks dish-service to give back the stock held by a
+// reservation. It is published to the stock exchange under StockReleaseKey.
+type StockReleaseMessage struct {
+	MessageId     string `json:"messageId"`
+	ReservationId string `json:"reservationId"`
+	OrderId       int    `json:"orderId,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+const StockReleaseKey = "stock.release"
+
+func StockExchange() string {
+	if exchange := os.Getenv("DISH_STOCK_EXCHANGE"); exchange != "" {
+		return exchange
+	}
+	return "dish_stock"
+}
+
+func ReserveStock(ctx context.Context, request StockReservationRequest) (*StockReservationReply, error) {
+	var response StockReservationReply
+	if err := Connect().Call(ctx, "dish_stock_reserve", request, &response); err != nil {
+		return nil, err
+	}
+	if response.Error != "" {
+		return nil, &RemoteError{Queue: "dish_stock_reserve", Message: response.Error}
+	}
+	return &response, nil
+}
@@ -0,0 +1,143 @@
+package queue
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type binding struct {
+	exchange   string
+	routingKey string
+}
+
+// Subscriber receives messages from topic exchanges on a private,
+// auto-deleted queue, so every instance of the service gets its own copy.
+// Bindings are reference counted: the queue is bound on the first Bind for
+// a key and unbound on the matching last Unbind. Bindings are restored
+// whenever the connection is re-established.
+type Subscriber struct {
+	url    string
+	handle func(exchange string, routingKey string, body []byte)
+
+	mu       sync.Mutex // guards ch, queue and bindings
+	ch       *amqp.Channel
+	queue    string
+	bindings map[binding]int
+}
+
+// NewSubscriber connects in the background and passes every delivery to
+// handle, one at a time. handle must not block.
+func NewSubscriber(url string, handle func(exchange string, routingKey string, body []byte)) *Subscriber {
+	s := &Subscriber{
+		url:      url,
+		handle:   handle,
+		bindings: map[binding]int{},
+	}
+	go s.run()
+	return s
+}
+
+// Bind starts delivering messages published to exchange under routingKey,
+// which may use topic wildcards.
+func (s *Subscriber) Bind(exchange string, routingKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := binding{exchange, routingKey}
+	s.bindings[b]++
+	if s.bindings[b] == 1 && s.ch != nil {
+		if err := s.bind(s.ch, b); err != nil {
+			log.Printf("Error binding %s to %s: %v\n", routingKey, exchange, err)
+		}
+	}
+}
+
+// Unbind releases one Bind of routingKey on exchange.
+func (s *Subscriber) Unbind(exchange string, routingKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := binding{exchange, routingKey}
+	if s.bindings[b] == 0 {
+		return
+	}
+	s.bindings[b]--
+	if s.bindings[b] > 0 {
+		return
+	}
+	delete(s.bindings, b)
+	if s.ch != nil {
+		if err := s.ch.QueueUnbind(s.queue, routingKey, exchange, nil); err != nil {
+			log.Printf("Error unbinding %s from %s: %v\n", routingKey, exchange, err)
+		}
+	}
+}
+
+// Subscribe starts a Subscriber on the shared RabbitMQ server.
+func Subscribe(handle func(exchange string, routingKey string, body []byte)) *Subscriber {
+	return NewSubscriber(rabbitMqUrl(), handle)
+}
+
+func (s *Subscriber) bind(ch *amqp.Channel, b binding) error {
+	if err := ch.ExchangeDeclare(b.exchange, "topic", true, false, false, false, nil); err != nil {
+		return err
+	}
+	return ch.QueueBind(s.queue, b.routingKey, b.exchange, false, nil)
+}
+
+func (s *Subscriber) run() {
+	backoff := time.Second
+	for {
+		err := s.subscribeOnce(func() { backoff = time.Second })
+		log.Println("Subscriber stopped:", err)
+		time.Sleep(backoff)
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+func (s *Subscriber) subscribeOnce(connected func()) error {
+	conn, err := amqp.Dial(s.url)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	q, err := ch.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		return err
+	}
+	msgs, err := ch.Consume(q.Name, "", true, true, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.ch = ch
+	s.queue = q.Name
+	for b := range s.bindings {
+		if err := s.bind(ch, b); err != nil {
+			s.ch = nil
+			s.mu.Unlock()
+			return err
+		}
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.ch = nil
+		s.mu.Unlock()
+	}()
+	connected()
+
+	for msg := range msgs {
+		s.handle(msg.Exchange, msg.RoutingKey, msg.Body)
+	}
+	return amqp.ErrClosed
+}
@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"order-service/src/config"
+	"order-service/src/model"
+	"order-service/src/queue"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var (
+	// ErrDispatchConflict is returned when an order's dispatch state changed
+	// underneath an update, for example two dispatch workers racing.
+	ErrDispatchConflict = errors.New("order dispatch was changed concurrently")
+	// ErrOfferNotPending is returned for responses to offers that were
+	// already answered, withdrawn or have expired.
+	ErrOfferNotPending = errors.New("delivery offer is no longer open")
+)
+
+// newAgentMessage encodes a message for a single delivery agent for the outbox.
+func newAgentMessage(messageType string, orderId int, agentID primitive.ObjectID, data any) (*model.OutboxEvent, error) {
+	now := time.Now()
+	message := queue.AgentMessage{
+		MessageId: uuid.New().String(),
+		Type:      messageType,
+		OrderId:   orderId,
+		AgentID:   agentID,
+		SentAt:    now,
+		Data:      data,
+	}
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	return &model.OutboxEvent{
+		EventId:    message.MessageId,
+		Type:       messageType,
+		OrderId:    orderId,
+		Exchange:   queue.AgentExchange(),
+		RoutingKey: queue.AgentRoutingKey(agentID),
+		Payload:    payload,
+		Status:     model.OutboxPending,
+		CreatedAt:  now,
+	}, nil
+}
+
+// SetPickupLocation caches the restaurant's coordinates on the order so
+// later dispatch rounds do not need to look them up again.
+func SetPickupLocation(ctx context.Context, order *model.Order, pickup model.GeoPoint) error {
+	_, err := config.OrderCollection.UpdateOne(ctx,
+		bson.M{"orderId": order.OrderId},
+		bson.M{"$set": bson.M{"dispatch.pickup": pickup}},
+	)
+	if err != nil {
+		return err
+	}
+	order.Dispatch.Pickup = &pickup
+	return nil
+}
+
+// OfferOrder records attempt and sends the offer to its agent. Only an order
+// that is searching for an agent can be offered.
+func OfferOrder(ctx context.Context, order *model.Order, attempt model.AssignmentAttempt) error {
+	message, err := newAgentMessage(queue.AgentDeliveryOffer, order.OrderId, attempt.AgentID, queue.DeliveryOfferMessage{
+		OfferId:         attempt.OfferId,
+		RestaurantID:    order.RestaurantID,
+		PickupLatitude:  order.Dispatch.Pickup.Latitude,
+		PickupLongitude: order.Dispatch.Pickup.Longitude,
+		DistanceKm:      attempt.DistanceKm,
+		Items:           len(order.Orders),
+		AmountToCollect: amountToCollect(order),
+		ExpiresAt:       attempt.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = withTransaction(ctx, func(ctx context.Context) error {
+		result, err := config.OrderCollection.UpdateOne(ctx,
+			bson.M{"orderId": order.OrderId, "status": model.StatusReadyForPickup, "dispatch.status": model.DispatchSearching},
+			bson.M{
+				"$set": bson.M{
+					"dispatch.status":         model.DispatchOffered,
+					"dispatch.offerExpiresAt": attempt.ExpiresAt,
+					"dispatch.updatedAt":      attempt.OfferedAt,
+				},
+				"$unset": bson.M{"dispatch.nextSearchAt": ""},
+				"$push":  bson.M{"dispatch.attempts": attempt},
+			},
+		)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrDispatchConflict
+		}
+		return insertOutboxEvent(ctx, message)
+	})
+	if err != nil {
+		return err
+	}
+	order.Dispatch.Status = model.DispatchOffered
+	order.Dispatch.OfferExpiresAt = &attempt.ExpiresAt
+	order.Dispatch.NextSearchAt = nil
+	order.Dispatch.Attempts = append(order.Dispatch.Attempts, attempt)
+	return nil
+}
+
+func amountToCollect(order *model.Order) float32 {
+	if model.IsPrepaid(order.PaymentMode) {
+		return 0
+	}
+	return order.TotalPrice
+}
+
+// ScheduleDispatchRetry records a search that found nobody to offer the
+// order to. The order is searched again at nextSearchAt, or marked failed
+// if nextSearchAt is nil.
+func ScheduleDispatchRetry(ctx context.Context, order *model.Order, nextSearchAt *time.Time) error {
+	now := time.Now()
+	set := bson.M{"dispatch.updatedAt": now}
+	if nextSearchAt != nil {
+		set["dispatch.nextSearchAt"] = *nextSearchAt
+	} else {
+		set["dispatch.status"] = model.DispatchFailed
+	}
+	result, err := config.OrderCollection.UpdateOne(ctx,
+		bson.M{"orderId": order.OrderId, "dispatch.status": model.DispatchSearching},
+		bson.M{"$set": set, "$inc": bson.M{"dispatch.rounds": 1}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrDispatchConflict
+	}
+	order.Dispatch.Rounds++
+	order.Dispatch.NextSearchAt = nextSearchAt
+	if nextSearchAt == nil {
+		order.Dispatch.Status = model.DispatchFailed
+	}
+	return nil
+}
+
+// openOfferFilter matches order only while offerId is its open offer to agentID.
+func openOfferFilter(orderId int, offerId string, agentID primitive.ObjectID) bson.M {
+	return bson.M{
+		"orderId":         orderId,
+		"dispatch.status": model.DispatchOffered,
+		"dispatch.attempts": bson.M{"$elemMatch": bson.M{
+			"offerId": offerId,
+			"agentId": agentID,
+			"status":  model.OfferPending,
+		}},
+	}
+}
+
+// AcceptOffer assigns the order to the agent that accepted offerId, provided
+// the offer is still open and has not expired.
+func AcceptOffer(ctx context.Context, orderId int, offerId string, agentID primitive.ObjectID) (*model.Order, error) {
+	now := time.Now()
+	filter := openOfferFilter(orderId, offerId, agentID)
+	filter["dispatch.offerExpiresAt"] = bson.M{"$gt": now}
+
+	var order model.Order
+	err := withTransaction(ctx, func(ctx context.Context) error {
+		err := config.OrderCollection.FindOneAndUpdate(ctx, filter,
+			bson.M{
+				"$set": bson.M{
+					"deliveryAgentId":                 agentID,
+					"dispatch.status":                 model.DispatchAssigned,
+					"dispatch.updatedAt":              now,
+					"dispatch.attempts.$.status":      model.OfferAccepted,
+					"dispatch.attempts.$.respondedAt": now,
+				},
+				"$unset": bson.M{"dispatch.offerExpiresAt": ""},
+			},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&order)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrOfferNotPending
+		}
+		if err != nil {
+			return err
+		}
+		event, err := newOrderEvent(model.EventOrderAgentAssigned, orderId, queue.OrderAgentAssignedData{
+			DeliveryAgentID: agentID,
+			OfferId:         offerId,
+			Attempts:        len(order.Dispatch.Attempts),
+		})
+		if err != nil {
+			return err
+		}
+		return insertOutboxEvent(ctx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// CloseOffer marks offerId declined or expired and puts the order back to
+// searching. Expired offers are withdrawn from the agent's app.
+func CloseOffer(ctx context.Context, orderId int, offerId string, agentID primitive.ObjectID, status string) error {
+	now := time.Now()
+	var message *model.OutboxEvent
+	if status == model.OfferExpired {
+		var err error
+		message, err = newAgentMessage(queue.AgentOfferWithdrawn, orderId, agentID, queue.OfferWithdrawnMessage{
+			OfferId: offerId,
+			Reason:  "offer expired",
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return withTransaction(ctx, func(ctx context.Context) error {
+		result, err := config.OrderCollection.UpdateOne(ctx,
+			openOfferFilter(orderId, offerId, agentID),
+			bson.M{
+				"$set": bson.M{
+					"dispatch.status":                 model.DispatchSearching,
+					"dispatch.nextSearchAt":           now,
+					"dispatch.updatedAt":              now,
+					"dispatch.attempts.$.status":      status,
+					"dispatch.attempts.$.respondedAt": now,
+				},
+				"$unset": bson.M{"dispatch.offerExpiresAt": ""},
+			},
+		)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrOfferNotPending
+		}
+		if message == nil {
+			return nil
+		}
+		return insertOutboxEvent(ctx, message)
+	})
+}
+
+// FindOrdersToDispatch returns ready orders due for an agent search.
+func FindOrdersToDispatch(ctx context.Context, now time.Time, limit int64) ([]model.Order, error) {
+	return findOrders(ctx, bson.M{
+		"status":                model.StatusReadyForPickup,
+		"dispatch.status":       model.DispatchSearching,
+		"dispatch.nextSearchAt": bson.M{"$lte": now},
+	}, limit)
+}
+
+// FindExpiredOffers returns orders whose open offer has run out.
+func FindExpiredOffers(ctx context.Context, now time.Time, limit int64) ([]model.Order, error) {
+	return findOrders(ctx, bson.M{
+		"status":                  model.StatusReadyForPickup,
+		"dispatch.status":         model.DispatchOffered,
+		"dispatch.offerExpiresAt": bson.M{"$lte": now},
+	}, limit)
+}
+
+func findOrders(ctx context.Context, filter bson.M, limit int64) ([]model.Order, error) {
+	cur, err := config.OrderCollection.Find(ctx, filter, options.Find().SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	var orders []model.Order
+	err = cur.All(ctx, &orders)
+	return orders, err
+}
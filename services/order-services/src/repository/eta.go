@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"order-service/src/config"
+	"order-service/src/model"
+	"order-service/src/queue"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// RecordETA stores estimate as the order's current ETA, appends it to the
+// ETA history and publishes an order.eta_updated event.
+func RecordETA(ctx context.Context, order *model.Order, estimate model.ETAEstimate) error {
+	event, err := newOrderEvent(model.EventOrderETAUpdated, order.OrderId, queue.OrderETAUpdatedData{
+		EstimatedDeliveryTime: estimate.EstimatedDeliveryTime,
+		Trigger:               estimate.Trigger,
+		Status:                estimate.Status,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = withTransaction(ctx, func(ctx context.Context) error {
+		_, err := config.OrderCollection.UpdateOne(ctx,
+			bson.M{"orderId": order.OrderId},
+			bson.M{
+				"$set":  bson.M{"estimatedDeliveryTime": estimate.EstimatedDeliveryTime},
+				"$push": bson.M{"etaHistory": estimate},
+			},
+		)
+		if err != nil {
+			return err
+		}
+		return insertOutboxEvent(ctx, event)
+	})
+	if err != nil {
+		return err
+	}
+	order.EstimatedDeliveryTime = &estimate.EstimatedDeliveryTime
+	order.ETAHistory = append(order.ETAHistory, estimate)
+	return nil
+}
+
+// CountOrdersInPreparation returns how many orders restaurantID is cooking.
+func CountOrdersInPreparation(ctx context.Context, restaurantID primitive.ObjectID) (int, error) {
+	count, err := config.OrderCollection.CountDocuments(ctx, bson.M{
+		"restaurantId": restaurantID,
+		"status":       model.StatusBeingPrepared,
+	})
+	return int(count), err
+}
+
+// FindActiveOrdersForAgent returns the orders agentID is assigned to and has
+// not delivered yet.
+func FindActiveOrdersForAgent(ctx context.Context, agentID primitive.ObjectID) ([]model.Order, error) {
+	return findOrders(ctx, bson.M{
+		"deliveryAgentId": agentID,
+		"status": bson.M{"$in": bson.A{
+			model.StatusBeingPrepared,
+			model.StatusReadyForPickup,
+			model.StatusOutForDelivery,
+		}},
+	}, 0)
+}
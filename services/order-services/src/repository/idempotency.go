@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"order-service/src/config"
+	"order-service/src/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// idempotencyLockTimeout is how long an in-progress request holds its key
+// before another request may assume the original attempt died.
+const idempotencyLockTimeout = time.Minute
+
+// ReserveIdempotencyKey claims record's key for the current request. When
+// the key is already taken it returns the stored record and reserved=false.
+func ReserveIdempotencyKey(ctx context.Context, record *model.IdempotencyRecord) (existing *model.IdempotencyRecord, reserved bool, err error) {
+	now := time.Now()
+	record.Status = model.IdempotencyInProgress
+	record.CreatedAt = now
+	record.LockedAt = now
+
+	_, err = config.IdempotencyCollection.InsertOne(ctx, record)
+	if err == nil {
+		return nil, true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, false, err
+	}
+
+	filter := bson.M{"key": record.Key, "scope": record.Scope}
+
+	// take over a key whose original request never finished
+	result, err := config.IdempotencyCollection.UpdateOne(ctx,
+		bson.M{
+			"key":         record.Key,
+			"scope":       record.Scope,
+			"requestHash": record.RequestHash,
+			"status":      model.IdempotencyInProgress,
+			"lockedAt":    bson.M{"$lt": now.Add(-idempotencyLockTimeout)},
+		},
+		bson.M{"$set": bson.M{"lockedAt": now}},
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	if result.MatchedCount == 1 {
+		return nil, true, nil
+	}
+
+	var stored model.IdempotencyRecord
+	err = config.IdempotencyCollection.FindOne(ctx, filter).Decode(&stored)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// the key expired or was released in the meantime
+		return ReserveIdempotencyKey(ctx, record)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &stored, false, nil
+}
+
+// CompleteIdempotencyKey stores the response to replay for record's key.
+func CompleteIdempotencyKey(ctx context.Context, record *model.IdempotencyRecord, code int, body []byte) error {
+	_, err := config.IdempotencyCollection.UpdateOne(ctx,
+		bson.M{"key": record.Key, "scope": record.Scope},
+		bson.M{"$set": bson.M{
+			"status":       model.IdempotencyCompleted,
+			"responseCode": code,
+			"responseBody": body,
+		}},
+	)
+	return err
+}
+
+// ReleaseIdempotencyKey forgets record's key so the request can be retried.
+func ReleaseIdempotencyKey(ctx context.Context, record *model.IdempotencyRecord) error {
+	_, err := config.IdempotencyCollection.DeleteOne(ctx, bson.M{"key": record.Key, "scope": record.Scope})
+	return err
+}
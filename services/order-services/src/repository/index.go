@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"order-service/src/config"
+	"order-service/src/model"
+	"order-service/src/queue"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var (
+	ErrIllegalTransition = errors.New("illegal order status transition")
+	ErrStatusConflict    = errors.New("order status was changed concurrently")
+)
+
+// TransitionStatus moves order from its current status to `to` on behalf of
+// actor. The update only applies if the stored status still matches
+// order.Status, so two concurrent transitions cannot both succeed. The
+// change is published as order.status_changed, or order.cancelled, in which
+// case the order's stock reservation is released too.
+func TransitionStatus(ctx context.Context, order *model.Order, to string, actor model.Actor, actorID *primitive.ObjectID) error {
+	if !model.CanTransition(actor, order.Status, to) {
+		return ErrIllegalTransition
+	}
+	var change model.StatusChange
+	var set bson.M
+	err := withTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		change, set, err = applyTransition(ctx, order, to, actor, actorID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	order.Status = to
+	order.StatusHistory = append(order.StatusHistory, change)
+	if dispatch, ok := set["dispatch"].(model.Dispatch); ok {
+		order.Dispatch = &dispatch
+	}
+	return nil
+}
+
+// applyTransition writes a transition TransitionStatus has checked, with its
+// outbox messages, and returns the history entry and fields it set. It must
+// run inside a transaction.
+func applyTransition(ctx context.Context, order *model.Order, to string, actor model.Actor, actorID *primitive.ObjectID) (model.StatusChange, bson.M, error) {
+	now := time.Now()
+	change := model.StatusChange{
+		From:      order.Status,
+		To:        to,
+		Actor:     actor,
+		ActorID:   actorID,
+		ChangedAt: now,
+	}
+	set := bson.M{"status": to}
+	switch to {
+	case model.StatusOutForDelivery:
+		set["fulfillmentTime"] = now
+	case model.StatusReadyForPickup:
+		// start looking for a delivery agent
+		set["dispatch"] = model.Dispatch{
+			Status:       model.DispatchSearching,
+			Attempts:     []model.AssignmentAttempt{},
+			NextSearchAt: &now,
+			UpdatedAt:    now,
+		}
+	case model.StatusDelivered:
+		set["deliveryTime"] = now
+	}
+
+	eventType := model.EventOrderStatusChanged
+	if to == model.StatusCancelled {
+		eventType = model.EventOrderCancelled
+	}
+	event, err := newOrderEvent(eventType, order.OrderId, queue.OrderStatusChangedData{
+		CustomerID:      order.CustomerID,
+		RestaurantID:    order.RestaurantID,
+		DeliveryAgentID: order.DeliveryAgentID,
+		From:            order.Status,
+		To:              to,
+		Actor:           string(actor),
+		ActorID:         actorID,
+	})
+	if err != nil {
+		return model.StatusChange{}, nil, err
+	}
+
+	// the restaurant already knows about the order, so tell it to stop
+	var message *model.OutboxEvent
+	if to == model.StatusCancelled && order.RestaurantNotifiedAt != nil && actor != model.ActorRestaurant {
+		message, err = newRestaurantMessage(queue.RestaurantOrderCancelled, order, queue.OrderCancelledMessage{
+			CancelledBy: string(actor),
+			Reason:      cancellationReason(actor),
+		})
+		if err != nil {
+			return model.StatusChange{}, nil, err
+		}
+	}
+
+	var release *model.OutboxEvent
+	if to == model.StatusCancelled && order.StockReservationId != "" {
+		release, err = newStockReleaseMessage(order, cancellationReason(actor))
+		if err != nil {
+			return model.StatusChange{}, nil, err
+		}
+	}
+
+	result, err := config.OrderCollection.UpdateOne(ctx,
+		bson.M{"orderId": order.OrderId, "status": order.Status},
+		bson.M{"$set": set, "$push": bson.M{"statusHistory": change}},
+	)
+	if err != nil {
+		return model.StatusChange{}, nil, err
+	}
+	if result.MatchedCount == 0 {
+		return model.StatusChange{}, nil, ErrStatusConflict
+	}
+	for _, outbox := range []*model.OutboxEvent{message, release, event} {
+		if outbox == nil {
+			continue
+		}
+		if err := insertOutboxEvent(ctx, outbox); err != nil {
+			return model.StatusChange{}, nil, err
+		}
+	}
+	return change, set, nil
+}
+
+func cancellationReason(actor model.Actor) string {
+	switch actor {
+	case model.ActorCustomer:
+		return "cancelled by customer"
+	case model.ActorSystem:
+		return "cancelled automatically"
+	}
+	return "cancelled"
+}
+
+// FindOrder loads an order by its public order number.
+func FindOrder(ctx context.Context, orderId int) (*model.Order, error) {
+	var order model.Order
+	err := config.OrderCollection.FindOne(ctx, bson.M{"orderId": orderId}).Decode(&order)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"order-service/src/config"
+	"order-service/src/model"
+	"order-service/src/queue"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	orderIdCounter = "orderId"
+	// orderIdOffset keeps order numbers six digits or longer, as they were
+	// when they were generated randomly.
+	orderIdOffset = 100000
+	// insertAttempts bounds retries when a generated order number collides
+	// with an existing order.
+	insertAttempts = 5
+)
+
+// NextOrderID atomically reserves the next order number.
+func NextOrderID(ctx context.Context) (int, error) {
+	var counter struct {
+		Seq int `bson:"seq"`
+	}
+	err := config.CounterCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": orderIdCounter},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return 0, err
+	}
+	return orderIdOffset + counter.Seq, nil
+}
+
+// InsertOrder assigns order a fresh order number and stores it together
+// with its payment and an order.created event, retrying with the next
+// number if the unique index reports a collision. Prepaid payments are sent
+// to the payment service; other orders go to the restaurant straight away.
+func InsertOrder(ctx context.Context, order *model.Order, payment *model.Payment) error {
+	var err error
+	var event, message *model.OutboxEvent
+	for attempt := 0; attempt < insertAttempts; attempt++ {
+		order.OrderId, err = NextOrderID(ctx)
+		if err != nil {
+			return err
+		}
+		payment.OrderId = order.OrderId
+		event, err = newOrderEvent(model.EventOrderCreated, order.OrderId, queue.OrderCreatedData{
+			CustomerID:   order.CustomerID,
+			RestaurantID: order.RestaurantID,
+			TotalPrice:   order.TotalPrice,
+			PaymentMode:  order.PaymentMode,
+			PaymentId:    order.PaymentId,
+			Status:       order.Status,
+		})
+		if err != nil {
+			return err
+		}
+		if model.IsPrepaid(order.PaymentMode) {
+			message, err = newPaymentIntentMessage(payment)
+		} else {
+			message, err = markRestaurantNotified(order, order.OrderTime)
+		}
+		if err != nil {
+			return err
+		}
+		err = withTransaction(ctx, func(ctx context.Context) error {
+			if _, err := config.OrderCollection.InsertOne(ctx, order); err != nil {
+				return err
+			}
+			if _, err := config.PaymentCollection.InsertOne(ctx, payment); err != nil {
+				return err
+			}
+			if message != nil {
+				if err := insertOutboxEvent(ctx, message); err != nil {
+					return err
+				}
+			}
+			return insertOutboxEvent(ctx, event)
+		})
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return err
+}
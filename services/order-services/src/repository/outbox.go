@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"order-service/src/config"
+	"order-service/src/model"
+	"order-service/src/queue"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// withTransaction runs fn in a MongoDB transaction, so order changes and the
+// outbox events describing them are committed together or not at all. fn
+// may be retried on transient errors and must only write to the database.
+// Transactions need MongoDB to run as a replica set.
+func withTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := config.OrderCollection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
+
+// newOrderEvent encodes an event about order for the outbox.
+func newOrderEvent(eventType string, orderId int, data any) (*model.OutboxEvent, error) {
+	now := time.Now()
+	event := queue.OrderEvent{
+		EventId:    uuid.New().String(),
+		Type:       eventType,
+		OrderId:    orderId,
+		OccurredAt: now,
+		Data:       data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return &model.OutboxEvent{
+		EventId:   event.EventId,
+		Type:      eventType,
+		OrderId:   orderId,
+		Payload:   payload,
+		Status:    model.OutboxPending,
+		CreatedAt: now,
+	}, nil
+}
+
+func insertOutboxEvent(ctx context.Context, event *model.OutboxEvent) error {
+	_, err := config.OutboxCollection.InsertOne(ctx, event)
+	return err
+}
+
+// FindPendingOutboxEvents returns up to limit unpublished events that are
+// due at now, oldest first, leaving out the events of skipOrders.
+func FindPendingOutboxEvents(ctx context.Context, now time.Time, skipOrders []int, limit int64) ([]model.OutboxEvent, error) {
+	filter := bson.M{
+		"status": model.OutboxPending,
+		"$or": bson.A{
+			bson.M{"nextAttemptAt": bson.M{"$exists": false}},
+			bson.M{"nextAttemptAt": bson.M{"$lte": now}},
+		},
+	}
+	if len(skipOrders) > 0 {
+		filter["orderId"] = bson.M{"$nin": skipOrders}
+	}
+	cur, err := config.OutboxCollection.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}).SetLimit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var events []model.OutboxEvent
+	err = cur.All(ctx, &events)
+	return events, err
+}
+
+// FindRetryingOutboxOrders returns the orders with an event waiting to be
+// retried after now. Their later events must wait for it.
+func FindRetryingOutboxOrders(ctx context.Context, now time.Time) ([]int, error) {
+	var orderIds []int
+	err := config.OutboxCollection.Distinct(ctx, "orderId", bson.M{
+		"status":        model.OutboxPending,
+		"nextAttemptAt": bson.M{"$gt": now},
+	}).Decode(&orderIds)
+	return orderIds, err
+}
+
+func MarkOutboxSent(ctx context.Context, eventId string) error {
+	now := time.Now()
+	_, err := config.OutboxCollection.UpdateOne(ctx,
+		bson.M{"eventId": eventId, "status": model.OutboxPending},
+		bson.M{"$set": bson.M{"status": model.OutboxSent, "sentAt": now}},
+	)
+	return err
+}
+
+// MarkOutboxFailed records a failed publish. The event is retried at
+// nextAttemptAt, or parked if that is nil.
+func MarkOutboxFailed(ctx context.Context, eventId string, reason string, nextAttemptAt *time.Time) error {
+	set := bson.M{"lastError": reason}
+	if nextAttemptAt != nil {
+		set["nextAttemptAt"] = *nextAttemptAt
+	} else {
+		set["status"] = model.OutboxParked
+	}
+	_, err := config.OutboxCollection.UpdateOne(ctx,
+		bson.M{"eventId": eventId, "status": model.OutboxPending},
+		bson.M{"$set": set, "$inc": bson.M{"attempts": 1}},
+	)
+	return err
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"order-service/src/config"
+	"order-service/src/model"
+	"order-service/src/queue"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ErrPaymentSettled is returned when a payment is no longer pending.
+var ErrPaymentSettled = errors.New("payment is already settled")
+
+// newPaymentIntentMessage encodes the request for the payment service to
+// collect a prepaid payment, sent to its queue through the outbox.
+func newPaymentIntentMessage(payment *model.Payment) (*model.OutboxEvent, error) {
+	payload, err := json.Marshal(queue.PaymentIntent{
+		PaymentId:  payment.PaymentId,
+		OrderId:    payment.OrderId,
+		CustomerID: payment.CustomerID,
+		Amount:     payment.Amount,
+		Mode:       payment.Mode,
+		ExpiresAt:  payment.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.OutboxEvent{
+		EventId:   uuid.New().String(),
+		Type:      queue.PaymentIntentQueue,
+		OrderId:   payment.OrderId,
+		Queue:     queue.PaymentIntentQueue,
+		Payload:   payload,
+		Status:    model.OutboxPending,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// SettlePayment moves a pending payment to status. It returns
+// ErrPaymentSettled if the payment was already settled, which makes
+// redelivered payment events harmless. Once a payment succeeds, a pending
+// order is sent to its restaurant; once it fails or expires, the order is
+// cancelled in the same transaction, so it cannot be left waiting.
+func SettlePayment(ctx context.Context, paymentId string, status string, providerRef string, reason string) (*model.Payment, error) {
+	set := bson.M{"status": status, "updatedAt": time.Now()}
+	if providerRef != "" {
+		set["providerRef"] = providerRef
+	}
+	if reason != "" {
+		set["failureReason"] = reason
+	}
+
+	var payment model.Payment
+	err := withTransaction(ctx, func(ctx context.Context) error {
+		err := config.PaymentCollection.FindOneAndUpdate(ctx,
+			bson.M{"paymentId": paymentId, "status": model.PaymentStatusPending},
+			bson.M{"$set": set},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&payment)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrPaymentSettled
+		}
+		if err != nil {
+			return err
+		}
+
+		if status == model.PaymentStatusSucceeded {
+			err = notifyPaidOrder(ctx, payment.OrderId)
+		} else {
+			err = cancelUnpaidOrder(ctx, payment.OrderId)
+		}
+		if err != nil {
+			return err
+		}
+		_, err = config.OrderCollection.UpdateOne(ctx,
+			bson.M{"orderId": payment.OrderId},
+			bson.M{"$set": bson.M{"paymentStatus": status}},
+		)
+		if err != nil {
+			return err
+		}
+		event, err := newOrderEvent(model.EventOrderPaymentUpdated, payment.OrderId, queue.OrderPaymentUpdatedData{
+			PaymentId:     payment.PaymentId,
+			PaymentStatus: status,
+			Amount:        payment.Amount,
+		})
+		if err != nil {
+			return err
+		}
+		return insertOutboxEvent(ctx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// cancelUnpaidOrder cancels the order of a failed or expired payment unless
+// it has already moved on. It runs inside SettlePayment's transaction.
+func cancelUnpaidOrder(ctx context.Context, orderId int) error {
+	var order model.Order
+	if err := config.OrderCollection.FindOne(ctx, bson.M{"orderId": orderId}).Decode(&order); err != nil {
+		return err
+	}
+	if !model.CanTransition(model.ActorSystem, order.Status, model.StatusCancelled) {
+		return nil
+	}
+	_, _, err := applyTransition(ctx, &order, model.StatusCancelled, model.ActorSystem, nil)
+	return err
+}
+
+// notifyPaidOrder sends a paid order to its restaurant unless it is no
+// longer pending or was already sent. It runs inside SettlePayment's
+// transaction.
+func notifyPaidOrder(ctx context.Context, orderId int) error {
+	var order model.Order
+	err := config.OrderCollection.FindOne(ctx, bson.M{
+		"orderId":              orderId,
+		"status":               model.StatusPending,
+		"restaurantNotifiedAt": bson.M{"$exists": false},
+	}).Decode(&order)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	message, err := markRestaurantNotified(&order, time.Now())
+	if err != nil {
+		return err
+	}
+	_, err = config.OrderCollection.UpdateOne(ctx,
+		bson.M{"orderId": orderId},
+		bson.M{"$set": bson.M{
+			"restaurantNotifiedAt": order.RestaurantNotifiedAt,
+			"restaurantResponseBy": order.RestaurantResponseBy,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	return insertOutboxEvent(ctx, message)
+}
+
+// FindExpiredPayments returns up to limit pending payments whose window has closed.
+func FindExpiredPayments(ctx context.Context, now time.Time, limit int64) ([]model.Payment, error) {
+	cur, err := config.PaymentCollection.Find(ctx,
+		bson.M{"status": model.PaymentStatusPending, "expiresAt": bson.M{"$lte": now}},
+		options.Find().SetLimit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var payments []model.Payment
+	err = cur.All(ctx, &payments)
+	return payments, err
+}
+
+func FindPaymentsByOrder(ctx context.Context, orderId int) ([]model.Payment, error) {
+	cur, err := config.PaymentCollection.Find(ctx, bson.M{"orderId": orderId})
+	if err != nil {
+		return nil, err
+	}
+	var payments []model.Payment
+	err = cur.All(ctx, &payments)
+	return payments, err
+}
+
+// FindPayment loads a payment by id.
+func FindPayment(ctx context.Context, paymentId string) (*model.Payment, error) {
+	var payment model.Payment
+	err := config.PaymentCollection.FindOne(ctx, bson.M{"paymentId": paymentId}).Decode(&payment)
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
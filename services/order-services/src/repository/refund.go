@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"order-service/src/config"
+	"order-service/src/model"
+	"order-service/src/queue"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var (
+	// ErrRefundExceedsPayment is returned when a refund would return more
+	// than the customer paid for the order.
+	ErrRefundExceedsPayment = errors.New("refund exceeds the amount paid")
+	// ErrRefundNotPending is returned when a refund is not in the state an
+	// update expects, for example a redelivered refund event.
+	ErrRefundNotPending = errors.New("refund is not awaiting this update")
+)
+
+// refundTolerance absorbs float rounding when comparing refund totals.
+const refundTolerance = 0.005
+
+// InsertRefund reserves refund.Amount against the order's paid total and
+// stores the refund. The reservation is a conditional increment, so
+// concurrent refunds can never add up to more than the order total.
+func InsertRefund(ctx context.Context, order *model.Order, refund *model.Refund) error {
+	event, err := newOrderEvent(model.EventOrderRefundRequested, order.OrderId, queue.OrderRefundRequestedData{
+		RefundId:       refund.RefundId,
+		Amount:         refund.Amount,
+		RefundedAmount: order.RefundedAmount + refund.Amount,
+		Reason:         refund.Reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = withTransaction(ctx, func(ctx context.Context) error {
+		result, err := config.OrderCollection.UpdateOne(ctx,
+			bson.M{
+				"orderId": order.OrderId,
+				"$or": bson.A{
+					bson.M{"refundedAmount": bson.M{"$exists": false}},
+					bson.M{"refundedAmount": bson.M{"$lte": order.TotalPrice - refund.Amount + refundTolerance}},
+				},
+			},
+			bson.M{"$inc": bson.M{"refundedAmount": refund.Amount}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrRefundExceedsPayment
+		}
+		if _, err := config.RefundCollection.InsertOne(ctx, refund); err != nil {
+			return err
+		}
+		return insertOutboxEvent(ctx, event)
+	})
+	if err != nil {
+		return err
+	}
+	order.RefundedAmount += refund.Amount
+	return nil
+}
+
+// newRefundRequestMessage encodes the request for the payment service to
+// return refund, sent to its queue through the outbox.
+func newRefundRequestMessage(refund *model.Refund) (*model.OutboxEvent, error) {
+	payload, err := json.Marshal(queue.RefundRequest{
+		RefundId:  refund.RefundId,
+		PaymentId: refund.PaymentId,
+		OrderId:   refund.OrderId,
+		Amount:    refund.Amount,
+		Reason:    refund.Reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.OutboxEvent{
+		EventId:   uuid.New().String(),
+		Type:      queue.RefundRequestQueue,
+		OrderId:   refund.OrderId,
+		Queue:     queue.RefundRequestQueue,
+		Payload:   payload,
+		Status:    model.OutboxPending,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// MarkRefundProcessing hands refund to the payment service: it is marked
+// processing and its request is written to the outbox in one transaction.
+// Requested and failed refunds can be sent, and so can refunds that have
+// been processing since before staleBefore without an answer.
+func MarkRefundProcessing(ctx context.Context, refund *model.Refund, staleBefore time.Time) error {
+	message, err := newRefundRequestMessage(refund)
+	if err != nil {
+		return err
+	}
+	var updated model.Refund
+	err = withTransaction(ctx, func(ctx context.Context) error {
+		err := config.RefundCollection.FindOneAndUpdate(ctx,
+			bson.M{"refundId": refund.RefundId, "$or": bson.A{
+				bson.M{"status": bson.M{"$in": bson.A{model.RefundRequested, model.RefundFailed}}},
+				bson.M{"status": model.RefundProcessing, "updatedAt": bson.M{"$lte": staleBefore}},
+			}},
+			bson.M{
+				"$set":   bson.M{"status": model.RefundProcessing, "updatedAt": time.Now()},
+				"$inc":   bson.M{"attempts": 1},
+				"$unset": bson.M{"nextAttemptAt": ""},
+			},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&updated)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrRefundNotPending
+		}
+		if err != nil {
+			return err
+		}
+		return insertOutboxEvent(ctx, message)
+	})
+	if err != nil {
+		return err
+	}
+	*refund = updated
+	return nil
+}
+
+// SettleRefund moves a processing refund to completed or failed. Failed
+// refunds are scheduled for another attempt at nextAttemptAt, if given.
+// Otherwise the refund is given up on and its amount is taken off the
+// order's refunded amount again, so it can be refunded another way.
+func SettleRefund(ctx context.Context, refundId string, status string, providerRef string, reason string, nextAttemptAt *time.Time) (*model.Refund, error) {
+	set := bson.M{"status": status, "updatedAt": time.Now()}
+	if providerRef != "" {
+		set["providerRef"] = providerRef
+	}
+	if reason != "" {
+		set["lastError"] = reason
+	}
+	if nextAttemptAt != nil {
+		set["nextAttemptAt"] = *nextAttemptAt
+	}
+
+	var refund model.Refund
+	err := withTransaction(ctx, func(ctx context.Context) error {
+		err := config.RefundCollection.FindOneAndUpdate(ctx,
+			bson.M{"refundId": refundId, "status": model.RefundProcessing},
+			bson.M{"$set": set},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&refund)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrRefundNotPending
+		}
+		if err != nil {
+			return err
+		}
+		if status != model.RefundFailed || nextAttemptAt != nil {
+			return nil
+		}
+		return releaseRefundedAmount(ctx, &refund, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// releaseRefundedAmount takes a refund that was given up on off its order's
+// refunded amount and records an order.refund_failed event.
+func releaseRefundedAmount(ctx context.Context, refund *model.Refund, reason string) error {
+	var order model.Order
+	err := config.OrderCollection.FindOneAndUpdate(ctx,
+		bson.M{"orderId": refund.OrderId},
+		bson.M{"$inc": bson.M{"refundedAmount": -refund.Amount}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&order)
+	if err != nil {
+		return err
+	}
+	event, err := newOrderEvent(model.EventOrderRefundFailed, refund.OrderId, queue.OrderRefundFailedData{
+		RefundId:       refund.RefundId,
+		Amount:         refund.Amount,
+		RefundedAmount: order.RefundedAmount,
+		Reason:         reason,
+	})
+	if err != nil {
+		return err
+	}
+	return insertOutboxEvent(ctx, event)
+}
+
+// FindRetryableRefunds returns refunds that never reached the payment
+// service, failed and are due for another attempt, or have been processing
+// since before staleBefore without an answer.
+func FindRetryableRefunds(ctx context.Context, now time.Time, staleBefore time.Time, limit int64) ([]model.Refund, error) {
+	cur, err := config.RefundCollection.Find(ctx,
+		bson.M{"$or": bson.A{
+			bson.M{"status": model.RefundRequested},
+			bson.M{"status": model.RefundFailed, "nextAttemptAt": bson.M{"$lte": now}},
+			bson.M{"status": model.RefundProcessing, "updatedAt": bson.M{"$lte": staleBefore}},
+		}},
+		options.Find().SetLimit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var refunds []model.Refund
+	err = cur.All(ctx, &refunds)
+	return refunds, err
+}
+
+func FindRefundsByOrder(ctx context.Context, orderId int) ([]model.Refund, error) {
+	cur, err := config.RefundCollection.Find(ctx,
+		bson.M{"orderId": orderId},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var refunds []model.Refund
+	err = cur.All(ctx, &refunds)
+	return refunds, err
+}
+
+func FindRefund(ctx context.Context, refundId string) (*model.Refund, error) {
+	var refund model.Refund
+	err := config.RefundCollection.FindOne(ctx, bson.M{"refundId": refundId}).Decode(&refund)
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"order-service/src/config"
+	"order-service/src/model"
+	"order-service/src/queue"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// newRestaurantMessage encodes a message for the restaurant of order for the
+// outbox, routed to that restaurant only.
+func newRestaurantMessage(messageType string, order *model.Order, data any) (*model.OutboxEvent, error) {
+	now := time.Now()
+	message := queue.RestaurantMessage{
+		MessageId:    uuid.New().String(),
+		Type:         messageType,
+		OrderId:      order.OrderId,
+		RestaurantID: order.RestaurantID,
+		SentAt:       now,
+		Data:         data,
+	}
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	return &model.OutboxEvent{
+		EventId:    message.MessageId,
+		Type:       messageType,
+		OrderId:    order.OrderId,
+		Exchange:   queue.RestaurantExchange(),
+		RoutingKey: queue.RestaurantRoutingKey(order.RestaurantID),
+		Payload:    payload,
+		Status:     model.OutboxPending,
+		CreatedAt:  now,
+	}, nil
+}
+
+// markRestaurantNotified starts the restaurant's response window on order
+// and returns the new_order message to store with it.
+func markRestaurantNotified(order *model.Order, now time.Time) (*model.OutboxEvent, error) {
+	respondBy := now.Add(config.RestaurantResponseTimeout())
+	order.RestaurantNotifiedAt = &now
+	order.RestaurantResponseBy = &respondBy
+
+	items := make([]queue.RestaurantOrderItem, 0, len(order.Orders))
+	for _, o := range order.Orders {
+		var components []queue.RestaurantComboComponent
+		for _, component := range o.Components {
+			components = append(components, queue.RestaurantComboComponent{
+				DishID:      component.DishID,
+				VariantName: component.VariantName,
+				Quantity:    component.Quantity * o.Quantity,
+			})
+		}
+		items = append(items, queue.RestaurantOrderItem{
+			DishID:         o.DishID,
+			VariantName:    o.VariantName,
+			ComboName:      o.ComboName,
+			Components:     components,
+			Quantity:       o.Quantity,
+			UnitPrice:      o.UnitPrice,
+			Surcharge:      o.Surcharge,
+			Price:          o.Price,
+			Customizations: o.Customizations,
+		})
+	}
+	var notes []queue.RestaurantNote
+	for _, note := range order.Notes {
+		notes = append(notes, queue.RestaurantNote{Text: note.Text, CreatedAt: note.CreatedAt})
+	}
+	return newRestaurantMessage(queue.RestaurantNewOrder, order, queue.NewOrderMessage{
+		Items:       items,
+		Notes:       notes,
+		TotalPrice:  order.TotalPrice,
+		PaymentMode: order.PaymentMode,
+		OrderTime:   order.OrderTime,
+		RespondBy:   respondBy,
+	})
+}
+
+// AddCustomerNote stores a note on order and forwards it to the restaurant
+// if the restaurant has already been sent the order; otherwise the note is
+// included in the new_order message.
+func AddCustomerNote(ctx context.Context, order *model.Order, text string) error {
+	note := model.OrderNote{Text: text, CreatedAt: time.Now()}
+	var message *model.OutboxEvent
+	if order.RestaurantNotifiedAt != nil {
+		var err error
+		message, err = newRestaurantMessage(queue.RestaurantCustomerNote, order, queue.CustomerNoteMessage{
+			Note: queue.RestaurantNote{Text: note.Text, CreatedAt: note.CreatedAt},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err := withTransaction(ctx, func(ctx context.Context) error {
+		result, err := config.OrderCollection.UpdateOne(ctx,
+			bson.M{"orderId": order.OrderId, "status": order.Status},
+			bson.M{"$push": bson.M{"notes": note}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrStatusConflict
+		}
+		if message == nil {
+			return nil
+		}
+		return insertOutboxEvent(ctx, message)
+	})
+	if err != nil {
+		return err
+	}
+	order.Notes = append(order.Notes, note)
+	return nil
+}
+
+// FindUnansweredOrders returns up to limit pending orders whose restaurant
+// response window has closed.
+func FindUnansweredOrders(ctx context.Context, now time.Time, limit int64) ([]model.Order, error) {
+	return findOrders(ctx, bson.M{
+		"status":               model.StatusPending,
+		"restaurantResponseBy": bson.M{"$lte": now},
+	}, limit)
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"encoding/json"
+	"order-service/src/model"
+	"order-service/src/queue"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// newStockReleaseMessage encodes a request to give back the stock reserved
+// for order, routed to dish-service through the outbox.
+func newStockReleaseMessage(order *model.Order, reason string) (*model.OutboxEvent, error) {
+	now := time.Now()
+	message := queue.StockReleaseMessage{
+		MessageId:     uuid.New().String(),
+		ReservationId: order.StockReservationId,
+		OrderId:       order.OrderId,
+		Reason:        reason,
+	}
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	return &model.OutboxEvent{
+		EventId:    message.MessageId,
+		Type:       queue.StockReleaseKey,
+		OrderId:    order.OrderId,
+		Exchange:   queue.StockExchange(),
+		RoutingKey: queue.StockReleaseKey,
+		Payload:    payload,
+		Status:     model.OutboxPending,
+		CreatedAt:  now,
+	}, nil
+}
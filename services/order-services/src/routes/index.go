@@ -2,33 +2,54 @@ package routes
 
 import (
 	"order-service/src/controller"
+	"order-service/src/middleware"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
 func Routes(r *gin.Engine, client *mongo.Client){
-	r.POST("/", func(ctx *gin.Context) {
+	r.POST("/", middleware.AuthMiddleware(), middleware.Idempotency(), func(ctx *gin.Context) {
 		controller.CreateOrder(client, ctx)
 	})
 
-	r.PATCH("/:orderId", func(ctx *gin.Context) {
+	r.PATCH("/:orderId", middleware.AuthMiddleware(), func(ctx *gin.Context) {
 		controller.CancelOrder(client, ctx)
 	})
 
-	r.PATCH("/update/:orderId/:status", func(ctx *gin.Context) {
+	r.PATCH("/update/:orderId/:status", middleware.AuthMiddleware(), func(ctx *gin.Context) {
 		controller.UpdateOrderStatus(client, ctx)
 	})
 
-	r.GET("/:orderId", func(ctx *gin.Context) {
+	r.GET("/:orderId", middleware.AuthMiddleware(), func(ctx *gin.Context) {
 		controller.GetOrder(client, ctx)
 	})
 
+	r.GET("/:orderId/history", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controller.GetOrderHistory(client, ctx)
+	})
+
+	r.GET("/:orderId/refunds", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controller.GetOrderRefunds(client, ctx)
+	})
+
+	r.POST("/:orderId/refunds", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controller.CreateRefund(client, ctx)
+	})
+
+	r.POST("/:orderId/notes", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controller.AddOrderNote(client, ctx)
+	})
+
 	r.GET("/", func(ctx *gin.Context) {
 		controller.GetAllOrders(client, ctx)
 	})
 
-	r.GET("/track/:orderId", func(ctx *gin.Context) {
+	r.GET("/track/:orderId", middleware.AuthMiddleware(), func(ctx *gin.Context) {
 		controller.TrackOrder(client, ctx)
 	})
+
+	r.GET("/track/:orderId/stream", middleware.AuthMiddleware(), func(ctx *gin.Context) {
+		controller.TrackOrderStream(client, ctx)
+	})
 }
\ No newline at end of file
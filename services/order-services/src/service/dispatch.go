@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"order-service/src/config"
+	"order-service/src/model"
+	"order-service/src/queue"
+	"order-service/src/repository"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// candidateLimit bounds how many agents are requested per search.
+const candidateLimit = 20
+
+// rankedCandidate is an agent scored for an order; lower scores rank first.
+type rankedCandidate struct {
+	agent      queue.AgentCandidate
+	distanceKm float64
+	score      float64
+}
+
+// rankCandidates drops unavailable, out of range and already offered agents
+// and orders the rest by distance to pickup, counting each active order an
+// agent carries as DispatchLoadPenaltyKm of extra distance.
+func rankCandidates(pickup model.GeoPoint, candidates []queue.AgentCandidate, dispatch *model.Dispatch) []rankedCandidate {
+	radius := config.DispatchRadiusKm()
+	penalty := config.DispatchLoadPenaltyKm()
+
+	var ranked []rankedCandidate
+	for _, agent := range candidates {
+		if !agent.Available || dispatch.OfferedTo(agent.AgentID) {
+			continue
+		}
+		distance := model.DistanceKm(pickup, model.GeoPoint{Latitude: agent.Latitude, Longitude: agent.Longitude})
+		if distance > radius {
+			continue
+		}
+		ranked = append(ranked, rankedCandidate{
+			agent:      agent,
+			distanceKm: distance,
+			score:      distance + penalty*float64(agent.ActiveOrders),
+		})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score < ranked[j].score
+	})
+	return ranked
+}
+
+// DispatchOrder offers a ready order to the best ranked agent that has not
+// been offered it yet. When nobody is available the search is retried later,
+// up to DispatchMaxRounds times.
+func DispatchOrder(ctx context.Context, order *model.Order) error {
+	if order.Status != model.StatusReadyForPickup || order.Dispatch == nil || order.Dispatch.Status != model.DispatchSearching {
+		return nil
+	}
+
+	if order.Dispatch.Pickup == nil {
+		location, err := queue.GetRestaurantLocation(ctx, order.RestaurantID)
+		if err != nil {
+			return err
+		}
+		pickup := model.GeoPoint{Latitude: location.Latitude, Longitude: location.Longitude}
+		if err := repository.SetPickupLocation(ctx, order, pickup); err != nil {
+			return err
+		}
+	}
+	pickup := *order.Dispatch.Pickup
+
+	var tried []primitive.ObjectID
+	for _, attempt := range order.Dispatch.Attempts {
+		tried = append(tried, attempt.AgentID)
+	}
+	candidates, err := queue.GetDispatchCandidates(ctx, queue.CandidateRequest{
+		OrderId:      order.OrderId,
+		RestaurantID: order.RestaurantID,
+		Latitude:     pickup.Latitude,
+		Longitude:    pickup.Longitude,
+		RadiusKm:     config.DispatchRadiusKm(),
+		Limit:        candidateLimit,
+		ExcludeIDs:   tried,
+	})
+	if err != nil {
+		return err
+	}
+
+	ranked := rankCandidates(pickup, candidates, order.Dispatch)
+	if len(ranked) == 0 {
+		var next *time.Time
+		if order.Dispatch.Rounds+1 < config.DispatchMaxRounds() {
+			at := time.Now().Add(config.DispatchRetryDelay())
+			next = &at
+		} else {
+			log.Printf("Giving up on finding a delivery agent for order %d\n", order.OrderId)
+		}
+		return ignoreDispatchConflict(repository.ScheduleDispatchRetry(ctx, order, next))
+	}
+
+	best := ranked[0]
+	now := time.Now()
+	attempt := model.AssignmentAttempt{
+		OfferId:      uuid.New().String(),
+		AgentID:      best.agent.AgentID,
+		DistanceKm:   best.distanceKm,
+		ActiveOrders: best.agent.ActiveOrders,
+		Status:       model.OfferPending,
+		OfferedAt:    now,
+		ExpiresAt:    now.Add(config.DispatchOfferTimeout()),
+	}
+	if err := repository.OfferOrder(ctx, order, attempt); err != nil {
+		return ignoreDispatchConflict(err)
+	}
+	log.Printf("Offered order %d to delivery agent %s\n", order.OrderId, attempt.AgentID.Hex())
+	return nil
+}
+
+// ignoreDispatchConflict treats losing a race with another dispatch worker
+// as success; the winner carries on with the order.
+func ignoreDispatchConflict(err error) error {
+	if errors.Is(err, repository.ErrDispatchConflict) {
+		return nil
+	}
+	return err
+}
+
+// HandleOfferResponse assigns the order to an agent that accepted its offer,
+// or moves on to the next candidate when the agent declined.
+func HandleOfferResponse(response queue.DeliveryOfferResponse) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if response.Accepted {
+		order, err := repository.AcceptOffer(ctx, response.OrderId, response.OfferId, response.AgentID)
+		if errors.Is(err, repository.ErrOfferNotPending) {
+			log.Printf("Ignoring acceptance of closed offer %s for order %d\n", response.OfferId, response.OrderId)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		log.Printf("Assigned order %d to delivery agent %s\n", order.OrderId, response.AgentID.Hex())
+		return nil
+	}
+
+	err := repository.CloseOffer(ctx, response.OrderId, response.OfferId, response.AgentID, model.OfferDeclined)
+	if errors.Is(err, repository.ErrOfferNotPending) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	order, err := repository.FindOrder(ctx, response.OrderId)
+	if err != nil {
+		return err
+	}
+	if err := DispatchOrder(ctx, order); err != nil {
+		// the dispatcher picks the order up again on its next run
+		log.Println("Error dispatching order:", err)
+	}
+	return nil
+}
+
+// RunDispatch expires offers that were not answered in time and offers
+// every order that is waiting for an agent.
+func RunDispatch(ctx context.Context) error {
+	now := time.Now()
+	expired, err := repository.FindExpiredOffers(ctx, now, 100)
+	if err != nil {
+		return err
+	}
+	for _, order := range expired {
+		last := order.Dispatch.Attempts[len(order.Dispatch.Attempts)-1]
+		err := repository.CloseOffer(ctx, order.OrderId, last.OfferId, last.AgentID, model.OfferExpired)
+		if err != nil && !errors.Is(err, repository.ErrOfferNotPending) {
+			return err
+		}
+	}
+
+	orders, err := repository.FindOrdersToDispatch(ctx, now, 100)
+	if err != nil {
+		return err
+	}
+	for i := range orders {
+		if err := DispatchOrder(ctx, &orders[i]); err != nil {
+			log.Printf("Error dispatching order %d: %v\n", orders[i].OrderId, err)
+		}
+	}
+	return nil
+}
+
+// RunDispatcher calls RunDispatch every interval. It never returns.
+func RunDispatcher(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		if err := RunDispatch(ctx); err != nil {
+			log.Println("Error running dispatch:", err)
+		}
+		cancel()
+	}
+}
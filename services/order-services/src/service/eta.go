@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"order-service/src/config"
+	"order-service/src/model"
+	"order-service/src/queue"
+	"order-service/src/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// etaChangeThreshold is the smallest change a location update must make to
+// an ETA before it is stored and pushed to watchers.
+const etaChangeThreshold = time.Minute
+
+// EstimateDelivery estimates when order will be delivered from its
+// remaining preparation time, the restaurant's queue of orders being
+// prepared and the travel time at AgentSpeedKmh. agent is the assigned
+// agent's last known location, if any.
+func EstimateDelivery(ctx context.Context, order *model.Order, agent *model.GeoPoint) (*model.ETAEstimate, error) {
+	now := time.Now()
+	estimate := &model.ETAEstimate{
+		ComputedAt: now,
+		Status:     order.Status,
+	}
+
+	var pickup *model.GeoPoint
+	if order.Status != model.StatusOutForDelivery {
+		var err error
+		pickup, err = pickupLocation(ctx, order)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// when the food is ready for pickup
+	prep := time.Duration(order.PreparationMinutes()) * time.Minute
+	readyAt := now
+	switch order.Status {
+	case model.StatusPending, model.StatusConfirmed:
+		depth, err := repository.CountOrdersInPreparation(ctx, order.RestaurantID)
+		if err != nil {
+			return nil, err
+		}
+		estimate.QueueDepth = depth
+		queueDelay := time.Duration(float64(depth) * config.QueueMinutesPerOrder() * float64(time.Minute))
+		readyAt = now.Add(prep + queueDelay)
+	case model.StatusBeingPrepared:
+		if started := statusReachedAt(order, model.StatusBeingPrepared); started != nil && started.Add(prep).After(now) {
+			readyAt = started.Add(prep)
+		}
+	}
+	estimate.PreparationMinutes = readyAt.Sub(now).Minutes()
+
+	// when an agent has the food
+	pickedUpAt := now
+	var toPickup time.Duration
+	if order.Status != model.StatusOutForDelivery {
+		if agent == nil {
+			pickedUpAt = maxTime(readyAt, now.Add(config.PickupAllowance()))
+		} else {
+			km := model.DistanceKm(*agent, *pickup)
+			estimate.TravelKm += km
+			toPickup = travelTime(km)
+			pickedUpAt = maxTime(readyAt, now.Add(toPickup))
+		}
+	}
+
+	// when the food reaches the customer
+	from := pickup
+	if order.Status == model.StatusOutForDelivery {
+		from = agent
+	}
+	deliveryLeg := config.DefaultDeliveryLeg()
+	if from != nil && order.DeliveryLocation != nil {
+		km := model.DistanceKm(*from, *order.DeliveryLocation)
+		estimate.TravelKm += km
+		deliveryLeg = travelTime(km)
+	}
+	estimate.TravelMinutes = (toPickup + deliveryLeg).Minutes()
+	estimate.EstimatedDeliveryTime = pickedUpAt.Add(deliveryLeg).Truncate(time.Second)
+	return estimate, nil
+}
+
+// pickupLocation returns the restaurant's coordinates, preferring the copy
+// dispatch cached on the order.
+func pickupLocation(ctx context.Context, order *model.Order) (*model.GeoPoint, error) {
+	if order.Dispatch != nil && order.Dispatch.Pickup != nil {
+		return order.Dispatch.Pickup, nil
+	}
+	location, err := queue.GetRestaurantLocation(ctx, order.RestaurantID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.GeoPoint{Latitude: location.Latitude, Longitude: location.Longitude}, nil
+}
+
+func travelTime(km float64) time.Duration {
+	hours := km / math.Max(config.AgentSpeedKmh(), 1)
+	return time.Duration(hours * float64(time.Hour))
+}
+
+func statusReachedAt(order *model.Order, status string) *time.Time {
+	for i := len(order.StatusHistory) - 1; i >= 0; i-- {
+		if order.StatusHistory[i].To == status {
+			return &order.StatusHistory[i].ChangedAt
+		}
+	}
+	return nil
+}
+
+func maxTime(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// RefreshETA recomputes and stores the ETA of an order that is still open.
+// Location driven estimates that barely move the ETA are dropped.
+func RefreshETA(ctx context.Context, order *model.Order, trigger string, agent *model.GeoPoint) error {
+	if model.IsTerminal(order.Status) {
+		return nil
+	}
+	estimate, err := EstimateDelivery(ctx, order, agent)
+	if err != nil {
+		return err
+	}
+	estimate.Trigger = trigger
+	if trigger == model.ETATriggerLocation && order.EstimatedDeliveryTime != nil {
+		change := estimate.EstimatedDeliveryTime.Sub(*order.EstimatedDeliveryTime)
+		if change.Abs() < etaChangeThreshold {
+			return nil
+		}
+	}
+	return repository.RecordETA(ctx, order, *estimate)
+}
+
+// HandleOrderEventForETA recomputes an order's ETA when it is created,
+// changes status or is assigned an agent.
+func HandleOrderEventForETA(event queue.ReceivedOrderEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	order, err := repository.FindOrder(ctx, event.OrderId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var agent *model.GeoPoint
+	if order.DeliveryAgentID != nil {
+		location, err := queue.GetOrderLocation(ctx, *order.DeliveryAgentID)
+		if err != nil {
+			log.Printf("Estimating order %d without its agent's location: %v\n", order.OrderId, err)
+		} else {
+			agent = &model.GeoPoint{Latitude: location.Latitude, Longitude: location.Longitude}
+		}
+	}
+	trigger := model.ETATriggerStatus
+	if event.Type == model.EventOrderAgentAssigned {
+		trigger = model.ETATriggerAgent
+	}
+	return RefreshETA(ctx, order, trigger, agent)
+}
+
+// HandleAgentLocationForETA recomputes the ETA of every order the agent is
+// carrying, at most once per ETALocationInterval per order.
+func HandleAgentLocationForETA(update queue.AgentLocationUpdate) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	orders, err := repository.FindActiveOrdersForAgent(ctx, update.AgentID)
+	if err != nil {
+		return err
+	}
+	agent := &model.GeoPoint{Latitude: update.Latitude, Longitude: update.Longitude}
+	for i := range orders {
+		order := &orders[i]
+		if n := len(order.ETAHistory); n > 0 && time.Since(order.ETAHistory[n-1].ComputedAt) < config.ETALocationInterval() {
+			continue
+		}
+		if err := RefreshETA(ctx, order, model.ETATriggerLocation, agent); err != nil {
+			log.Printf("Error refreshing ETA of order %d: %v\n", order.OrderId, err)
+		}
+	}
+	return nil
+}
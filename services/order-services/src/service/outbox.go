@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"log"
+	"order-service/src/config"
+	"order-service/src/model"
+	"order-service/src/queue"
+	"order-service/src/repository"
+	"time"
+)
+
+const outboxBatchSize = 100
+
+// RelayOutbox publishes pending outbox events in the order they were
+// written and marks each one sent once the broker confirms it. An event is
+// published again if the process stops between the confirm and the update,
+// so delivery is at least once. An event that fails to publish is retried
+// with a growing delay and parked after OutboxMaxAttempts; until then the
+// later events of its order wait, so they are never published ahead of it,
+// while the events of other orders go on.
+func RelayOutbox(ctx context.Context) error {
+	now := time.Now()
+	waiting, err := repository.FindRetryingOutboxOrders(ctx, now)
+	if err != nil {
+		return err
+	}
+	for {
+		events, err := repository.FindPendingOutboxEvents(ctx, now, waiting, outboxBatchSize)
+		if err != nil {
+			return err
+		}
+		held := map[int]bool{}
+		for _, event := range events {
+			if held[event.OrderId] {
+				continue
+			}
+			if err := publishOutboxEvent(ctx, event); err != nil {
+				held[event.OrderId] = true
+				waiting = append(waiting, event.OrderId)
+				next := nextOutboxAttempt(event.Attempts + 1)
+				if next == nil {
+					log.Printf("Parking outbox event %s after %d attempts: %v\n", event.EventId, event.Attempts+1, err)
+				} else {
+					log.Printf("Error publishing outbox event %s, retrying at %s: %v\n", event.EventId, next.Format(time.RFC3339), err)
+				}
+				if err := repository.MarkOutboxFailed(ctx, event.EventId, err.Error(), next); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := repository.MarkOutboxSent(ctx, event.EventId); err != nil {
+				return err
+			}
+		}
+		if len(events) < outboxBatchSize {
+			return nil
+		}
+	}
+}
+
+func publishOutboxEvent(ctx context.Context, event model.OutboxEvent) error {
+	publishCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if event.Queue != "" {
+		return queue.PublishToQueue(publishCtx, event.Queue, event.EventId, event.Payload)
+	}
+	exchange, routingKey := event.Exchange, event.RoutingKey
+	if exchange == "" {
+		exchange = queue.OrderEventsExchange()
+	}
+	if routingKey == "" {
+		routingKey = event.Type
+	}
+	return queue.PublishEvent(publishCtx, exchange, routingKey, event.EventId, event.Payload)
+}
+
+// nextOutboxAttempt is when an event that has failed attempts times is
+// published again, or nil once it has used up its attempts.
+func nextOutboxAttempt(attempts int) *time.Time {
+	if attempts >= config.OutboxMaxAttempts() {
+		return nil
+	}
+	delay := 5 * time.Second << (attempts - 1)
+	if delay > 10*time.Minute || delay <= 0 {
+		delay = 10 * time.Minute
+	}
+	next := time.Now().Add(delay)
+	return &next
+}
+
+// RunOutboxRelay calls RelayOutbox every interval. It never returns.
+func RunOutboxRelay(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := RelayOutbox(context.Background()); err != nil {
+			log.Println("Error relaying outbox events:", err)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"order-service/src/config"
+	"order-service/src/model"
+	"order-service/src/queue"
+	"order-service/src/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NewPaymentId returns the id to store on an order before it is inserted.
+func NewPaymentId() string {
+	return uuid.New().String()
+}
+
+// NewPayment builds the pending payment for an order about to be inserted;
+// InsertOrder stores it with the order and fills in its order number.
+// Prepaid payments expire if they are not settled in time, COD payments stay
+// pending until the order is delivered.
+func NewPayment(order *model.Order) *model.Payment {
+	now := time.Now()
+	payment := &model.Payment{
+		PaymentId:  order.PaymentId,
+		CustomerID: order.CustomerID,
+		Amount:     order.TotalPrice,
+		Mode:       order.PaymentMode,
+		Status:     model.PaymentStatusPending,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if model.IsPrepaid(order.PaymentMode) {
+		expiresAt := now.Add(config.PaymentExpiry())
+		payment.ExpiresAt = &expiresAt
+	}
+	return payment
+}
+
+// HandlePaymentEvent settles the payment named by event. A failed payment
+// cancels its order as it is settled; a successful one leaves the order
+// pending until the restaurant accepts it. Events for payments that are
+// already settled are ignored.
+func HandlePaymentEvent(event queue.PaymentEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var status string
+	switch event.Status {
+	case model.PaymentStatusSucceeded, model.PaymentStatusFailed:
+		status = event.Status
+	default:
+		log.Printf("Ignoring payment event with unknown status %q\n", event.Status)
+		return nil
+	}
+
+	payment, err := repository.SettlePayment(ctx, event.PaymentId, status, event.ProviderRef, event.Reason)
+	if errors.Is(err, repository.ErrPaymentSettled) {
+		log.Println("Ignoring event for settled payment:", event.PaymentId)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if status == model.PaymentStatusFailed {
+		log.Printf("Payment for order %d failed\n", payment.OrderId)
+		return nil
+	}
+
+	order, err := repository.FindOrder(ctx, payment.OrderId)
+	if err != nil {
+		return err
+	}
+	// SettlePayment has sent the order to the restaurant, which confirms it
+	if order.Status == model.StatusCancelled {
+		// the order was cancelled while the payment was in flight
+		return RefundCancelledOrder(ctx, order, "payment received after the order was cancelled", model.ActorSystem)
+	}
+	return nil
+}
+
+// ExpirePayments cancels prepaid orders whose payment window has closed.
+func ExpirePayments(ctx context.Context) error {
+	payments, err := repository.FindExpiredPayments(ctx, time.Now(), 100)
+	if err != nil {
+		return err
+	}
+	for _, expired := range payments {
+		_, err := repository.SettlePayment(ctx, expired.PaymentId, model.PaymentStatusExpired, "", "payment window expired")
+		if errors.Is(err, repository.ErrPaymentSettled) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		log.Printf("Cancelled order %d after its payment expired\n", expired.OrderId)
+	}
+	return nil
+}
+
+// RunPaymentExpirySweeper calls ExpirePayments every interval. It never returns.
+func RunPaymentExpirySweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		if err := ExpirePayments(ctx); err != nil {
+			log.Println("Error expiring payments:", err)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"order-service/src/config"
+	"order-service/src/model"
+	"order-service/src/queue"
+	"order-service/src/repository"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// RefundLine selects a quantity of one order line for a partial refund.
+type RefundLine struct {
+	LineIndex int
+	Quantity  int
+}
+
+// RefundError reports a refund request that cannot be honoured as asked.
+type RefundError struct {
+	Message string
+}
+
+func (e *RefundError) Error() string {
+	return e.Message
+}
+
+// RequestRefund refunds the given lines of order, or everything not yet
+// refunded when lines is empty, and hands the refund to the payment service.
+func RequestRefund(ctx context.Context, order *model.Order, lines []RefundLine, reason string, actor model.Actor) (*model.Refund, error) {
+	payment, err := capturedPayment(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		return nil, &RefundError{Message: "Order has no captured payment to refund"}
+	}
+
+	amount := order.TotalPrice - order.RefundedAmount
+	var items []model.RefundItem
+	if len(lines) > 0 {
+		items, err = refundItems(ctx, order, lines)
+		if err != nil {
+			return nil, err
+		}
+		amount = 0
+		for _, item := range items {
+			amount += item.Amount
+		}
+	}
+	amount = roundMoney(amount)
+	if amount <= 0 {
+		return nil, &RefundError{Message: "Nothing left to refund on this order"}
+	}
+
+	now := time.Now()
+	refund := &model.Refund{
+		RefundId:    uuid.New().String(),
+		OrderId:     order.OrderId,
+		PaymentId:   payment.PaymentId,
+		Amount:      amount,
+		Reason:      reason,
+		Status:      model.RefundRequested,
+		Items:       items,
+		RequestedBy: actor,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	err = repository.InsertRefund(ctx, order, refund)
+	if errors.Is(err, repository.ErrRefundExceedsPayment) {
+		return nil, &RefundError{Message: "Refund exceeds the amount paid for this order"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dispatchRefund(ctx, refund); err != nil {
+		// the refund is stored; the retrier will send it
+		log.Println("Error sending refund request:", err)
+	}
+	return refund, nil
+}
+
+// RefundCancelledOrder refunds whatever is left of a prepaid order's
+// payment. Orders that were never paid online are left alone.
+func RefundCancelledOrder(ctx context.Context, order *model.Order, reason string, actor model.Actor) error {
+	payment, err := capturedPayment(ctx, order)
+	if err != nil || payment == nil {
+		return err
+	}
+	if order.TotalPrice-order.RefundedAmount <= 0 {
+		return nil
+	}
+	_, err = RequestRefund(ctx, order, nil, reason, actor)
+	return err
+}
+
+// capturedPayment returns the order's payment if it was collected online.
+func capturedPayment(ctx context.Context, order *model.Order) (*model.Payment, error) {
+	if order.PaymentId == "" || !model.IsPrepaid(order.PaymentMode) {
+		return nil, nil
+	}
+	payment, err := repository.FindPayment(ctx, order.PaymentId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != model.PaymentStatusSucceeded {
+		return nil, nil
+	}
+	return payment, nil
+}
+
+// refundItems prices lines at what the customer paid for them and checks
+// they do not refund more of a line than was ordered.
+func refundItems(ctx context.Context, order *model.Order, lines []RefundLine) ([]model.RefundItem, error) {
+	previous, err := repository.FindRefundsByOrder(ctx, order.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	refunded := map[int]int{}
+	for _, refund := range previous {
+		if refund.GivenUp() {
+			continue
+		}
+		if len(refund.Items) == 0 {
+			return nil, &RefundError{Message: "Order was already refunded in full"}
+		}
+		for _, item := range refund.Items {
+			refunded[item.LineIndex] += item.Quantity
+		}
+	}
+
+	var items []model.RefundItem
+	for _, line := range lines {
+		if line.LineIndex < 0 || line.LineIndex >= len(order.Orders) {
+			return nil, &RefundError{Message: fmt.Sprintf("Order has no line %d", line.LineIndex)}
+		}
+		ordered := order.Orders[line.LineIndex]
+		if line.Quantity < 1 || refunded[line.LineIndex]+line.Quantity > ordered.Quantity {
+			return nil, &RefundError{Message: fmt.Sprintf("Cannot refund %d more of line %d", line.Quantity, line.LineIndex)}
+		}
+		refunded[line.LineIndex] += line.Quantity
+		items = append(items, model.RefundItem{
+			LineIndex: line.LineIndex,
+			Quantity:  line.Quantity,
+			Amount:    roundMoney(ordered.Price / float32(ordered.Quantity) * float32(line.Quantity)),
+		})
+	}
+	return items, nil
+}
+
+// dispatchRefund sends refund to the payment service through the outbox.
+// A refund left unanswered for the processing timeout is sent again by the
+// retrier.
+func dispatchRefund(ctx context.Context, refund *model.Refund) error {
+	staleBefore := time.Now().Add(-config.RefundProcessingTimeout())
+	err := repository.MarkRefundProcessing(ctx, refund, staleBefore)
+	if errors.Is(err, repository.ErrRefundNotPending) {
+		return nil
+	}
+	return err
+}
+
+// nextRefundAttempt backs off exponentially from one minute up to an hour,
+// and returns nil once the refund has used all its attempts.
+func nextRefundAttempt(attempts int) *time.Time {
+	if attempts >= config.RefundMaxAttempts() {
+		return nil
+	}
+	delay := time.Minute << (attempts - 1)
+	if delay > time.Hour || delay <= 0 {
+		delay = time.Hour
+	}
+	next := time.Now().Add(delay)
+	return &next
+}
+
+// HandleRefundEvent records the outcome the payment service reported.
+func HandleRefundEvent(event queue.RefundEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var next *time.Time
+	switch event.Status {
+	case model.RefundCompleted:
+	case model.RefundFailed:
+		refund, err := repository.FindRefund(ctx, event.RefundId)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			log.Println("Ignoring event for unknown refund:", event.RefundId)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		next = nextRefundAttempt(refund.Attempts)
+	default:
+		log.Printf("Ignoring refund event with unknown status %q\n", event.Status)
+		return nil
+	}
+
+	refund, err := repository.SettleRefund(ctx, event.RefundId, event.Status, event.ProviderRef, event.Reason, next)
+	if errors.Is(err, repository.ErrRefundNotPending) {
+		log.Println("Ignoring event for refund that is not processing:", event.RefundId)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if refund.Status == model.RefundFailed && next == nil {
+		log.Printf("Refund %s for order %d failed permanently: %s\n", refund.RefundId, refund.OrderId, event.Reason)
+	}
+	return nil
+}
+
+// RetryRefunds resends refunds that never went out, are due another attempt
+// or went unanswered. An unanswered refund that has used all its attempts
+// is given up on instead.
+func RetryRefunds(ctx context.Context) error {
+	now := time.Now()
+	refunds, err := repository.FindRetryableRefunds(ctx, now, now.Add(-config.RefundProcessingTimeout()), 100)
+	if err != nil {
+		return err
+	}
+	for i := range refunds {
+		refund := &refunds[i]
+		if refund.Status == model.RefundProcessing && refund.Attempts >= config.RefundMaxAttempts() {
+			_, err := repository.SettleRefund(ctx, refund.RefundId, model.RefundFailed, "", "payment service did not answer", nil)
+			if err == nil {
+				log.Printf("Refund %s for order %d failed permanently: payment service did not answer\n", refund.RefundId, refund.OrderId)
+			} else if !errors.Is(err, repository.ErrRefundNotPending) {
+				log.Println("Error giving up on refund:", err)
+			}
+			continue
+		}
+		if err := dispatchRefund(ctx, refund); err != nil {
+			log.Println("Error retrying refund:", err)
+		}
+	}
+	return nil
+}
+
+// RunRefundRetrier calls RetryRefunds every interval. It never returns.
+func RunRefundRetrier(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		if err := RetryRefunds(ctx); err != nil {
+			log.Println("Error retrying refunds:", err)
+		}
+		cancel()
+	}
+}
+
+func roundMoney(amount float32) float32 {
+	return float32(math.Round(float64(amount)*100) / 100)
+}
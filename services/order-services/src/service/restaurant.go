@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"order-service/src/model"
+	"order-service/src/queue"
+	"order-service/src/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// ErrAwaitingPayment is returned when a restaurant tries to accept a prepaid
+// order that has not been paid for.
+var ErrAwaitingPayment = errors.New("order has not been paid yet")
+
+// AcceptOrder confirms a pending order on behalf of its restaurant.
+func AcceptOrder(ctx context.Context, order *model.Order) error {
+	if order.RestaurantNotifiedAt == nil {
+		return ErrAwaitingPayment
+	}
+	restaurantID := order.RestaurantID
+	return repository.TransitionStatus(ctx, order, model.StatusConfirmed, model.ActorRestaurant, &restaurantID)
+}
+
+// RejectOrder cancels a pending order on behalf of its restaurant and
+// refunds the customer if they already paid.
+func RejectOrder(ctx context.Context, order *model.Order, reason string) error {
+	restaurantID := order.RestaurantID
+	if err := repository.TransitionStatus(ctx, order, model.StatusCancelled, model.ActorRestaurant, &restaurantID); err != nil {
+		return err
+	}
+	if reason == "" {
+		reason = "rejected by restaurant"
+	}
+	return RefundCancelledOrder(ctx, order, reason, model.ActorRestaurant)
+}
+
+// HandleRestaurantResponse applies a restaurant's answer to a new order.
+// Responses for orders that are no longer pending, for example because they
+// were rejected automatically, are ignored.
+func HandleRestaurantResponse(response queue.RestaurantResponse) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	order, err := repository.FindOrder(ctx, response.OrderId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		log.Println("Ignoring restaurant response for unknown order:", response.OrderId)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if order.RestaurantID != response.RestaurantID {
+		log.Printf("Ignoring response for order %d from restaurant %s\n", order.OrderId, response.RestaurantID.Hex())
+		return nil
+	}
+	if order.Status != model.StatusPending {
+		log.Printf("Ignoring restaurant response for order %d, which is %s\n", order.OrderId, order.Status)
+		return nil
+	}
+
+	if response.Accepted {
+		err = AcceptOrder(ctx, order)
+	} else {
+		err = RejectOrder(ctx, order, response.Reason)
+	}
+	if errors.Is(err, ErrAwaitingPayment) || errors.Is(err, repository.ErrIllegalTransition) {
+		log.Printf("Ignoring restaurant response for order %d: %v\n", order.OrderId, err)
+		return nil
+	}
+	// a status conflict is requeued and re-evaluated against the new status
+	return err
+}
+
+// RejectUnansweredOrders cancels pending orders whose restaurant did not
+// respond in time and refunds any payment.
+func RejectUnansweredOrders(ctx context.Context) error {
+	orders, err := repository.FindUnansweredOrders(ctx, time.Now(), 100)
+	if err != nil {
+		return err
+	}
+	for i := range orders {
+		order := &orders[i]
+		err := repository.TransitionStatus(ctx, order, model.StatusCancelled, model.ActorSystem, nil)
+		if errors.Is(err, repository.ErrStatusConflict) {
+			// answered in the meantime; the next sweep re-checks it if still pending
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		log.Printf("Rejected order %d after the restaurant did not respond\n", order.OrderId)
+		if err := RefundCancelledOrder(ctx, order, "restaurant did not respond in time", model.ActorSystem); err != nil {
+			log.Println("Error refunding rejected order:", err)
+		}
+	}
+	return nil
+}
+
+// RunRestaurantResponseSweeper calls RejectUnansweredOrders every interval.
+// It never returns.
+func RunRestaurantResponseSweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		if err := RejectUnansweredOrders(ctx); err != nil {
+			log.Println("Error rejecting unanswered orders:", err)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"order-service/src/model"
+	"order-service/src/queue"
+
+	"github.com/google/uuid"
+)
+
+// StockError reports that dish-service does not have enough stock for an
+// order.
+type StockError struct {
+	Message string
+}
+
+func (e *StockError) Error() string {
+	return e.Message
+}
+
+// NewStockReservationId returns the id to reserve an order's stock under
+// before the order is inserted.
+func NewStockReservationId() string {
+	return uuid.New().String()
+}
+
+// ReserveStock holds the stock for every dish in lines, counting each combo
+// component once per combo ordered. It fails with a StockError when any of
+// them has too little left, in which case nothing is held.
+func ReserveStock(ctx context.Context, reservationId string, lines []model.SingleOrder) error {
+	var items []queue.StockItem
+	for _, line := range lines {
+		if line.ComboID == nil {
+			items = append(items, queue.StockItem{DishID: line.DishID, VariantID: line.VariantID, Quantity: line.Quantity})
+			continue
+		}
+		for _, component := range line.Components {
+			items = append(items, queue.StockItem{
+				DishID:    component.DishID,
+				VariantID: component.VariantID,
+				Quantity:  component.Quantity * line.Quantity,
+			})
+		}
+	}
+	reply, err := queue.ReserveStock(ctx, queue.StockReservationRequest{ReservationId: reservationId, Items: items})
+	if err != nil {
+		return err
+	}
+	if !reply.Reserved {
+		return &StockError{Message: reply.Message}
+	}
+	return nil
+}
+
+// ReleaseStock gives back stock reserved for an order that was never
+// created. Orders that exist release theirs through the outbox when they
+// are cancelled.
+func ReleaseStock(ctx context.Context, reservationId string, reason string) error {
+	message := queue.StockReleaseMessage{
+		MessageId:     uuid.New().String(),
+		ReservationId: reservationId,
+		Reason:        reason,
+	}
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return queue.PublishEvent(ctx, queue.StockExchange(), queue.StockReleaseKey, message.MessageId, body)
+}
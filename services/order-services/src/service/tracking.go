@@ -0,0 +1,237 @@
+package service
+
+import (
+	"encoding/json"
+	"log"
+	"order-service/src/model"
+	"order-service/src/queue"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tracking update types.
+const (
+	TrackingLocation      = "location"
+	TrackingStatus        = "status"
+	TrackingAgentAssigned = "agent_assigned"
+	TrackingETA           = "eta"
+)
+
+// TrackingUpdate is one message pushed to watchers of an order.
+type TrackingUpdate struct {
+	Type    string    `json:"type"`
+	OrderId int       `json:"orderId"`
+	Data    any       `json:"data"`
+	At      time.Time `json:"at"`
+}
+
+type TrackingStatusData struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type TrackingLocationData struct {
+	AgentID   primitive.ObjectID `json:"agentId"`
+	Latitude  float64            `json:"latitude"`
+	Longitude float64            `json:"longitude"`
+	UpdatedAt time.Time          `json:"updatedAt"`
+}
+
+// watcherBuffer is how many updates a slow watcher may fall behind by
+// before further updates are dropped for it.
+const watcherBuffer = 16
+
+// trackedOrder is the shared subscription for one watched order.
+type trackedOrder struct {
+	agentID  *primitive.ObjectID
+	watchers map[chan TrackingUpdate]struct{}
+}
+
+// Tracker fans order updates out to everyone watching an order. Each
+// watched order holds one binding to its agent's location updates no matter
+// how many watchers it has, and the binding is dropped with the last one.
+type Tracker struct {
+	mu         sync.Mutex
+	orders     map[int]*trackedOrder
+	subscriber *queue.Subscriber
+}
+
+var (
+	tracker     *Tracker
+	trackerOnce sync.Once
+)
+
+// OrderTracker returns the process wide tracker, starting its subscription
+// on first use.
+func OrderTracker() *Tracker {
+	trackerOnce.Do(func() {
+		tracker = &Tracker{orders: map[int]*trackedOrder{}}
+		tracker.subscriber = queue.Subscribe(tracker.dispatch)
+		for _, eventType := range []string{
+			model.EventOrderStatusChanged,
+			model.EventOrderCancelled,
+			model.EventOrderAgentAssigned,
+			model.EventOrderETAUpdated,
+		} {
+			tracker.subscriber.Bind(queue.OrderEventsExchange(), eventType)
+		}
+	})
+	return tracker
+}
+
+// Watch subscribes to updates for order. The returned channel is closed
+// once the order reaches a terminal status; call stop when done watching.
+func (t *Tracker) Watch(order *model.Order) (updates <-chan TrackingUpdate, stop func()) {
+	ch := make(chan TrackingUpdate, watcherBuffer)
+
+	t.mu.Lock()
+	tracked, ok := t.orders[order.OrderId]
+	if !ok {
+		tracked = &trackedOrder{watchers: map[chan TrackingUpdate]struct{}{}}
+		t.orders[order.OrderId] = tracked
+		if order.DeliveryAgentID != nil {
+			t.followAgent(tracked, *order.DeliveryAgentID)
+		}
+	}
+	tracked.watchers[ch] = struct{}{}
+	t.mu.Unlock()
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() { t.unwatch(order.OrderId, ch) })
+	}
+	return ch, stop
+}
+
+func (t *Tracker) unwatch(orderId int, ch chan TrackingUpdate) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tracked, ok := t.orders[orderId]
+	if !ok {
+		return
+	}
+	if _, ok := tracked.watchers[ch]; !ok {
+		return
+	}
+	delete(tracked.watchers, ch)
+	close(ch)
+	if len(tracked.watchers) == 0 {
+		if tracked.agentID != nil {
+			t.subscriber.Unbind(queue.AgentLocationExchange(), queue.AgentRoutingKey(*tracked.agentID))
+		}
+		delete(t.orders, orderId)
+	}
+}
+
+// followAgent binds to agentID's location updates for tracked. t.mu must be held.
+func (t *Tracker) followAgent(tracked *trackedOrder, agentID primitive.ObjectID) {
+	if tracked.agentID != nil {
+		if *tracked.agentID == agentID {
+			return
+		}
+		t.subscriber.Unbind(queue.AgentLocationExchange(), queue.AgentRoutingKey(*tracked.agentID))
+	}
+	tracked.agentID = &agentID
+	t.subscriber.Bind(queue.AgentLocationExchange(), queue.AgentRoutingKey(agentID))
+}
+
+// broadcast sends update to every watcher of its order. t.mu must be held.
+func (t *Tracker) broadcast(tracked *trackedOrder, update TrackingUpdate) {
+	for ch := range tracked.watchers {
+		select {
+		case ch <- update:
+		default:
+			// watcher is not keeping up; it will get the next update
+		}
+	}
+}
+
+// dispatch routes a message from the subscription to the orders it concerns.
+func (t *Tracker) dispatch(exchange string, routingKey string, body []byte) {
+	if exchange == queue.AgentLocationExchange() {
+		t.dispatchLocation(body)
+		return
+	}
+
+	var event queue.ReceivedOrderEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		log.Println("Dropping malformed order event:", err)
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tracked, ok := t.orders[event.OrderId]
+	if !ok {
+		return
+	}
+	update := TrackingUpdate{OrderId: event.OrderId, At: event.OccurredAt}
+	switch event.Type {
+	case model.EventOrderStatusChanged, model.EventOrderCancelled:
+		var data queue.OrderStatusChangedData
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			log.Println("Dropping malformed status event:", err)
+			return
+		}
+		update.Type = TrackingStatus
+		update.Data = TrackingStatusData{From: data.From, To: data.To}
+		t.broadcast(tracked, update)
+		if model.IsTerminal(data.To) {
+			t.closeOrder(event.OrderId, tracked)
+		}
+	case model.EventOrderAgentAssigned:
+		var data queue.OrderAgentAssignedData
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			log.Println("Dropping malformed assignment event:", err)
+			return
+		}
+		t.followAgent(tracked, data.DeliveryAgentID)
+		update.Type = TrackingAgentAssigned
+		update.Data = event.Data
+		t.broadcast(tracked, update)
+	case model.EventOrderETAUpdated:
+		update.Type = TrackingETA
+		update.Data = event.Data
+		t.broadcast(tracked, update)
+	}
+}
+
+func (t *Tracker) dispatchLocation(body []byte) {
+	var location queue.AgentLocationUpdate
+	if err := json.Unmarshal(body, &location); err != nil {
+		log.Println("Dropping malformed location update:", err)
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for orderId, tracked := range t.orders {
+		if tracked.agentID == nil || *tracked.agentID != location.AgentID {
+			continue
+		}
+		t.broadcast(tracked, TrackingUpdate{
+			Type:    TrackingLocation,
+			OrderId: orderId,
+			Data: TrackingLocationData{
+				AgentID:   *tracked.agentID,
+				Latitude:  location.Latitude,
+				Longitude: location.Longitude,
+				UpdatedAt: location.UpdatedAt,
+			},
+			At: location.UpdatedAt,
+		})
+	}
+}
+
+// closeOrder ends every watch of a finished order. t.mu must be held.
+func (t *Tracker) closeOrder(orderId int, tracked *trackedOrder) {
+	for ch := range tracked.watchers {
+		close(ch)
+	}
+	if tracked.agentID != nil {
+		t.subscriber.Unbind(queue.AgentLocationExchange(), queue.AgentRoutingKey(*tracked.agentID))
+	}
+	delete(t.orders, orderId)
+}